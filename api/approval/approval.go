@@ -0,0 +1,14 @@
+package approval
+
+import (
+	"context"
+	v1 "github.com/NuyoahCh/eocall/api/approval/v1"
+)
+
+// IApprovalV1 工具调用审批接口
+type IApprovalV1 interface {
+	List(ctx context.Context, req *v1.ListReq) (res *v1.ListRes, err error)
+	Approve(ctx context.Context, req *v1.ApproveReq) (res *v1.ApproveRes, err error)
+	Reject(ctx context.Context, req *v1.RejectReq) (res *v1.RejectRes, err error)
+	Resume(ctx context.Context, req *v1.ResumeReq) (res *v1.ResumeRes, err error)
+}
@@ -0,0 +1,53 @@
+package v1
+
+import "github.com/gogf/gf/v2/frame/g"
+
+type Approval struct {
+	Id        string `json:"id" dc:"审批单 ID"`
+	Tool      string `json:"tool" dc:"等待审批的工具"`
+	Arguments string `json:"arguments" dc:"模型生成的工具调用参数"`
+	Requester string `json:"requester" dc:"发起对话的用户"`
+	SessionId string `json:"sessionId" dc:"对话会话 ID"`
+	Status    string `json:"status" dc:"审批状态：pending、approved、rejected、expired"`
+	Approver  string `json:"approver" dc:"审批人"`
+	Reason    string `json:"reason" dc:"审批意见"`
+	CreatedAt string `json:"createdAt" dc:"创建时间"`
+	ExpiresAt string `json:"expiresAt" dc:"超时时间，超时视为拒绝"`
+}
+
+type ListReq struct {
+	g.Meta `path:"/approval" method:"get" summary:"待审批的工具调用列表"`
+}
+
+type ListRes struct {
+	List []*Approval `json:"list"`
+}
+
+type ApproveReq struct {
+	g.Meta `path:"/approval/approve" method:"post" summary:"批准工具调用，对话从中断处继续执行"`
+	Id     string `json:"id" v:"required" dc:"审批单 ID"`
+	Reason string `json:"reason" dc:"审批意见"`
+}
+
+type ApproveRes struct {
+	Approval *Approval `json:"approval"`
+}
+
+type RejectReq struct {
+	g.Meta `path:"/approval/reject" method:"post" summary:"拒绝工具调用，对话终止执行"`
+	Id     string `json:"id" v:"required" dc:"审批单 ID"`
+	Reason string `json:"reason" dc:"审批意见"`
+}
+
+type RejectRes struct {
+	Approval *Approval `json:"approval"`
+}
+
+type ResumeReq struct {
+	g.Meta `path:"/approval/resume" method:"post" summary:"审批结束后继续挂起的同步对话，批准则从中断处执行，拒绝或超时则终止"`
+	Id     string `json:"id" v:"required" dc:"同步对话挂起时返回的任一审批单 ID"`
+}
+
+type ResumeRes struct {
+	Result any `json:"result" dc:"对话的回复，格式同 /api/chat 的响应；运行再次等待审批时包含新的 approvals"`
+}
@@ -3,26 +3,89 @@ package v1
 import "github.com/gogf/gf/v2/frame/g"
 
 type ChatReq struct {
-	g.Meta   `path:"/chat" method:"post" summary:"对话"`
-	Id       string
-	Question string
+	g.Meta         `path:"/chat" method:"post" summary:"对话"`
+	Id             string
+	Question       string
+	KnowledgeBase  string   `json:"knowledge_base" dc:"检索的知识库，为空时使用调用方团队绑定的知识库或默认知识库"`
+	TopK           int      `json:"top_k" dc:"检索返回的文档数量，为 0 时使用配置 retrieval.top_k"`
+	ScoreThreshold *float64 `json:"score_threshold" dc:"最低相似度，低于该值的文档不会注入提示词，为空时使用配置 retrieval.score_threshold"`
+	Debug          bool     `json:"debug" dc:"是否返回调试信息"`
+	Tools          []string `json:"tools" dc:"允许智能体使用的工具名，支持通配符；不传表示不限制，空数组表示不使用工具。实际可用的工具还受服务端 tool_policy 限制"`
 }
 
 type ChatRes struct {
-	Answer string `json:"answer"`
+	Answer    string          `json:"answer"`
+	Route     string          `json:"route" dc:"意图路由选择的处理路线：small_talk | knowledge | incident | agent"`
+	Tools     []string        `json:"tools" dc:"本次回答中智能体可以使用的工具"`
+	Sources   []*ChatSource   `json:"sources" dc:"检索到并注入提示词的文档，按编号排列"`
+	Uncited   bool            `json:"uncited" dc:"回答未引用任何文档，可能并非来自内部文档"`
+	Grounded  bool            `json:"grounded" dc:"是否检索到足以作为回答依据的内部文档"`
+	Debug     *ChatDebug      `json:"debug,omitempty" dc:"调试信息，请求开启 debug 时返回"`
+	Approvals []*ChatApproval `json:"approvals,omitempty" dc:"等待审批的工具调用，非空时回答尚未生成，审批结束后通过 /api/approval/resume 继续对话并获取回答"`
+}
+
+// ChatApproval 对话挂起时等待审批的工具调用
+type ChatApproval struct {
+	Id        string `json:"id" dc:"审批单 ID"`
+	Tool      string `json:"tool" dc:"等待审批的工具"`
+	Arguments string `json:"arguments" dc:"模型生成的工具调用参数"`
+	ExpiresAt string `json:"expiresAt" dc:"超时时间，超时视为拒绝"`
+}
+
+// ChatSource 回答的引用来源
+type ChatSource struct {
+	Index      int     `json:"index" dc:"文档编号，对应回答中的 [编号]"`
+	Source     string  `json:"_source" dc:"文档来源文件"`
+	Title      string  `json:"title" dc:"文档标题"`
+	HeaderPath string  `json:"headerPath" dc:"标题路径"`
+	ChunkId    string  `json:"chunkId" dc:"文档切片 ID"`
+	Score      float64 `json:"score" dc:"检索或重排分值"`
+	Cited      bool    `json:"cited" dc:"回答中是否引用了该文档"`
+}
+
+// ChatSources 流式对话结束时推送的 sources 事件
+type ChatSources struct {
+	Sources  []*ChatSource `json:"sources"`
+	Uncited  bool          `json:"uncited"`
+	Grounded bool          `json:"grounded"`
+}
+
+// ChatDebug 对话调试信息
+type ChatDebug struct {
+	RewrittenQuery string      `json:"rewrittenQuery" dc:"结合历史改写后的检索查询"`
+	SubQueries     []string    `json:"subQueries,omitempty" dc:"拆分出的子查询"`
+	Budget         *ChatBudget `json:"budget,omitempty" dc:"提示词预算的使用情况"`
+}
+
+// ChatBudget 提示词预算的使用情况，token 数为估算值
+type ChatBudget struct {
+	Profile            string   `json:"profile" dc:"对话模型的配置节点"`
+	ContextWindow      int      `json:"contextWindow" dc:"模型上下文窗口"`
+	Reserved           int      `json:"reserved" dc:"为模型输出与工具调用预留的 token"`
+	PromptTokens       int      `json:"promptTokens" dc:"裁剪后提示词的 token 数"`
+	DroppedDocuments   []string `json:"droppedDocuments,omitempty" dc:"因超出预算被丢弃的文档切片 ID"`
+	TruncatedDocuments []string `json:"truncatedDocuments,omitempty" dc:"因超出预算被截断的文档切片 ID"`
+	DroppedHistory     int      `json:"droppedHistory" dc:"被丢弃的较早历史消息条数"`
+	TruncatedHistory   int      `json:"truncatedHistory" dc:"被截断内容的最近历史消息条数"`
 }
 
 type ChatStreamReq struct {
-	g.Meta   `path:"/chat_stream" method:"post" summary:"流式对话"`
-	Id       string
-	Question string
+	g.Meta         `path:"/chat_stream" method:"post" summary:"流式对话"`
+	Id             string
+	Question       string
+	KnowledgeBase  string   `json:"knowledge_base" dc:"检索的知识库，为空时使用调用方团队绑定的知识库或默认知识库"`
+	TopK           int      `json:"top_k" dc:"检索返回的文档数量，为 0 时使用配置 retrieval.top_k"`
+	ScoreThreshold *float64 `json:"score_threshold" dc:"最低相似度，低于该值的文档不会注入提示词，为空时使用配置 retrieval.score_threshold"`
+	Debug          bool     `json:"debug" dc:"是否返回调试信息"`
+	Tools          []string `json:"tools" dc:"允许智能体使用的工具名，支持通配符；不传表示不限制，空数组表示不使用工具。实际可用的工具还受服务端 tool_policy 限制"`
 }
 
 type ChatStreamRes struct {
 }
 
 type FileUploadReq struct {
-	g.Meta `path:"/upload" method:"post" mime:"multipart/form-data" summary:"文件上传"`
+	g.Meta        `path:"/upload" method:"post" mime:"multipart/form-data" summary:"文件上传"`
+	KnowledgeBase string `json:"knowledge_base" dc:"写入的知识库，为空时使用调用方团队绑定的知识库或默认知识库"`
 }
 
 type FileUploadRes struct {
@@ -32,7 +95,8 @@ type FileUploadRes struct {
 }
 
 type AIOpsReq struct {
-	g.Meta `path:"/ai_ops" method:"post" summary:"AI运维"`
+	g.Meta        `path:"/ai_ops" method:"post" summary:"AI运维"`
+	KnowledgeBase string `json:"knowledge_base" dc:"查询处理方案的知识库，为空时使用调用方团队绑定的知识库或默认知识库"`
 }
 
 type AIOpsRes struct {
@@ -0,0 +1,13 @@
+package investigation
+
+import (
+	"context"
+	v1 "github.com/NuyoahCh/eocall/api/investigation/v1"
+)
+
+// IInvestigationV1 告警自动排查接口
+type IInvestigationV1 interface {
+	Webhook(ctx context.Context, req *v1.WebhookReq) (res *v1.WebhookRes, err error)
+	List(ctx context.Context, req *v1.ListReq) (res *v1.ListRes, err error)
+	Get(ctx context.Context, req *v1.GetReq) (res *v1.GetRes, err error)
+}
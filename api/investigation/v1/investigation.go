@@ -0,0 +1,69 @@
+package v1
+
+import "github.com/gogf/gf/v2/frame/g"
+
+// Alert Alertmanager webhook 推送的告警
+type Alert struct {
+	Status       string            `json:"status" dc:"告警状态：firing、resolved"`
+	Labels       map[string]string `json:"labels" dc:"告警标签"`
+	Annotations  map[string]string `json:"annotations" dc:"告警注解"`
+	StartsAt     string            `json:"startsAt" dc:"开始时间，RFC3339 格式"`
+	EndsAt       string            `json:"endsAt" dc:"结束时间，RFC3339 格式"`
+	GeneratorURL string            `json:"generatorURL" dc:"产生告警的规则地址"`
+	Fingerprint  string            `json:"fingerprint" dc:"告警指纹"`
+}
+
+type Investigation struct {
+	Id            string            `json:"id" dc:"排查 ID"`
+	GroupKey      string            `json:"groupKey" dc:"Alertmanager 告警分组的 groupKey"`
+	Receiver      string            `json:"receiver" dc:"告警分组的接收器"`
+	GroupLabels   map[string]string `json:"groupLabels" dc:"告警分组的分组标签"`
+	Alerts        []*Alert          `json:"alerts" dc:"开始排查时正在触发的告警"`
+	Status        string            `json:"status" dc:"排查状态：running、completed、failed"`
+	Report        string            `json:"report" dc:"排查报告"`
+	Detail        []string          `json:"detail,omitempty" dc:"各步骤的输出，仅详情接口返回"`
+	Error         string            `json:"error" dc:"排查失败的原因"`
+	Requester     string            `json:"requester" dc:"推送通知的调用方"`
+	KnowledgeBase string            `json:"knowledgeBase" dc:"排查使用的知识库，只有可以访问该知识库的调用方才能查看"`
+	CreatedAt     string            `json:"createdAt" dc:"开始排查的时间"`
+	FinishedAt    string            `json:"finishedAt" dc:"排查结束的时间"`
+	ResolvedAt    string            `json:"resolvedAt" dc:"告警分组恢复的时间"`
+}
+
+type WebhookReq struct {
+	g.Meta            `path:"/alertmanager/webhook" method:"post" summary:"接收 Alertmanager webhook 推送，新触发的告警分组自动排查"`
+	Version           string            `json:"version" dc:"webhook 消息版本"`
+	GroupKey          string            `json:"groupKey" v:"required" dc:"告警分组的唯一标识，用于去重"`
+	TruncatedAlerts   int               `json:"truncatedAlerts" dc:"超过 max_alerts 被截断的告警数"`
+	Status            string            `json:"status" dc:"分组状态：firing、resolved"`
+	Receiver          string            `json:"receiver" dc:"接收器名称"`
+	GroupLabels       map[string]string `json:"groupLabels" dc:"分组标签"`
+	CommonLabels      map[string]string `json:"commonLabels" dc:"分组内告警的公共标签"`
+	CommonAnnotations map[string]string `json:"commonAnnotations" dc:"分组内告警的公共注解"`
+	ExternalURL       string            `json:"externalURL" dc:"Alertmanager 的访问地址"`
+	Alerts            []*Alert          `json:"alerts" dc:"分组内的告警"`
+}
+
+type WebhookRes struct {
+	Outcome       string         `json:"outcome" dc:"处理结果：started、duplicate、resolved、ignored"`
+	Investigation *Investigation `json:"investigation" dc:"告警分组对应的排查，ignored 时为空"`
+}
+
+type ListReq struct {
+	g.Meta   `path:"/investigation" method:"get" summary:"告警自动排查列表，按开始时间从新到旧"`
+	GroupKey string `json:"groupKey" dc:"只列出该告警分组的排查"`
+	Status   string `json:"status" dc:"只列出该状态的排查"`
+}
+
+type ListRes struct {
+	List []*Investigation `json:"list"`
+}
+
+type GetReq struct {
+	g.Meta `path:"/investigation/detail" method:"get" summary:"告警自动排查详情，包含报告与各步骤的输出"`
+	Id     string `json:"id" v:"required" dc:"排查 ID"`
+}
+
+type GetRes struct {
+	Investigation *Investigation `json:"investigation"`
+}
@@ -0,0 +1,12 @@
+package knowledge
+
+import (
+	"context"
+	v1 "github.com/NuyoahCh/eocall/api/knowledge/v1"
+)
+
+// IKnowledgeV1 知识库管理接口
+type IKnowledgeV1 interface {
+	Create(ctx context.Context, req *v1.CreateReq) (res *v1.CreateRes, err error)
+	List(ctx context.Context, req *v1.ListReq) (res *v1.ListRes, err error)
+}
@@ -0,0 +1,29 @@
+package v1
+
+import "github.com/gogf/gf/v2/frame/g"
+
+type KnowledgeBase struct {
+	Name        string   `json:"name" dc:"知识库名称"`
+	Description string   `json:"description" dc:"知识库描述"`
+	Teams       []string `json:"teams" dc:"可访问的团队，为空表示所有调用方均可访问"`
+	CreatedAt   string   `json:"createdAt" dc:"创建时间"`
+}
+
+type CreateReq struct {
+	g.Meta      `path:"/knowledge_base" method:"post" summary:"创建知识库"`
+	Name        string   `json:"name" v:"required" dc:"知识库名称，字母开头，仅包含字母、数字和下划线"`
+	Description string   `json:"description" dc:"知识库描述"`
+	Teams       []string `json:"teams" dc:"可访问的团队，为空表示所有调用方均可访问"`
+}
+
+type CreateRes struct {
+	KnowledgeBase *KnowledgeBase `json:"knowledgeBase"`
+}
+
+type ListReq struct {
+	g.Meta `path:"/knowledge_base" method:"get" summary:"知识库列表"`
+}
+
+type ListRes struct {
+	List []*KnowledgeBase `json:"list"`
+}
@@ -0,0 +1,176 @@
+package chat_pipeline
+
+import (
+	"context"
+	"github.com/NuyoahCh/eocall/internal/ai/models"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"log"
+)
+
+// 提示词预算的默认配置
+const (
+	DefaultKeepRecentHistory = 2   // 始终保留的最近历史消息条数
+	DefaultMinDocTokens      = 128 // 剩余预算不足该值时不再截断文档，直接丢弃
+	truncatedSuffix          = "\n……（内容过长，已截断）"
+)
+
+// BudgetConfig 提示词预算配置，模型上下文窗口与预留空间来自对话模型的配置节点，裁剪策略对应配置文件中的 prompt_budget 节点
+type BudgetConfig struct {
+	*models.Budget
+	KeepRecentHistory int // 始终保留的最近历史消息条数，超出预算时截断其内容
+	MinDocTokens      int // 剩余预算不足该值时不再截断文档，直接丢弃
+}
+
+// GetBudgetConfig 从配置文件读取提示词预算配置，未配置的项使用默认值
+func GetBudgetConfig(ctx context.Context) *BudgetConfig {
+	cfg := g.Cfg()
+	c := &BudgetConfig{
+		Budget:            models.GetBudget(ctx, chatModelProfile),
+		KeepRecentHistory: cfg.MustGet(ctx, "prompt_budget.keep_recent_history", DefaultKeepRecentHistory).Int(),
+		MinDocTokens:      cfg.MustGet(ctx, "prompt_budget.min_doc_tokens", DefaultMinDocTokens).Int(),
+	}
+	if c.KeepRecentHistory < 0 {
+		c.KeepRecentHistory = DefaultKeepRecentHistory
+	}
+	if c.MinDocTokens <= 0 {
+		c.MinDocTokens = DefaultMinDocTokens
+	}
+	return c
+}
+
+// BudgetReport 提示词预算的使用情况
+type BudgetReport struct {
+	Profile            string   // 对话模型的配置节点
+	ContextWindow      int      // 模型上下文窗口
+	Reserved           int      // 为模型输出与工具调用预留的 token
+	PromptTokens       int      // 裁剪后提示词的估算 token 数
+	DroppedDocuments   []string // 因超出预算被丢弃的文档切片 ID
+	TruncatedDocuments []string // 因超出预算被截断的文档切片 ID
+	DroppedHistory     int      // 被丢弃的较早历史消息条数
+	TruncatedHistory   int      // 被截断内容的最近历史消息条数
+}
+
+// newPromptBudgetLambda component initialization function of node 'PromptBudget' in graph 'ChatAgent'
+// 按对话模型的上下文窗口裁剪文档与历史：系统提示词与当前问题完整保留，其次是最近的历史消息，
+// 然后按排名依次放入文档（放不下时截断或丢弃），剩余预算再从新到旧放入较早的历史消息
+func newPromptBudgetLambda(ctx context.Context) func(ctx context.Context, input map[string]any, opts ...any) (map[string]any, error) {
+	c := GetBudgetConfig(ctx)
+	return func(ctx context.Context, input map[string]any, opts ...any) (map[string]any, error) {
+		docs, _ := input["docs"].([]*schema.Document)
+		history, _ := input["history"].([]*schema.Message)
+		content, _ := input["content"].(string)
+		date, _ := input["date"].(string)
+
+		report := &BudgetReport{
+			Profile:       c.Profile,
+			ContextWindow: c.ContextWindow,
+			Reserved:      c.Reserved(),
+		}
+		fixed := models.CountTokens(systemPrompt) + models.CountTokens(date) + models.CountMessageTokens(schema.UserMessage(content))
+		remaining := c.Prompt() - fixed
+		if remaining < 0 {
+			log.Printf("[warn] prompt exceeds budget of %s without documents and history: %d > %d", c.Profile, fixed, c.Prompt())
+			remaining = 0
+		}
+
+		recent, older := splitHistory(history, c.KeepRecentHistory)
+		recent, remaining = fitRecentHistory(recent, remaining, report)
+		docs, remaining = fitDocuments(docs, remaining, c.MinDocTokens, report)
+		older, _ = fitOlderHistory(older, remaining, report)
+		history = append(append(make([]*schema.Message, 0, len(older)+len(recent)), older...), recent...)
+
+		report.PromptTokens = fixed + models.CountTokens(formatDocuments(docs))
+		for _, msg := range history {
+			report.PromptTokens += models.CountMessageTokens(msg)
+		}
+		if len(report.DroppedDocuments)+len(report.TruncatedDocuments)+report.DroppedHistory+report.TruncatedHistory > 0 {
+			log.Printf("[info] prompt trimmed to budget of %s: dropped %d documents, truncated %d documents, dropped %d history messages, truncated %d history messages",
+				c.Profile, len(report.DroppedDocuments), len(report.TruncatedDocuments), report.DroppedHistory, report.TruncatedHistory)
+		}
+		recordTrace(ctx, func(t *Trace) {
+			t.Documents = docs
+			t.Budget = report
+		})
+		return map[string]any{
+			"documents": formatDocuments(docs),
+			"history":   history,
+			"content":   content,
+			"date":      date,
+		}, nil
+	}
+}
+
+// splitHistory 将历史分为始终保留的最近 n 条与较早的部分，最近部分不以工具结果开头，避免与对应的工具调用分离
+func splitHistory(history []*schema.Message, n int) (recent, older []*schema.Message) {
+	i := max(len(history)-n, 0)
+	for i > 0 && i < len(history) && history[i].Role == schema.Tool {
+		i--
+	}
+	return history[i:], history[:i]
+}
+
+// fitRecentHistory 最近的历史消息超出预算时从旧到新截断其内容，最新一条最后截断
+func fitRecentHistory(recent []*schema.Message, remaining int, report *BudgetReport) ([]*schema.Message, int) {
+	total := 0
+	for _, msg := range recent {
+		total += models.CountMessageTokens(msg)
+	}
+	out := make([]*schema.Message, len(recent))
+	copy(out, recent)
+	for i := 0; i < len(out) && total > remaining; i++ {
+		cost := models.CountMessageTokens(out[i])
+		m := *out[i]
+		limit := models.CountTokens(m.Content) - (total - remaining) - models.CountTokens(truncatedSuffix)
+		m.Content = models.TruncateTokens(m.Content, limit) + truncatedSuffix
+		m.ReasoningContent = ""
+		out[i] = &m
+		total += models.CountMessageTokens(&m) - cost
+		report.TruncatedHistory++
+	}
+	return out, remaining - total
+}
+
+// fitDocuments 按排名放入文档，放不下的文档在剩余预算不少于 minTokens 时截断，否则丢弃
+func fitDocuments(docs []*schema.Document, remaining, minTokens int, report *BudgetReport) ([]*schema.Document, int) {
+	kept := make([]*schema.Document, 0, len(docs))
+	for _, doc := range docs {
+		// 编号、来源与标题行按单篇文档单独格式化估算
+		cost := models.CountTokens(formatDocuments([]*schema.Document{doc}))
+		if cost <= remaining {
+			kept = append(kept, doc)
+			remaining -= cost
+			continue
+		}
+		overhead := cost - models.CountTokens(doc.Content) + models.CountTokens(truncatedSuffix)
+		if remaining < minTokens || remaining <= overhead {
+			report.DroppedDocuments = append(report.DroppedDocuments, doc.ID)
+			continue
+		}
+		d := &schema.Document{ID: doc.ID, Content: models.TruncateTokens(doc.Content, remaining-overhead) + truncatedSuffix, MetaData: doc.MetaData}
+		kept = append(kept, d)
+		remaining -= models.CountTokens(formatDocuments([]*schema.Document{d}))
+		report.TruncatedDocuments = append(report.TruncatedDocuments, doc.ID)
+	}
+	return kept, remaining
+}
+
+// fitOlderHistory 从新到旧放入较早的历史消息，第一条放不下的消息及更早的消息全部丢弃，保持历史连续
+func fitOlderHistory(older []*schema.Message, remaining int, report *BudgetReport) ([]*schema.Message, int) {
+	start := len(older)
+	for start > 0 {
+		cost := models.CountMessageTokens(older[start-1])
+		if cost > remaining {
+			break
+		}
+		remaining -= cost
+		start--
+	}
+	// 不以工具结果开头，避免与对应的工具调用分离
+	for start < len(older) && older[start].Role == schema.Tool {
+		remaining += models.CountMessageTokens(older[start])
+		start++
+	}
+	report.DroppedHistory = start
+	return older[start:], remaining
+}
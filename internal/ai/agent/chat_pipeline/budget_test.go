@@ -0,0 +1,173 @@
+package chat_pipeline
+
+import (
+	"github.com/NuyoahCh/eocall/internal/ai/models"
+	"github.com/cloudwego/eino/schema"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func docCost(doc *schema.Document) int {
+	return models.CountTokens(formatDocuments([]*schema.Document{doc}))
+}
+
+func messageCost(msgs ...*schema.Message) int {
+	n := 0
+	for _, m := range msgs {
+		n += models.CountMessageTokens(m)
+	}
+	return n
+}
+
+func TestSplitHistory(t *testing.T) {
+	u1, a1, u2, a2 := schema.UserMessage("u1"), schema.AssistantMessage("a1", nil), schema.UserMessage("u2"), schema.AssistantMessage("a2", nil)
+	call := schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "get_current_time"}}})
+	result := schema.ToolMessage("now", "1")
+	cases := []struct {
+		name       string
+		history    []*schema.Message
+		n          int
+		wantRecent []*schema.Message
+		wantOlder  []*schema.Message
+	}{
+		{"keep last two", []*schema.Message{u1, a1, u2, a2}, 2, []*schema.Message{u2, a2}, []*schema.Message{u1, a1}},
+		{"keep none", []*schema.Message{u1, a1}, 0, []*schema.Message{}, []*schema.Message{u1, a1}},
+		{"keep more than history", []*schema.Message{u1, a1}, 5, []*schema.Message{u1, a1}, []*schema.Message{}},
+		{"tool result stays with its call", []*schema.Message{u1, call, result, a2}, 2, []*schema.Message{call, result, a2}, []*schema.Message{u1}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			recent, older := splitHistory(c.history, c.n)
+			if !reflect.DeepEqual(recent, c.wantRecent) || !reflect.DeepEqual(older, c.wantOlder) {
+				t.Errorf("splitHistory() = %v, %v, want %v, %v", recent, older, c.wantRecent, c.wantOlder)
+			}
+		})
+	}
+}
+
+func TestFitDocuments(t *testing.T) {
+	doc := func(id string, chars int) *schema.Document {
+		return &schema.Document{ID: id, Content: strings.Repeat("磁", chars), MetaData: map[string]any{"_source": id + ".md"}}
+	}
+	d1, d2, d3 := doc("d1", 100), doc("d2", 300), doc("d3", 50)
+	cases := []struct {
+		name          string
+		remaining     int
+		minTokens     int
+		wantKept      []string
+		wantTruncated []string
+		wantDropped   []string
+	}{
+		{"all fit", docCost(d1) + docCost(d2) + docCost(d3), 10, []string{"d1", "d2", "d3"}, nil, nil},
+		{"truncated doc uses the rest", docCost(d1) + 150, 10, []string{"d1", "d2"}, []string{"d2"}, []string{"d3"}},
+		{"drop below min tokens", docCost(d1) + 60, 64, []string{"d1", "d3"}, nil, []string{"d2"}},
+		{"nothing fits", 5, 10, []string{}, nil, []string{"d1", "d2", "d3"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			report := &BudgetReport{}
+			kept, remaining := fitDocuments([]*schema.Document{d1, d2, d3}, c.remaining, c.minTokens, report)
+			ids := make([]string, 0, len(kept))
+			used := 0
+			for _, d := range kept {
+				ids = append(ids, d.ID)
+				used += docCost(d)
+			}
+			if !reflect.DeepEqual(ids, c.wantKept) {
+				t.Errorf("kept = %v, want %v", ids, c.wantKept)
+			}
+			if !reflect.DeepEqual(report.TruncatedDocuments, c.wantTruncated) || !reflect.DeepEqual(report.DroppedDocuments, c.wantDropped) {
+				t.Errorf("truncated = %v, dropped = %v, want %v, %v", report.TruncatedDocuments, report.DroppedDocuments, c.wantTruncated, c.wantDropped)
+			}
+			if remaining < 0 || used > c.remaining || remaining != c.remaining-used {
+				t.Errorf("remaining = %d after using %d of %d", remaining, used, c.remaining)
+			}
+			for _, d := range kept {
+				if contains(c.wantTruncated, d.ID) && !strings.HasSuffix(d.Content, truncatedSuffix) {
+					t.Errorf("truncated doc %s missing suffix", d.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestFitOlderHistory(t *testing.T) {
+	u1, a1, u2 := schema.UserMessage("第一个问题"), schema.AssistantMessage("第一个问题的详细回答", nil), schema.UserMessage("第二个问题")
+	call := schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "get_current_time"}}})
+	result := schema.ToolMessage("现在是十点", "1")
+	a2 := schema.AssistantMessage("现在十点", nil)
+	cases := []struct {
+		name        string
+		older       []*schema.Message
+		remaining   int
+		want        []*schema.Message
+		wantDropped int
+	}{
+		{"all fit", []*schema.Message{u1, a1, u2}, messageCost(u1, a1, u2), []*schema.Message{u1, a1, u2}, 0},
+		{"newest first", []*schema.Message{u1, a1, u2}, messageCost(a1, u2), []*schema.Message{a1, u2}, 1},
+		{"gap drops everything older", []*schema.Message{u1, a1, u2}, messageCost(u2) + messageCost(u1), []*schema.Message{u2}, 2},
+		{"does not start with tool result", []*schema.Message{u1, call, result, a2}, messageCost(result, a2), []*schema.Message{a2}, 3},
+		{"nothing fits", []*schema.Message{u1}, 1, []*schema.Message{}, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			report := &BudgetReport{}
+			got, remaining := fitOlderHistory(c.older, c.remaining, report)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("fitOlderHistory() = %v, want %v", got, c.want)
+			}
+			if report.DroppedHistory != c.wantDropped {
+				t.Errorf("DroppedHistory = %d, want %d", report.DroppedHistory, c.wantDropped)
+			}
+			if remaining != c.remaining-messageCost(got...) {
+				t.Errorf("remaining = %d, want %d", remaining, c.remaining-messageCost(got...))
+			}
+		})
+	}
+}
+
+func TestFitRecentHistory(t *testing.T) {
+	long := schema.UserMessage(strings.Repeat("磁", 200))
+	latest := schema.AssistantMessage(strings.Repeat("盘", 50), nil)
+	cases := []struct {
+		name          string
+		remaining     int
+		wantTruncated int
+		latestKept    bool // 最新一条内容保持不变
+	}{
+		{"within budget", messageCost(long, latest), 0, true},
+		{"truncate oldest first", messageCost(long, latest) - 100, 1, true},
+		{"truncate both", messageCost(latest) - 10, 2, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			report := &BudgetReport{}
+			got, remaining := fitRecentHistory([]*schema.Message{long, latest}, c.remaining, report)
+			if len(got) != 2 {
+				t.Fatalf("fitRecentHistory() returned %d messages, want 2", len(got))
+			}
+			if report.TruncatedHistory != c.wantTruncated {
+				t.Errorf("TruncatedHistory = %d, want %d", report.TruncatedHistory, c.wantTruncated)
+			}
+			if remaining < 0 || remaining != c.remaining-messageCost(got...) {
+				t.Errorf("remaining = %d, used %d of %d", remaining, messageCost(got...), c.remaining)
+			}
+			if (got[1] == latest) != c.latestKept {
+				t.Errorf("latest message kept = %v, want %v", got[1] == latest, c.latestKept)
+			}
+			if len(long.Content) != 600 {
+				t.Error("fitRecentHistory() modified the input message")
+			}
+		})
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package chat_pipeline
+
+import (
+	"context"
+	"github.com/cloudwego/eino/schema"
+	"sync"
+	"time"
+)
+
+// checkPointTTL 检查点的保留时间，超过后对应的运行无法再恢复
+const checkPointTTL = time.Hour
+
+func init() {
+	// 工具调用等待审批时图的状态与输入随检查点一起序列化
+	schema.RegisterName[*chatState]("eocall_chat_state")
+	schema.RegisterName[*UserMessage]("eocall_user_message")
+}
+
+// checkPoints 对话图共享的检查点存储，配置变更重建对话图后仍可恢复等待审批的运行
+var checkPoints = &checkPointStore{data: map[string]*checkPoint{}}
+
+type checkPoint struct {
+	data    []byte
+	savedAt time.Time
+}
+
+// checkPointStore 内存检查点存储，写入时清理过期的检查点
+type checkPointStore struct {
+	mu   sync.Mutex
+	data map[string]*checkPoint
+}
+
+func (s *checkPointStore) Get(_ context.Context, checkPointID string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp, ok := s.data[checkPointID]
+	if !ok || time.Since(cp.savedAt) > checkPointTTL {
+		return nil, false, nil
+	}
+	return cp.data, true, nil
+}
+
+func (s *checkPointStore) Set(_ context.Context, checkPointID string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, cp := range s.data {
+		if now.Sub(cp.savedAt) > checkPointTTL {
+			delete(s.data, id)
+		}
+	}
+	s.data[checkPointID] = &checkPoint{data: data, savedAt: now}
+	return nil
+}
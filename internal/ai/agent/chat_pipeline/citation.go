@@ -0,0 +1,97 @@
+package chat_pipeline
+
+import (
+	"fmt"
+	"github.com/cloudwego/eino/schema"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// headerKeys 文档切分时写入元数据的标题字段，按层级排列
+var headerKeys = []string{"title", "h2", "h3"}
+
+// citationPattern 匹配回答中的引用标记，如 [1]、[1,2]、【3】
+var citationPattern = regexp.MustCompile(`[\[【]\s*(\d+(?:\s*[,，、]\s*\d+)*)\s*[\]】]`)
+
+// Source 回答引用的文档来源
+type Source struct {
+	Index      int     // 文档在提示词中的编号，从 1 开始
+	Source     string  // 文档 metadata 中的 _source
+	Title      string  // 文档标题
+	HeaderPath string  // 标题路径，如 "告警处理 > CPU 使用率过高"
+	ChunkID    string  // 文档切片 ID
+	Score      float64 // 检索或重排分值
+	Cited      bool    // 回答中是否引用了该文档
+}
+
+// formatDocuments 按 [编号] 来源、标题、正文的格式拼接文档
+func formatDocuments(docs []*schema.Document) string {
+	if len(docs) == 0 {
+		return "（未检索到相关文档）"
+	}
+	var sb strings.Builder
+	for i, doc := range docs {
+		fmt.Fprintf(&sb, "[%d] 来源：%s", i+1, metaString(doc, "_source"))
+		if path := headerPath(doc); path != "" {
+			fmt.Fprintf(&sb, "，标题：%s", path)
+		}
+		sb.WriteString("\n")
+		sb.WriteString(strings.TrimSpace(doc.Content))
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// BuildSources 根据回答中的引用标记生成来源列表，cited 表示回答是否至少引用了一篇文档
+func BuildSources(docs []*schema.Document, answer string) (sources []*Source, cited bool) {
+	refs := ExtractCitations(answer, len(docs))
+	sources = make([]*Source, 0, len(docs))
+	for i, doc := range docs {
+		_, ok := refs[i+1]
+		sources = append(sources, &Source{
+			Index:      i + 1,
+			Source:     metaString(doc, "_source"),
+			Title:      metaString(doc, headerKeys[0]),
+			HeaderPath: headerPath(doc),
+			ChunkID:    doc.ID,
+			Score:      doc.Score(),
+			Cited:      ok,
+		})
+	}
+	return sources, len(refs) > 0
+}
+
+// ExtractCitations 提取回答中引用的文档编号，忽略超出 [1, n] 范围的编号
+func ExtractCitations(answer string, n int) map[int]struct{} {
+	refs := map[int]struct{}{}
+	for _, m := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		for _, part := range strings.FieldsFunc(m[1], func(r rune) bool {
+			return r == ',' || r == '，' || r == '、' || r == ' '
+		}) {
+			idx, err := strconv.Atoi(part)
+			if err == nil && idx >= 1 && idx <= n {
+				refs[idx] = struct{}{}
+			}
+		}
+	}
+	return refs
+}
+
+func headerPath(doc *schema.Document) string {
+	parts := make([]string, 0, len(headerKeys))
+	for _, key := range headerKeys {
+		if v := metaString(doc, key); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+func metaString(doc *schema.Document, key string) string {
+	v, ok := doc.MetaData[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimLeft(fmt.Sprint(v), "#"))
+}
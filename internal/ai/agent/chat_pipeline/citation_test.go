@@ -0,0 +1,63 @@
+package chat_pipeline
+
+import (
+	"github.com/cloudwego/eino/schema"
+	"reflect"
+	"testing"
+)
+
+func TestExtractCitations(t *testing.T) {
+	cases := []struct {
+		name   string
+		answer string
+		n      int
+		want   []int
+	}{
+		{"none", "磁盘满时清理日志目录。", 3, nil},
+		{"single", "清理日志目录[1]。", 3, []int{1}},
+		{"multiple markers", "先扩容[2]，再清理[3]。", 3, []int{2, 3}},
+		{"comma list", "参考 [1, 3]", 3, []int{1, 3}},
+		{"full width", "参考【2】与[1，3]及[1、2]", 3, []int{1, 2, 3}},
+		{"out of range ignored", "参考[0][4][2]", 3, []int{2}},
+		{"no documents", "参考[1]", 0, nil},
+		{"not a citation", "数组 a[i] 与 [x1]", 3, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			refs := ExtractCitations(c.answer, c.n)
+			var got []int
+			for i := 1; i <= c.n; i++ {
+				if _, ok := refs[i]; ok {
+					got = append(got, i)
+				}
+			}
+			if len(refs) != len(got) {
+				t.Errorf("ExtractCitations() = %v contains out of range index", refs)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("ExtractCitations() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestBuildSources(t *testing.T) {
+	docs := []*schema.Document{
+		(&schema.Document{ID: "c1", MetaData: map[string]any{"_source": "disk.md", "title": "# 磁盘告警", "h2": "## 清理日志"}}).WithScore(0.9),
+		(&schema.Document{ID: "c2", MetaData: map[string]any{"_source": "cpu.md"}}).WithScore(0.7),
+	}
+	sources, cited := BuildSources(docs, "先清理日志[1]。")
+	if !cited {
+		t.Error("BuildSources() cited = false, want true")
+	}
+	want := []*Source{
+		{Index: 1, Source: "disk.md", Title: "磁盘告警", HeaderPath: "磁盘告警 > 清理日志", ChunkID: "c1", Score: 0.9, Cited: true},
+		{Index: 2, Source: "cpu.md", ChunkID: "c2", Score: 0.7},
+	}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("BuildSources() = %+v, want %+v", sources, want)
+	}
+	if _, cited = BuildSources(docs, "无引用"); cited {
+		t.Error("BuildSources() cited = true for answer without citations")
+	}
+}
@@ -2,41 +2,158 @@ package chat_pipeline
 
 import (
 	"context"
+	"fmt"
 	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+	"slices"
 )
 
-func newReactAgentLambda(ctx context.Context) (lba *compose.Lambda, err error) {
+// toolSet 对话智能体可用的全部工具，单次请求按工具策略与请求选择从中筛选
+type toolSet struct {
+	tools []tool.BaseTool
+	infos []*schema.ToolInfo
+	names []string
+}
+
+// newToolSet 初始化对话智能体的全部工具，有副作用的工具调用前需要人工审批
+func newToolSet(ctx context.Context) (*toolSet, error) {
+	//searchTool, err := newSearchTool(ctx)
+	//if err != nil {
+	//	return nil, err
+	//}
+	// 配置的 MCP 服务中连接失败的会被跳过
+	all := tools.GetMcpTools(ctx)
+	all = append(all, tools.NewPrometheusAlertsQueryTool(), tools.NewPrometheusMetricsQueryTool())
+	all = append(all, tools.NewAlertmanagerAlertGroupsTool(), tools.NewAlertmanagerSilencesTool())
+	all = append(all, tools.NewAlertmanagerCreateSilenceTool(), tools.NewAlertmanagerExpireSilenceTool())
+	all = append(all, tools.NewMysqlCrudTool())
+	all = append(all, tools.NewListTablesTool(), tools.NewDescribeTableTool(), tools.NewSampleRowsTool())
+	all = append(all, tools.NewGetCurrentTimeTool())
+	all = append(all, tools.NewQueryInternalDocsTool())
+	ts := &toolSet{}
+	for _, t := range all {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		t, err = tools.WithApproval(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if it, ok := t.(tool.InvokableTool); ok {
+			t = &permittedTool{InvokableTool: it, name: info.Name}
+		}
+		ts.tools = append(ts.tools, t)
+		ts.infos = append(ts.infos, info)
+		ts.names = append(ts.names, info.Name)
+	}
+	return ts, nil
+}
+
+// Permitted 调用方在本次请求中可以使用的工具名
+func (ts *toolSet) Permitted(ctx context.Context) []string {
+	return tools.Permitted(ctx, ts.names)
+}
+
+// stateTools 读取路由节点写入状态的本次请求可用工具
+func stateTools(ctx context.Context) ([]string, error) {
+	var names []string
+	err := compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+		names = s.Tools
+		return nil
+	})
+	return names, err
+}
+
+// newReactAgentGraph 构建 ReAct 智能体并导出其编排图，作为子图嵌入对话图，
+// 工具调用中断时由对话图统一保存检查点，审批后从中断处恢复执行
+func newReactAgentGraph(ctx context.Context, ts *toolSet) (compose.AnyGraph, []compose.GraphAddNodeOpt, error) {
 	config := &react.AgentConfig{
 		MaxStep:            25,
 		ToolReturnDirectly: map[string]struct{}{}}
 	chatModelIns11, err := newChatModel(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	config.ToolCallingModel = chatModelIns11
-	//searchTool, err := newSearchTool(ctx)
-	//if err != nil {
-	//	return nil, err
-	//}
-	mcpTool, err := tools.GetLogMcpTool()
+	// 智能体构建时绑定全部工具，每次调用只开放路由节点写入状态的工具
+	config.ToolCallingModel = &permittedModel{model: chatModelIns11}
+	config.ToolsConfig.Tools = ts.tools
+
+	ins, err := react.NewAgent(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	sg, opts := ins.ExportGraph()
+	return sg, opts, nil
+}
+
+// permittedModel 每次调用只向模型提供本次请求可用的工具定义
+type permittedModel struct {
+	model model.ToolCallingChatModel
+	infos []*schema.ToolInfo
+}
+
+func (m *permittedModel) WithTools(infos []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return &permittedModel{model: m.model, infos: infos}, nil
+}
+
+// bind 按状态中的工具列表绑定工具定义，没有可用工具时不绑定
+func (m *permittedModel) bind(ctx context.Context) (model.BaseChatModel, error) {
+	names, err := stateTools(ctx)
 	if err != nil {
 		return nil, err
 	}
-	config.ToolsConfig.Tools = mcpTool
-	config.ToolsConfig.Tools = append(config.ToolsConfig.Tools, tools.NewPrometheusAlertsQueryTool())
-	config.ToolsConfig.Tools = append(config.ToolsConfig.Tools, tools.NewMysqlCrudTool())
-	config.ToolsConfig.Tools = append(config.ToolsConfig.Tools, tools.NewGetCurrentTimeTool())
-	config.ToolsConfig.Tools = append(config.ToolsConfig.Tools, tools.NewQueryInternalDocsTool())
+	infos := make([]*schema.ToolInfo, 0, len(names))
+	for _, info := range m.infos {
+		if slices.Contains(names, info.Name) {
+			infos = append(infos, info)
+		}
+	}
+	if len(infos) == 0 {
+		return m.model, nil
+	}
+	return m.model.WithTools(infos)
+}
 
-	ins, err := react.NewAgent(ctx, config)
+func (m *permittedModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	cm, err := m.bind(ctx)
 	if err != nil {
 		return nil, err
 	}
-	lba, err = compose.AnyLambda(ins.Generate, ins.Stream, nil, nil)
+	return cm.Generate(ctx, input, opts...)
+}
+
+func (m *permittedModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	cm, err := m.bind(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return lba, nil
+	return cm.Stream(ctx, input, opts...)
+}
+
+// IsCallbacksEnabled 回调由被包装的模型触发，避免重复记录
+func (m *permittedModel) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(m.model)
+}
+
+// permittedTool 拒绝执行本次请求未开放的工具，防止模型调用工具定义之外的工具
+type permittedTool struct {
+	tool.InvokableTool
+	name string
+}
+
+func (t *permittedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	names, err := stateTools(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(names, t.name) {
+		return fmt.Sprintf("工具 %s 在本次请求中不可用", t.name), nil
+	}
+	return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
 }
@@ -0,0 +1,255 @@
+package chat_pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/lexical"
+	retriever2 "github.com/NuyoahCh/eocall/internal/ai/retriever"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 检索未命中时的处理策略
+const (
+	GroundingRefuse = "refuse" // 直接返回模板回复，不调用模型
+	GroundingLabel  = "label"  // 仍然回答，但在回答开头标注未基于内部文档
+	GroundingOff    = "off"    // 不做处理
+)
+
+// 检索未命中处理的默认配置
+const (
+	DefaultGroundingMissLog        = "./data/retrieval_misses.jsonl"
+	DefaultGroundingMinScore       = 0.5  // 本地存储的余弦相似度
+	DefaultMilvusGroundingMinScore = 0.59 // Milvus 的汉明相似度，尺度见 groundingMinScore
+	defaultRefuseMessage           = "未在内部知识库中找到与该问题相关的处理手册（runbook）。为避免给出未经验证的生产操作建议，本次不作回答。请补充告警名称、错误码、服务名等关键信息后重试，或联系值班负责人。"
+	defaultUngroundedLabel         = "【提示：未找到相关内部文档，以下回答基于通用知识，未经内部 runbook 验证，请谨慎操作】\n"
+)
+
+// GroundingConfig 检索未命中的处理配置，对应配置文件中的 grounding 节点
+type GroundingConfig struct {
+	Policy        string  // refuse | label | off
+	MinScore      float64 // 向量相似度不低于该值或命中有效关键词的文档才算有效，0 表示检索到文档即有效；按向量存储后端取值
+	RefuseMessage string  // refuse 策略的模板回复
+	Label         string  // label 策略在回答开头添加的标注
+	MissLog       string  // 记录未命中问题的文件，为空时只打印日志
+}
+
+// GetGroundingConfig 从配置文件读取检索未命中的处理配置，未配置的项使用默认值
+func GetGroundingConfig(ctx context.Context) *GroundingConfig {
+	cfg := g.Cfg()
+	c := &GroundingConfig{
+		Policy:        cfg.MustGet(ctx, "grounding.policy", GroundingLabel).String(),
+		MinScore:      groundingMinScore(ctx),
+		RefuseMessage: cfg.MustGet(ctx, "grounding.refuse_message", defaultRefuseMessage).String(),
+		Label:         cfg.MustGet(ctx, "grounding.label", defaultUngroundedLabel).String(),
+		MissLog:       cfg.MustGet(ctx, "grounding.miss_log", DefaultGroundingMissLog).String(),
+	}
+	if c.Policy != GroundingRefuse && c.Policy != GroundingOff {
+		c.Policy = GroundingLabel
+	}
+	if c.RefuseMessage == "" {
+		c.RefuseMessage = defaultRefuseMessage
+	}
+	if c.Label == "" {
+		c.Label = defaultUngroundedLabel
+	}
+	return c
+}
+
+// groundingMinScore 按 vector_store.type 读取 grounding.min_score，配置为单个数值时对所有后端生效
+// Milvus 的分值为 float32 位模式的汉明相似度 1 - distance/bits，符号位与指数位在不同向量间大多相同，
+// 无关文档也在 0.57 左右，余弦相似度 0.9 的文档约为 0.6，与本地存储的余弦相似度不能使用同一阈值
+func groundingMinScore(ctx context.Context) float64 {
+	typ := vectorstore.StoreType(ctx)
+	def := DefaultGroundingMinScore
+	if typ == vectorstore.TypeMilvus {
+		def = DefaultMilvusGroundingMinScore
+	}
+	v := g.Cfg().MustGet(ctx, "grounding.min_score")
+	switch {
+	case v.IsNil():
+		return def
+	case v.IsMap():
+		if score, ok := v.MapStrVar()[typ]; ok && !score.IsNil() {
+			return score.Float64()
+		}
+		return def
+	default:
+		return v.Float64()
+	}
+}
+
+// grounded 判断检索结果中是否有足以作为回答依据的文档：向量相似度不低于 minScore，
+// 或由关键词检索召回且与查询共享至少一个有效词项（单个汉字不算，见 lexical.Significant）
+func grounded(docs []*schema.Document, queries []string, minScore float64) bool {
+	if minScore <= 0 {
+		return len(docs) > 0
+	}
+	for _, doc := range docs {
+		if s, ok := doc.MetaData[retriever2.MetaVectorScore].(float64); ok && s >= minScore {
+			return true
+		}
+		if s, ok := doc.MetaData[retriever2.MetaLexicalScore].(float64); !ok || s <= 0 {
+			continue
+		}
+		for _, q := range queries {
+			if len(lexical.MatchedTerms(q, doc.Content)) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newGroundingLambda component initialization function of node 'Grounding' in graph 'ChatAgent'
+// 判断检索结果是否足以作为回答依据，未命中时记录问题供后续补充文档，文档原样传给预算节点
+func newGroundingLambda(gc *GroundingConfig) func(ctx context.Context, docs []*schema.Document, opts ...any) ([]*schema.Document, error) {
+	return func(ctx context.Context, docs []*schema.Document, opts ...any) ([]*schema.Document, error) {
+		var (
+			query, rewritten string
+			subQueries       []string
+		)
+		err := compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+			query, rewritten, subQueries = s.Query, s.RewrittenQuery, s.SubQueries
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		queries := append([]string{query, rewritten}, subQueries...)
+		ok := gc.Policy == GroundingOff || grounded(docs, queries, gc.MinScore)
+		err = compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+			s.Grounded = ok
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			recordMiss(ctx, gc, query, rewritten, docs)
+		}
+		recordTrace(ctx, func(t *Trace) {
+			t.Grounded = ok
+		})
+		return docs, nil
+	}
+}
+
+// newRefuseLambda component initialization function of node 'NoRunbook' in graph 'ChatAgent'
+func newRefuseLambda(message string) *compose.Lambda {
+	return compose.InvokableLambda(func(ctx context.Context, in []*schema.Message) (*schema.Message, error) {
+		return schema.AssistantMessage(message, nil), nil
+	})
+}
+
+// newGroundingLabelLambda component initialization function of node 'GroundingLabel' in graph 'ChatAgent'
+// 检索未命中时在回答开头添加标注，流式输出时标注作为第一个片段
+func newGroundingLabelLambda(label string) (*compose.Lambda, error) {
+	ungrounded := func(ctx context.Context) bool {
+		miss := false
+		_ = compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+			miss = !s.Grounded
+			return nil
+		})
+		return miss
+	}
+	invoke := func(ctx context.Context, in *schema.Message, opts ...any) (*schema.Message, error) {
+		if !ungrounded(ctx) {
+			return in, nil
+		}
+		out := *in
+		out.Content = label + in.Content
+		return &out, nil
+	}
+	transform := func(ctx context.Context, in *schema.StreamReader[*schema.Message], opts ...any) (*schema.StreamReader[*schema.Message], error) {
+		if !ungrounded(ctx) {
+			return in, nil
+		}
+		sr, sw := schema.Pipe[*schema.Message](1)
+		go func() {
+			defer sw.Close()
+			defer in.Close()
+			if sw.Send(schema.AssistantMessage(label, nil), nil) {
+				return
+			}
+			for {
+				chunk, err := in.Recv()
+				if err == io.EOF {
+					return
+				}
+				if sw.Send(chunk, err) || err != nil {
+					return
+				}
+			}
+		}()
+		return sr, nil
+	}
+	return compose.AnyLambda(invoke, nil, nil, transform)
+}
+
+// retrievalMiss 检索未命中记录
+type retrievalMiss struct {
+	Time           string   `json:"time"`
+	KnowledgeBase  string   `json:"knowledge_base"`
+	User           string   `json:"user"`
+	Team           string   `json:"team"`
+	Question       string   `json:"question"`
+	RewrittenQuery string   `json:"rewritten_query"`
+	Retrieved      int      `json:"retrieved"`
+	TopScores      []string `json:"top_scores"`
+	Policy         string   `json:"policy"`
+}
+
+var missLogMu sync.Mutex
+
+// recordMiss 将检索未命中的问题追加到未命中记录文件，供后续补充 runbook
+func recordMiss(ctx context.Context, c *GroundingConfig, question, rewritten string, docs []*schema.Document) {
+	id := auth.FromContext(ctx)
+	miss := &retrievalMiss{
+		Time:           time.Now().Format(time.RFC3339),
+		KnowledgeBase:  vectorstore.KnowledgeBaseFromContext(ctx),
+		User:           id.User,
+		Team:           id.Team,
+		Question:       question,
+		RewrittenQuery: rewritten,
+		Retrieved:      len(docs),
+		TopScores:      make([]string, 0, len(docs)),
+		Policy:         c.Policy,
+	}
+	for _, doc := range docs {
+		miss.TopScores = append(miss.TopScores, fmt.Sprintf("%s=%.4f", doc.ID, doc.Score()))
+	}
+	log.Printf("[warn] retrieval miss: kb=%s, user=%s, question=%q", miss.KnowledgeBase, miss.User, question)
+	if c.MissLog == "" {
+		return
+	}
+	line, err := json.Marshal(miss)
+	if err != nil {
+		return
+	}
+	missLogMu.Lock()
+	defer missLogMu.Unlock()
+	if err = os.MkdirAll(filepath.Dir(c.MissLog), 0o755); err != nil {
+		log.Printf("[warn] create retrieval miss log dir failed: %v", err)
+		return
+	}
+	f, err := os.OpenFile(c.MissLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("[warn] open retrieval miss log failed: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		log.Printf("[warn] write retrieval miss log failed: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package chat_pipeline
+
+import (
+	"context"
+	retriever2 "github.com/NuyoahCh/eocall/internal/ai/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"math"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+func TestGrounded(t *testing.T) {
+	doc := func(content string, vector, lexical float64) *schema.Document {
+		meta := map[string]any{retriever2.MetaVectorScore: vector}
+		if lexical > 0 {
+			meta[retriever2.MetaLexicalScore] = lexical
+		}
+		return &schema.Document{ID: content, Content: content, MetaData: meta}
+	}
+	cases := []struct {
+		name     string
+		docs     []*schema.Document
+		queries  []string
+		minScore float64
+		want     bool
+	}{
+		{"no docs", nil, []string{"磁盘告警"}, 0.5, false},
+		{"min score disabled", []*schema.Document{doc("无关内容", 0.1, 0)}, []string{"磁盘告警"}, 0, true},
+		{"vector above threshold", []*schema.Document{doc("无关内容", 0.8, 0)}, []string{"磁盘告警"}, 0.5, true},
+		{"vector below threshold", []*schema.Document{doc("无关内容", 0.2, 0)}, []string{"磁盘告警"}, 0.5, false},
+		{"single han character only", []*schema.Document{doc("服务的日志", 0.2, 1.3)}, []string{"怎么处理的"}, 0.5, false},
+		{"han bigram match", []*schema.Document{doc("磁盘告警处理手册", 0.2, 2.1)}, []string{"磁盘满了"}, 0.5, true},
+		{"error code match", []*schema.Document{doc("错误码 ERR_5003 的处理", 0.2, 4.2)}, []string{"遇到 err_5003 怎么办"}, 0.5, true},
+		{"match needs lexical hit", []*schema.Document{doc("磁盘告警处理手册", 0.2, 0)}, []string{"磁盘满了"}, 0.5, false},
+		{"rewritten query match", []*schema.Document{doc("CPU 使用率告警", 0.2, 1.5)}, []string{"那第二个呢", "cpu 告警如何处理"}, 0.5, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := grounded(c.docs, c.queries, c.minScore); got != c.want {
+				t.Errorf("grounded() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestGroundingMinScore(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		want   float64
+	}{
+		{"milvus default", "vector_store:\n  type: milvus\n", DefaultMilvusGroundingMinScore},
+		{"local default", "vector_store:\n  type: local\n", DefaultGroundingMinScore},
+		{"scalar for all backends", "vector_store:\n  type: milvus\ngrounding:\n  min_score: 0.3\n", 0.3},
+		{"per backend", "vector_store:\n  type: milvus\ngrounding:\n  min_score:\n    local: 0.4\n    milvus: 0.6\n", 0.6},
+		{"per backend missing type", "vector_store:\n  type: local\ngrounding:\n  min_score:\n    milvus: 0.6\n", DefaultGroundingMinScore},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			adapter, err := gcfg.NewAdapterContent(c.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.Cfg().SetAdapter(adapter)
+			if got := groundingMinScore(context.Background()); got != c.want {
+				t.Errorf("groundingMinScore() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+// milvusScore 与 MilvusStore.Search 相同，按 float32 位模式的汉明距离换算相似度
+func milvusScore(a, b []float64) float64 {
+	diff := 0
+	for i := range a {
+		diff += bits.OnesCount32(math.Float32bits(float32(a[i])) ^ math.Float32bits(float32(b[i])))
+	}
+	return 1 - float64(diff)/float64(len(a)*32)
+}
+
+// unitVector 生成与 base 余弦相似度约为 cos 的单位向量，base 为空时生成随机单位向量
+func unitVector(r *rand.Rand, base []float64, cos float64, dims int) []float64 {
+	v := make([]float64, dims)
+	norm := 0.0
+	for i := range v {
+		v[i] = r.NormFloat64()
+		norm += v[i] * v[i]
+	}
+	for i := range v {
+		v[i] /= math.Sqrt(norm)
+		if base != nil {
+			v[i] = cos*base[i] + math.Sqrt(1-cos*cos)*v[i]
+		}
+	}
+	return v
+}
+
+func TestGroundedMilvusScale(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	query := unitVector(r, nil, 0, 1024)
+	cases := []struct {
+		name string
+		cos  float64
+		want bool
+	}{
+		{"unrelated", 0, false},
+		{"weakly related", 0.4, false},
+		{"same topic", 0.95, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				doc := unitVector(r, query, c.cos, len(query))
+				score := milvusScore(query, doc)
+				docs := []*schema.Document{{ID: "d", Content: "无关内容", MetaData: map[string]any{retriever2.MetaVectorScore: score}}}
+				if got := grounded(docs, []string{"磁盘告警"}, DefaultMilvusGroundingMinScore); got != c.want {
+					t.Fatalf("grounded() with milvus score %.4f = %v, want %v", score, got, c.want)
+				}
+				// 与余弦相似度相同的阈值在 Milvus 尺度上无法区分无关文档
+				if c.cos == 0 && !grounded(docs, []string{"磁盘告警"}, DefaultGroundingMinScore) {
+					t.Fatalf("milvus score %.4f of an unrelated document is below the cosine threshold", score)
+				}
+			}
+		})
+	}
+}
@@ -2,12 +2,44 @@ package chat_pipeline
 
 import (
 	"context"
+	"github.com/cloudwego/eino/compose"
 	"time"
 )
 
-// newInputToRagLambda component initialization function of node 'InputToQuery' in graph 'EinoAgent'
-func newInputToRagLambda(ctx context.Context, input *UserMessage, opts ...any) (output string, err error) {
-	return input.Query, nil
+// chatState 对话图的状态，用于在节点之间传递中间结果
+type chatState struct {
+	Route          string   // 意图路由选择的处理路线
+	Tools          []string // 本次请求可以使用的工具
+	Query          string   // 用户原始问题
+	RewrittenQuery string   // 改写后的检索查询
+	SubQueries     []string // 改写得到的子查询
+	Grounded       bool     // 检索结果是否足以作为回答依据
+}
+
+// newInputToRagLambda component initialization function of node 'InputToRag' in graph 'ChatAgent'
+// 结合历史将问题改写为独立的检索查询，子查询写入图的状态供检索节点使用
+func newInputToRagLambda(ctx context.Context) (func(ctx context.Context, input *UserMessage, opts ...any) (string, error), error) {
+	rewriter, err := newQueryRewriter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, input *UserMessage, opts ...any) (output string, err error) {
+		result := rewriter.Rewrite(ctx, input.Query, input.History)
+		recordTrace(ctx, func(t *Trace) {
+			t.RewrittenQuery = result.Query
+			t.SubQueries = result.SubQueries
+		})
+		err = compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+			s.Query = input.Query
+			s.RewrittenQuery = result.Query
+			s.SubQueries = result.SubQueries
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		return result.Query, nil
+	}, nil
 }
 
 // newInputToChatLambda component initialization function of node 'InputToHistory' in graph 'EinoAgent'
@@ -6,6 +6,9 @@ import (
 	"github.com/cloudwego/eino/components/model"
 )
 
+// chatModelProfile 对话模型的配置节点，提示词预算按该节点下的配置计算
+const chatModelProfile = models.ProfileDeepSeekV3Quick
+
 func newChatModel(ctx context.Context) (cm model.ToolCallingChatModel, err error) {
 	cm, err = models.OpenAIForDeepSeekV3Quick(ctx)
 	if err != nil {
@@ -2,45 +2,135 @@ package chat_pipeline
 
 import (
 	"context"
+	"github.com/NuyoahCh/eocall/internal/ai/reranker"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 )
 
-func BuildChatAgent(ctx context.Context) (r compose.Runnable[*UserMessage, *schema.Message], err error) {
+// BuildChatAgent 构建对话智能体，故障排查请求交给 incident 获取的共享排查智能体
+func BuildChatAgent(ctx context.Context, incident IncidentAgent) (r compose.Runnable[*UserMessage, *schema.Message], err error) {
 	const (
+		Router          = "Router"
+		SmallTalk       = "SmallTalk"
+		Incident        = "Incident"
+		RagEntry        = "RagEntry"
+		RagAnswer       = "RagAnswer"
 		InputToRag      = "InputToRag"
 		ChatTemplate    = "ChatTemplate"
 		ReactAgent      = "ReactAgent"
 		MilvusRetriever = "MilvusRetriever"
 		InputToChat     = "InputToChat"
+		InputToRerank   = "InputToRerank"
+		ExpandParents   = "ExpandParents"
+		Grounding       = "Grounding"
+		PromptBudget    = "PromptBudget"
+		NoRunbook       = "NoRunbook"
+		GroundingLabel  = "GroundingLabel"
 	)
-	g := compose.NewGraph[*UserMessage, *schema.Message]()
-	_ = g.AddLambdaNode(InputToRag, compose.InvokableLambdaWithOption(newInputToRagLambda), compose.WithNodeName("UserMessageToRag"))
+	g := compose.NewGraph[*UserMessage, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *chatState {
+		return &chatState{}
+	}))
+	ts, err := newToolSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	incidentKeyOfLambda := newIncidentLambda(incident)
+	routerKeyOfLambda, err := newRouterLambda(ctx, ts, incident)
+	if err != nil {
+		return nil, err
+	}
+	_ = g.AddLambdaNode(Router, compose.InvokableLambdaWithOption(routerKeyOfLambda), compose.WithNodeName("IntentRouter"))
+	smallTalkKeyOfLambda, err := newSmallTalkLambda(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_ = g.AddLambdaNode(SmallTalk, smallTalkKeyOfLambda)
+	_ = g.AddLambdaNode(Incident, compose.InvokableLambdaWithOption(incidentKeyOfLambda), compose.WithNodeName("PlanExecuteReplan"))
+	_ = g.AddLambdaNode(RagEntry, compose.InvokableLambda(func(ctx context.Context, input *UserMessage) (*UserMessage, error) {
+		return input, nil
+	}))
+	inputToRagKeyOfLambda, err := newInputToRagLambda(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_ = g.AddLambdaNode(InputToRag, compose.InvokableLambdaWithOption(inputToRagKeyOfLambda), compose.WithNodeName("UserMessageToRag"))
 	chatTemplateKeyOfChatTemplate, err := newChatTemplate(ctx)
 	if err != nil {
 		return nil, err
 	}
 	_ = g.AddChatTemplateNode(ChatTemplate, chatTemplateKeyOfChatTemplate)
-	reactAgentKeyOfLambda, err := newReactAgentLambda(ctx)
+	reactAgentKeyOfGraph, reactAgentOpts, err := newReactAgentGraph(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+	_ = g.AddGraphNode(ReactAgent, reactAgentKeyOfGraph, append(reactAgentOpts, compose.WithNodeName("ReActAgent"))...)
+	ragAnswerKeyOfChatModel, err := newChatModel(ctx)
 	if err != nil {
 		return nil, err
 	}
-	_ = g.AddLambdaNode(ReactAgent, reactAgentKeyOfLambda, compose.WithNodeName("ReActAgent"))
+	_ = g.AddChatModelNode(RagAnswer, ragAnswerKeyOfChatModel)
 	milvusRetrieverKeyOfRetriever, err := newRetriever(ctx)
 	if err != nil {
 		return nil, err
 	}
-	// 注意下面的 output key 设置，把查询出来的设置为了documents，匹配 ChatTemplate 里面说prompt
-	_ = g.AddRetrieverNode(MilvusRetriever, milvusRetrieverKeyOfRetriever, compose.WithOutputKey("documents"))
+	rerankEnabled := reranker.GetConfig(ctx).Enabled
+	if rerankEnabled {
+		// 启用重排时检索结果先作为候选 candidates 交给重排节点，重排后的 top-N 再编号注入 prompt
+		rerankKeyOfLambda, err := newRerankLambda(ctx)
+		if err != nil {
+			return nil, err
+		}
+		_ = g.AddRetrieverNode(MilvusRetriever, milvusRetrieverKeyOfRetriever, compose.WithOutputKey("candidates"))
+		_ = g.AddLambdaNode(InputToRerank, compose.InvokableLambdaWithOption(newInputToRerankLambda), compose.WithNodeName("UserMessageToRerank"))
+		_ = g.AddLambdaNode(RerankerNode, rerankKeyOfLambda)
+	} else {
+		_ = g.AddRetrieverNode(MilvusRetriever, milvusRetrieverKeyOfRetriever)
+	}
+	_ = g.AddLambdaNode(ExpandParents, compose.InvokableLambdaWithOption(newExpandParentsLambda(ctx)))
+	grounding := GetGroundingConfig(ctx)
+	_ = g.AddLambdaNode(Grounding, compose.InvokableLambdaWithOption(newGroundingLambda(grounding)), compose.WithOutputKey("docs"))
+	// 预算节点合并文档与对话输入，裁剪后把编号的文档设置为 documents，匹配 ChatTemplate 里面的 prompt
+	_ = g.AddLambdaNode(PromptBudget, compose.InvokableLambdaWithOption(newPromptBudgetLambda(ctx)))
+	groundingLabelKeyOfLambda, err := newGroundingLabelLambda(grounding.Label)
+	if err != nil {
+		return nil, err
+	}
+	_ = g.AddLambdaNode(GroundingLabel, groundingLabelKeyOfLambda)
+	if grounding.Policy == GroundingRefuse {
+		_ = g.AddLambdaNode(NoRunbook, newRefuseLambda(grounding.RefuseMessage))
+	}
 	_ = g.AddLambdaNode(InputToChat, compose.InvokableLambdaWithOption(newInputToChatLambda), compose.WithNodeName("UserMessageToChat"))
-	_ = g.AddEdge(compose.START, InputToRag)
-	_ = g.AddEdge(compose.START, InputToChat)
-	_ = g.AddEdge(ReactAgent, compose.END)
+	_ = g.AddEdge(compose.START, Router)
+	// 寒暄与故障排查不进入检索流程
+	_ = g.AddBranch(Router, newRouteBranch(SmallTalk, Incident, RagEntry))
+	_ = g.AddEdge(SmallTalk, compose.END)
+	_ = g.AddEdge(Incident, compose.END)
+	_ = g.AddEdge(RagEntry, InputToRag)
+	_ = g.AddEdge(RagEntry, InputToChat)
+	_ = g.AddEdge(ReactAgent, GroundingLabel)
+	_ = g.AddEdge(RagAnswer, GroundingLabel)
+	_ = g.AddEdge(GroundingLabel, compose.END)
 	_ = g.AddEdge(InputToRag, MilvusRetriever)
-	_ = g.AddEdge(MilvusRetriever, ChatTemplate)
-	_ = g.AddEdge(InputToChat, ChatTemplate)
-	_ = g.AddEdge(ChatTemplate, ReactAgent)
-	r, err = g.Compile(ctx, compose.WithGraphName("ChatAgent"), compose.WithNodeTriggerMode(compose.AllPredecessor))
+	if rerankEnabled {
+		_ = g.AddEdge(RagEntry, InputToRerank)
+		_ = g.AddEdge(MilvusRetriever, RerankerNode)
+		_ = g.AddEdge(InputToRerank, RerankerNode)
+		_ = g.AddEdge(RerankerNode, ExpandParents)
+	} else {
+		_ = g.AddEdge(MilvusRetriever, ExpandParents)
+	}
+	_ = g.AddEdge(ExpandParents, Grounding)
+	_ = g.AddEdge(Grounding, PromptBudget)
+	_ = g.AddEdge(InputToChat, PromptBudget)
+	_ = g.AddEdge(PromptBudget, ChatTemplate)
+	// 检索未命中且策略为 refuse 时不调用模型，直接返回模板回复；文档问答不调用工具
+	_ = g.AddBranch(ChatTemplate, newAnswerBranch(ReactAgent, RagAnswer, NoRunbook, grounding.Policy == GroundingRefuse))
+	if grounding.Policy == GroundingRefuse {
+		_ = g.AddEdge(NoRunbook, compose.END)
+	}
+	// 有副作用的工具调用等待审批时中断执行并保存检查点，调用方使用同一检查点 ID 恢复
+	r, err = g.Compile(ctx, compose.WithGraphName("ChatAgent"), compose.WithNodeTriggerMode(compose.AllPredecessor),
+		compose.WithCheckPointStore(checkPoints))
 	if err != nil {
 		return nil, err
 	}
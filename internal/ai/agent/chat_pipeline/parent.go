@@ -0,0 +1,23 @@
+package chat_pipeline
+
+import (
+	"context"
+	retriever2 "github.com/NuyoahCh/eocall/internal/ai/retriever"
+	"github.com/cloudwego/eino/schema"
+	"log"
+)
+
+// newExpandParentsLambda component initialization function of node 'ExpandParents' in graph 'ChatAgent'
+// 检索与重排在小切片上进行以保证精度，注入提示词前将命中切片扩展为所属父章节，保证模型拿到完整的处理步骤
+func newExpandParentsLambda(ctx context.Context) func(ctx context.Context, docs []*schema.Document, opts ...any) ([]*schema.Document, error) {
+	pc := retriever2.GetParentConfig(ctx)
+	return func(ctx context.Context, docs []*schema.Document, opts ...any) ([]*schema.Document, error) {
+		out, err := retriever2.ExpandParents(ctx, docs, pc)
+		if err != nil {
+			// 扩展失败时退回命中的切片，不影响回答
+			log.Printf("[warn] expand parent sections failed: %v", err)
+			return docs, nil
+		}
+		return out, nil
+	}
+}
@@ -0,0 +1,93 @@
+package chat_pipeline
+
+import (
+	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/reranker"
+	retriever2 "github.com/NuyoahCh/eocall/internal/ai/retriever"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// RerankerNode 图中重排节点的名称，用于向该节点传递调用参数
+const RerankerNode = "Reranker"
+
+// rerankOptions 重排节点的单次调用参数
+type rerankOptions struct {
+	TopN int
+}
+
+// RerankOption 重排节点的调用选项
+type RerankOption func(o *rerankOptions)
+
+// WithRerankTopN 设置重排后注入提示词的文档数量
+func WithRerankTopN(topN int) RerankOption {
+	return func(o *rerankOptions) {
+		o.TopN = topN
+	}
+}
+
+// RetrievalOptions 将单次请求的检索覆盖项转换为图的调用选项
+// 启用重排时检索节点按 rerank.candidates 多召回候选，请求的 top_k 作用于重排后的文档数量
+func RetrievalOptions(ctx context.Context, overrides *retriever2.Overrides) []compose.Option {
+	rc := reranker.GetConfig(ctx)
+	if !rc.Enabled {
+		return []compose.Option{compose.WithRetrieverOption(overrides.Options()...)}
+	}
+	topN := rc.TopN
+	if topN <= 0 {
+		topN = retriever2.GetConfig(ctx).TopK
+	}
+	opts := []retriever.Option{}
+	if overrides != nil {
+		if overrides.TopK > 0 {
+			topN = min(overrides.TopK, retriever2.MaxTopK)
+		}
+		if overrides.ScoreThreshold != nil {
+			opts = append(opts, retriever.WithScoreThreshold(*overrides.ScoreThreshold))
+		}
+	}
+	opts = append(opts, retriever.WithTopK(max(rc.Candidates, topN)))
+	return []compose.Option{
+		compose.WithRetrieverOption(opts...),
+		compose.WithLambdaOption(WithRerankTopN(topN)).DesignateNode(RerankerNode),
+	}
+}
+
+// newInputToRerankLambda component initialization function of node 'InputToRerank' in graph 'ChatAgent'
+func newInputToRerankLambda(ctx context.Context, input *UserMessage, opts ...any) (output map[string]any, err error) {
+	return map[string]any{
+		"query": input.Query,
+	}, nil
+}
+
+// newRerankLambda 重排节点，输入为检索节点输出的 candidates 与原始查询 query
+// 按改写后的检索查询重排，使追问与检索使用同一个独立查询；InputToRerank 与改写节点并行执行，
+// 因此改写结果在本节点（位于检索节点之后）从图的状态中读取，未改写时使用原始查询
+func newRerankLambda(ctx context.Context) (*compose.Lambda, error) {
+	rr := reranker.NewReranker(ctx)
+	defaultTopN := reranker.GetConfig(ctx).TopN
+	if defaultTopN <= 0 {
+		defaultTopN = retriever2.GetConfig(ctx).TopK
+	}
+	return compose.InvokableLambdaWithOption(func(ctx context.Context, input map[string]any, opts ...RerankOption) ([]*schema.Document, error) {
+		o := &rerankOptions{TopN: defaultTopN}
+		for _, opt := range opts {
+			opt(o)
+		}
+		docs, _ := input["candidates"].([]*schema.Document)
+		query, _ := input["query"].(string)
+		_ = compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+			if s.RewrittenQuery != "" {
+				query = s.RewrittenQuery
+			}
+			return nil
+		})
+		out, err := rr.Rerank(ctx, query, docs, o.TopN)
+		if err != nil {
+			return nil, fmt.Errorf("rerank documents failed: %w", err)
+		}
+		return out, nil
+	}), nil
+}
@@ -4,8 +4,62 @@ import (
 	"context"
 	retriever2 "github.com/NuyoahCh/eocall/internal/ai/retriever"
 	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"sync"
 )
 
 func newRetriever(ctx context.Context) (rtr retriever.Retriever, err error) {
-	return retriever2.NewMilvusRetriever(ctx)
+	inner, err := retriever2.NewRetriever(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &multiQueryRetriever{inner: inner}, nil
+}
+
+// multiQueryRetriever 使用改写后的查询及其子查询并发检索，多路结果按 RRF 融合
+type multiQueryRetriever struct {
+	inner retriever.Retriever
+}
+
+// Retrieve 子查询由查询改写节点写入图的状态，不在图中运行时只检索 query 本身
+func (m *multiQueryRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	queries := []string{query}
+	_ = compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+		queries = append(queries, s.SubQueries...)
+		return nil
+	})
+	if len(queries) == 1 {
+		return m.inner.Retrieve(ctx, query, opts...)
+	}
+	var (
+		wg      sync.WaitGroup
+		results = make([][]*schema.Document, len(queries))
+		errs    = make([]error, len(queries))
+	)
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q string) {
+			defer wg.Done()
+			results[i], errs[i] = m.inner.Retrieve(ctx, q, opts...)
+		}(i, q)
+	}
+	wg.Wait()
+	limit := 0
+	for i := range queries {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		limit = max(limit, len(results[i]))
+	}
+	docs := retriever2.Fuse(0, results...)
+	if len(docs) > limit {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
+
+// GetType 组件类型，用于回调中展示
+func (m *multiQueryRetriever) GetType() string {
+	return "MultiQuery"
 }
@@ -0,0 +1,139 @@
+package chat_pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/models"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"log"
+	"strings"
+)
+
+// 查询改写参数默认值
+const (
+	defaultRewriteMaxHistory = 6
+	maxSubQueries            = 5
+)
+
+// RewriteConfig 查询改写配置，对应配置文件中的 query_rewrite 节点
+type RewriteConfig struct {
+	Enabled       bool // 是否在检索前结合历史改写查询
+	MaxHistory    int  // 参与改写的最近历史消息条数
+	MaxSubQueries int  // 额外拆分的子查询数量，0 表示不拆分
+}
+
+// GetRewriteConfig 从配置文件读取查询改写配置，未配置的项使用默认值
+func GetRewriteConfig(ctx context.Context) *RewriteConfig {
+	cfg := g.Cfg()
+	c := &RewriteConfig{
+		Enabled:       cfg.MustGet(ctx, "query_rewrite.enabled", true).Bool(),
+		MaxHistory:    cfg.MustGet(ctx, "query_rewrite.max_history", defaultRewriteMaxHistory).Int(),
+		MaxSubQueries: cfg.MustGet(ctx, "query_rewrite.max_sub_queries").Int(),
+	}
+	if c.MaxHistory <= 0 {
+		c.MaxHistory = defaultRewriteMaxHistory
+	}
+	c.MaxSubQueries = min(max(c.MaxSubQueries, 0), maxSubQueries)
+	return c
+}
+
+// RewriteResult 查询改写结果
+type RewriteResult struct {
+	Query      string   `json:"query"`       // 可独立检索的完整查询
+	SubQueries []string `json:"sub_queries"` // 复合问题拆分出的子查询
+}
+
+// queryRewriter 使用快速模型将历史与当前问题压缩为独立的检索查询
+type queryRewriter struct {
+	config *RewriteConfig
+	model  model.BaseChatModel
+}
+
+func newQueryRewriter(ctx context.Context) (*queryRewriter, error) {
+	c := GetRewriteConfig(ctx)
+	if !c.Enabled {
+		return &queryRewriter{config: c}, nil
+	}
+	cm, err := models.OpenAIForDeepSeekV3Quick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &queryRewriter{config: c, model: cm}, nil
+}
+
+// Rewrite 改写查询，没有历史且无需拆分子查询时直接返回原始问题，模型调用失败时退化为原始问题
+func (r *queryRewriter) Rewrite(ctx context.Context, query string, history []*schema.Message) *RewriteResult {
+	origin := &RewriteResult{Query: query}
+	if !r.config.Enabled || r.model == nil || (len(history) == 0 && r.config.MaxSubQueries == 0) {
+		return origin
+	}
+	if len(history) > r.config.MaxHistory {
+		history = history[len(history)-r.config.MaxHistory:]
+	}
+	out, err := r.model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(fmt.Sprintf(rewritePrompt, r.config.MaxSubQueries)),
+		schema.UserMessage(formatRewriteInput(query, history)),
+	})
+	if err != nil {
+		log.Printf("[warn] rewrite query failed, use original query: %v", err)
+		return origin
+	}
+	result, err := parseRewriteResult(out.Content)
+	if err != nil || result.Query == "" {
+		log.Printf("[warn] parse rewritten query failed, use original query: %v, output: %s", err, out.Content)
+		return origin
+	}
+	if len(result.SubQueries) > r.config.MaxSubQueries {
+		result.SubQueries = result.SubQueries[:r.config.MaxSubQueries]
+	}
+	return result
+}
+
+func formatRewriteInput(query string, history []*schema.Message) string {
+	var sb strings.Builder
+	sb.WriteString("对话历史：\n")
+	if len(history) == 0 {
+		sb.WriteString("（无）\n")
+	}
+	for _, m := range history {
+		sb.WriteString(string(m.Role))
+		sb.WriteString(": ")
+		sb.WriteString(m.Content)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n当前问题：")
+	sb.WriteString(query)
+	return sb.String()
+}
+
+// parseRewriteResult 解析模型输出的 JSON，兼容被代码块包裹的输出
+func parseRewriteResult(content string) (*RewriteResult, error) {
+	content = strings.TrimSpace(content)
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		content = content[start : end+1]
+	}
+	result := &RewriteResult{}
+	if err := json.Unmarshal([]byte(content), result); err != nil {
+		return nil, err
+	}
+	result.Query = strings.TrimSpace(result.Query)
+	subQueries := result.SubQueries[:0]
+	for _, q := range result.SubQueries {
+		if q = strings.TrimSpace(q); q != "" && q != result.Query {
+			subQueries = append(subQueries, q)
+		}
+	}
+	result.SubQueries = subQueries
+	return result, nil
+}
+
+var rewritePrompt = `你是运维知识库的检索查询改写助手。
+根据对话历史和当前问题，输出一个可以脱离上下文独立检索的完整查询：
+- 将代词、序号（如"第二个告警"）等指代替换为历史中对应的告警名、服务名、错误码、地域等具体内容
+- 保留原问题中的错误码、告警名、指标名等专有名词，不要翻译或改写
+- 不要回答问题，也不要补充历史中没有的信息
+如果问题包含多个相互独立的方面，可以额外拆分出最多 %d 个子查询，为 0 时不拆分。
+只输出 JSON，格式为：{"query": "完整查询", "sub_queries": ["子查询"]}`
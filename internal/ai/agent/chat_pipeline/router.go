@@ -0,0 +1,307 @@
+package chat_pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/agent/plan_execute_replan"
+	"github.com/NuyoahCh/eocall/internal/ai/models"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 对话请求的处理路线
+const (
+	RouteSmallTalk = "small_talk" // 寒暄闲聊，快速模型直接回答
+	RouteKnowledge = "knowledge"  // 文档问答，检索后由模型直接回答，不调用工具
+	RouteIncident  = "incident"   // 故障排查，交给 plan-execute-replan 智能体调查
+	RouteAgent     = "agent"      // 其他请求，检索后由 ReAct 智能体结合工具回答
+)
+
+// routes 合法的路线
+var routes = map[string]bool{RouteSmallTalk: true, RouteKnowledge: true, RouteIncident: true, RouteAgent: true}
+
+// 规则分类使用的模式
+var (
+	smallTalkPattern = regexp.MustCompile(`(?i)^(你好|您好|哈喽|嗨|早上好|中午好|下午好|晚上好|早安|晚安|在吗|在不在|谢谢|多谢|感谢|辛苦了|好的|好的谢谢|再见|拜拜|你是谁|hi|hello|hey|thanks|thank you|bye|ok)[\s!！。.~～?？,，]*$`)
+	incidentPattern  = regexp.MustCompile(`(?i)(告警|报警|故障|宕机|异常|报错|超时|不可用|挂了|OOM|5\d\d|延迟|失败)`)
+	investigate      = regexp.MustCompile(`(现在|当前|刚刚|刚才|正在|一直|突然|帮我排查|排查一下|看一下|查一下|定位|分析一下|根因|怎么回事)`)
+)
+
+// RouterConfig 意图路由配置，对应配置文件中的 intent_router 节点
+type RouterConfig struct {
+	Enabled      bool   // 是否按意图分流，关闭时所有请求走 agent 路线
+	UseModel     bool   // 规则无法判断时是否使用快速模型分类
+	DefaultRoute string // 无法判断或分类失败时使用的路线
+}
+
+// GetRouterConfig 从配置文件读取意图路由配置，未配置的项使用默认值
+func GetRouterConfig(ctx context.Context) *RouterConfig {
+	cfg := g.Cfg()
+	c := &RouterConfig{
+		Enabled:      cfg.MustGet(ctx, "intent_router.enabled", true).Bool(),
+		UseModel:     cfg.MustGet(ctx, "intent_router.use_model", true).Bool(),
+		DefaultRoute: cfg.MustGet(ctx, "intent_router.default_route", RouteAgent).String(),
+	}
+	if !routes[c.DefaultRoute] {
+		c.DefaultRoute = RouteAgent
+	}
+	return c
+}
+
+// intentRouter 先按规则识别寒暄与明显的故障排查请求，其余请求交给快速模型分类
+type intentRouter struct {
+	config *RouterConfig
+	model  model.BaseChatModel
+}
+
+func newIntentRouter(ctx context.Context) (*intentRouter, error) {
+	c := GetRouterConfig(ctx)
+	if !c.Enabled || !c.UseModel {
+		return &intentRouter{config: c}, nil
+	}
+	cm, err := models.OpenAIForDeepSeekV3Quick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &intentRouter{config: c, model: cm}, nil
+}
+
+// Classify 判断请求的处理路线，模型调用失败时使用默认路线
+func (r *intentRouter) Classify(ctx context.Context, query string, history []*schema.Message) string {
+	if !r.config.Enabled {
+		return RouteAgent
+	}
+	if route := classifyByRules(query); route != "" {
+		return route
+	}
+	if r.model == nil {
+		return r.config.DefaultRoute
+	}
+	if len(history) > defaultRewriteMaxHistory {
+		history = history[len(history)-defaultRewriteMaxHistory:]
+	}
+	out, err := r.model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(routerPrompt),
+		schema.UserMessage(formatRewriteInput(query, history)),
+	})
+	if err != nil {
+		log.Printf("[warn] classify intent failed, use default route %s: %v", r.config.DefaultRoute, err)
+		return r.config.DefaultRoute
+	}
+	route, err := parseRoute(out.Content)
+	if err != nil {
+		log.Printf("[warn] parse intent failed, use default route %s: %v, output: %s", r.config.DefaultRoute, err, out.Content)
+		return r.config.DefaultRoute
+	}
+	return route
+}
+
+// classifyByRules 按规则识别寒暄与正在发生的故障，无法判断时返回空
+func classifyByRules(query string) string {
+	q := strings.TrimSpace(query)
+	if q == "" || smallTalkPattern.MatchString(q) {
+		return RouteSmallTalk
+	}
+	if utf8.RuneCountInString(q) <= 80 && incidentPattern.MatchString(q) && investigate.MatchString(q) {
+		return RouteIncident
+	}
+	return ""
+}
+
+// parseRoute 解析模型输出的 JSON，兼容被代码块包裹的输出
+func parseRoute(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		content = content[start : end+1]
+	}
+	var result struct {
+		Route string `json:"route"`
+	}
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return "", err
+	}
+	route := strings.TrimSpace(result.Route)
+	if !routes[route] {
+		return "", fmt.Errorf("unknown route %q", route)
+	}
+	return route, nil
+}
+
+// newRouterLambda component initialization function of node 'Router' in graph 'ChatAgent'
+// 识别请求意图并按工具策略确定可以使用的工具，写入图的状态，由路由分支选择后续节点
+// 排查智能体不可用或调用方无权使用其全部工具时，故障排查请求退回 agent 路线
+func newRouterLambda(ctx context.Context, ts *toolSet, incident IncidentAgent) (func(ctx context.Context, input *UserMessage, opts ...any) (*UserMessage, error), error) {
+	router, err := newIntentRouter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, input *UserMessage, opts ...any) (*UserMessage, error) {
+		route := router.Classify(ctx, input.Query, input.History)
+		permitted := ts.Permitted(ctx)
+		var incidentTools []string
+		if route == RouteIncident {
+			var err error
+			if incidentTools, err = incidentToolNames(incident); err != nil {
+				log.Printf("[warn] get incident agent failed, fall back to route %s: %v", RouteAgent, err)
+				route = RouteAgent
+			} else if len(tools.Permitted(ctx, incidentTools)) < len(incidentTools) {
+				log.Printf("[info] incident investigation is not permitted for %s, fall back to route %s", auth.FromContext(ctx).User, RouteAgent)
+				route = RouteAgent
+			}
+		}
+		// 只有 ReAct 智能体与排查智能体会调用工具
+		available := []string{}
+		switch route {
+		case RouteAgent:
+			available = permitted
+		case RouteIncident:
+			available = incidentTools
+		}
+		recordTrace(ctx, func(t *Trace) {
+			t.Route = route
+			t.Tools = available
+		})
+		err := compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+			s.Route = route
+			s.Tools = permitted
+			s.Query = input.Query
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return input, nil
+	}, nil
+}
+
+// newRouteBranch 按意图选择后续节点：寒暄直接回答，故障排查交给调查节点，其余请求进入检索流程
+func newRouteBranch(smallTalkNode, incidentNode, ragNode string) *compose.GraphBranch {
+	return compose.NewGraphBranch(func(ctx context.Context, in *UserMessage) (string, error) {
+		next := ragNode
+		err := compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+			switch s.Route {
+			case RouteSmallTalk:
+				next = smallTalkNode
+			case RouteIncident:
+				next = incidentNode
+			}
+			return nil
+		})
+		return next, err
+	}, map[string]bool{smallTalkNode: true, incidentNode: true, ragNode: true})
+}
+
+// newAnswerBranch 检索完成后选择回答节点：检索未命中且策略为 refuse 时返回模板回复，
+// 文档问答或没有可用工具时由模型直接回答，其余请求交给 ReAct 智能体
+func newAnswerBranch(agentNode, ragAnswerNode, refuseNode string, refuse bool) *compose.GraphBranch {
+	ends := map[string]bool{agentNode: true, ragAnswerNode: true}
+	if refuse {
+		ends[refuseNode] = true
+	}
+	return compose.NewGraphBranch(func(ctx context.Context, in []*schema.Message) (string, error) {
+		next := agentNode
+		err := compose.ProcessState[*chatState](ctx, func(_ context.Context, s *chatState) error {
+			switch {
+			case refuse && !s.Grounded:
+				next = refuseNode
+			case s.Route == RouteKnowledge || len(s.Tools) == 0:
+				next = ragAnswerNode
+			}
+			return nil
+		})
+		return next, err
+	}, ends)
+}
+
+// newSmallTalkLambda component initialization function of node 'SmallTalk' in graph 'ChatAgent'
+// 寒暄闲聊不检索也不调用工具，由快速模型结合最近的历史直接回答
+func newSmallTalkLambda(ctx context.Context) (*compose.Lambda, error) {
+	cm, err := models.OpenAIForDeepSeekV3Quick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	messages := func(input *UserMessage) []*schema.Message {
+		history := input.History
+		if len(history) > DefaultKeepRecentHistory {
+			history = history[len(history)-DefaultKeepRecentHistory:]
+		}
+		msgs := make([]*schema.Message, 0, len(history)+2)
+		msgs = append(msgs, schema.SystemMessage(smallTalkPrompt))
+		msgs = append(msgs, history...)
+		return append(msgs, schema.UserMessage(input.Query))
+	}
+	invoke := func(ctx context.Context, input *UserMessage, opts ...any) (*schema.Message, error) {
+		return cm.Generate(ctx, messages(input))
+	}
+	stream := func(ctx context.Context, input *UserMessage, opts ...any) (*schema.StreamReader[*schema.Message], error) {
+		return cm.Stream(ctx, messages(input))
+	}
+	return compose.AnyLambda(invoke, stream, nil, nil)
+}
+
+// IncidentAgent 获取所有请求共享的 plan-execute-replan 智能体及其执行器使用的工具名，运行结束后调用 release
+type IncidentAgent func() (agent adk.Agent, tools []string, release func(), err error)
+
+// incidentToolNames 排查智能体使用的工具名
+func incidentToolNames(incident IncidentAgent) ([]string, error) {
+	_, names, release, err := incident()
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return names, nil
+}
+
+// newIncidentLambda component initialization function of node 'Incident' in graph 'ChatAgent'
+// 故障排查请求交给共享的 plan-execute-replan 智能体，结合告警、日志与内部文档生成排查报告
+func newIncidentLambda(incident IncidentAgent) func(ctx context.Context, input *UserMessage, opts ...any) (*schema.Message, error) {
+	return func(ctx context.Context, input *UserMessage, opts ...any) (*schema.Message, error) {
+		agent, _, release, err := incident()
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		result, _, err := plan_execute_replan.RunPlanAgent(ctx, agent, fmt.Sprintf(incidentPrompt, input.Query))
+		if err != nil {
+			return nil, err
+		}
+		return schema.AssistantMessage(result, nil), nil
+	}
+}
+
+var routerPrompt = `你是运维助手的请求分类器，根据对话历史和当前问题判断处理路线：
+- small_talk：寒暄、感谢、询问助手身份等与运维无关的闲聊
+- knowledge：询问内部文档、流程、规范、处理手册（runbook）中的知识，只需查阅文档即可回答
+- incident：要求排查、分析正在发生的告警或故障，需要查询告警、日志等实时数据
+- agent：其他需要调用工具的请求，如查询当前时间、查询数据库、查询日志等
+只输出 JSON，格式为：{"route": "small_talk|knowledge|incident|agent"}`
+
+var smallTalkPrompt = `你是运维团队的对话小助手，可以查阅内部运维文档、排查告警与故障。
+用简短、友好的纯文本回复用户的寒暄；如果用户有运维相关的问题，引导用户直接描述。`
+
+var incidentPrompt = `
+"1. 你是一个智能的服务故障排查助手，用户描述的问题如下：%s"
+"2. 调用工具query_prometheus_alerts获取与问题相关的活跃告警。"
+"3. 根据问题与告警的名称调用工具query_internal_docs，获取对应的处理方案。"
+"4. 完全遵循内部文档的内容进行查询和分析，不允许使用文档外的任何信息。"
+"5. 涉及到时间的参数都需要先通过工具get_current_time获取当前时间，再结合工具的时间要求进行传参。"
+"6. 涉及到日志的查询，需要先通过日志工具获取相关日志信息，参数必须携带地域和日志主题。"
+"7. 将查询到的信息进行总结分析，最后生成故障排查报告，格式如下：
+故障排查报告
+---
+# 问题描述
+## 相关告警
+## 根因分析
+## 处理建议
+## 结论
+`
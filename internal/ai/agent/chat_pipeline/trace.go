@@ -0,0 +1,55 @@
+package chat_pipeline
+
+import (
+	"context"
+	"github.com/cloudwego/eino/schema"
+	"slices"
+	"sync"
+)
+
+// Trace 单次对话的中间结果，用于生成引用来源与调试信息
+type Trace struct {
+	mu sync.Mutex
+
+	Route          string             // 意图路由选择的处理路线
+	Tools          []string           // 本次请求可以使用的工具
+	RewrittenQuery string             // 结合历史改写后的检索查询
+	SubQueries     []string           // 拆分出的子查询
+	Documents      []*schema.Document // 按编号顺序注入提示词的文档
+	Grounded       bool               // 检索结果是否足以作为回答依据
+	Budget         *BudgetReport      // 提示词预算的使用情况
+}
+
+type traceKey struct{}
+
+// WithTrace 在上下文中挂载中间结果收集器，图中各节点会将中间结果写入其中
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	t := &Trace{}
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// recordTrace 记录中间结果，上下文中没有收集器时不做任何处理
+func recordTrace(ctx context.Context, fn func(t *Trace)) {
+	t, ok := ctx.Value(traceKey{}).(*Trace)
+	if !ok || t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(t)
+}
+
+// Snapshot 加锁复制当前的中间结果，图仍在运行时读取中间结果需通过该方法
+func (t *Trace) Snapshot() *Trace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &Trace{
+		Route:          t.Route,
+		Tools:          slices.Clone(t.Tools),
+		RewrittenQuery: t.RewrittenQuery,
+		SubQueries:     slices.Clone(t.SubQueries),
+		Documents:      slices.Clone(t.Documents),
+		Grounded:       t.Grounded,
+		Budget:         t.Budget,
+	}
+}
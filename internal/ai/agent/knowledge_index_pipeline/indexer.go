@@ -6,7 +6,7 @@ import (
 	"github.com/cloudwego/eino/components/indexer"
 )
 
-// newIndexer component initialization function of node 'RedisIndexer' in graph 'KnowledgeIndexing'
+// newIndexer component initialization function of node 'MilvusIndexer' in graph 'KnowledgeIndexing'
 func newIndexer(ctx context.Context) (idr indexer.Indexer, err error) {
-	return indexer2.NewMilvusIndexer(ctx)
+	return indexer2.NewIndexer(ctx)
 }
@@ -11,6 +11,7 @@ func BuildKnowledgeIndexing(ctx context.Context) (r compose.Runnable[document.So
 	const (
 		FileLoader       = "FileLoader"
 		MarkdownSplitter = "MarkdownSplitter"
+		ParentLinker     = "ParentLinker"
 		MilvusIndexer    = "MilvusIndexer"
 	)
 	g := compose.NewGraph[document.Source, []string]()
@@ -24,6 +25,11 @@ func BuildKnowledgeIndexing(ctx context.Context) (r compose.Runnable[document.So
 		return nil, err
 	}
 	_ = g.AddDocumentTransformerNode(MarkdownSplitter, markdownSplitterKeyOfDocumentTransformer)
+	parentLinkerKeyOfDocumentTransformer, err := newParentLinker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_ = g.AddDocumentTransformerNode(ParentLinker, parentLinkerKeyOfDocumentTransformer)
 	milvusIndexerKeyOfIndexer, err := newIndexer(ctx)
 	if err != nil {
 		return nil, err
@@ -32,7 +38,8 @@ func BuildKnowledgeIndexing(ctx context.Context) (r compose.Runnable[document.So
 	_ = g.AddEdge(compose.START, FileLoader)
 	_ = g.AddEdge(MilvusIndexer, compose.END)
 	_ = g.AddEdge(FileLoader, MarkdownSplitter)
-	_ = g.AddEdge(MarkdownSplitter, MilvusIndexer)
+	_ = g.AddEdge(MarkdownSplitter, ParentLinker)
+	_ = g.AddEdge(ParentLinker, MilvusIndexer)
 	r, err = g.Compile(ctx, compose.WithGraphName("KnowledgeIndexing"), compose.WithNodeTriggerMode(compose.AnyPredecessor))
 	if err != nil {
 		return nil, err
@@ -0,0 +1,39 @@
+package knowledge_index_pipeline
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/retriever"
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+)
+
+// parentLinker 为每个切片记录所属父章节与在文件中的顺序，检索命中后据此扩展为完整章节
+type parentLinker struct{}
+
+// newParentLinker component initialization function of node 'ParentLinker' in graph 'KnowledgeIndexing'
+func newParentLinker(ctx context.Context) (tfr document.Transformer, err error) {
+	return &parentLinker{}, nil
+}
+
+// Transform 父章节为一级标题（metadata title）划分的章节，没有一级标题的内容归入文件级章节
+func (p *parentLinker) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	for i, doc := range src {
+		if doc.MetaData == nil {
+			doc.MetaData = map[string]any{}
+		}
+		source := fmt.Sprint(doc.MetaData["_source"])
+		title, _ := doc.MetaData[retriever.ParentHeaderKey].(string)
+		sum := sha1.Sum([]byte(source + "\x00" + title))
+		doc.MetaData[retriever.MetaParentID] = hex.EncodeToString(sum[:8])
+		doc.MetaData[retriever.MetaChunkIndex] = i
+	}
+	return src, nil
+}
+
+// GetType 组件类型，用于回调中展示
+func (p *parentLinker) GetType() string {
+	return "ParentLinker"
+}
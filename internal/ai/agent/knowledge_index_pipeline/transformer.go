@@ -10,8 +10,11 @@ import (
 // newDocumentTransformer component initialization function of node 'MarkdownSplitter' in graph 'KnowledgeIndexing'
 func newDocumentTransformer(ctx context.Context) (tfr document.Transformer, err error) {
 	config := &markdown.HeaderConfig{
+		// 按一至三级标题切分为小切片，一级标题所在章节作为父章节
 		Headers: map[string]string{
-			"#": "title",
+			"#":   "title",
+			"##":  "h2",
+			"###": "h3",
 		},
 		TrimHeaders: false,
 		IDGenerator: func(ctx context.Context, originalID string, splitIndex int) string {
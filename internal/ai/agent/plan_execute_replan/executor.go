@@ -6,22 +6,28 @@ import (
 	"github.com/NuyoahCh/eocall/internal/ai/tools"
 	"github.com/cloudwego/eino/adk"
 	"github.com/cloudwego/eino/adk/prebuilt/planexecute"
+	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 )
 
-func NewExecutor(ctx context.Context) (adk.Agent, error) {
-	// log
-	mcpTool, err := tools.GetLogMcpTool()
-	if err != nil {
-		return nil, err
-	}
-	toolList := mcpTool
-	// alerts
-	toolList = append(toolList, tools.NewPrometheusAlertsQueryTool())
+// ExecutorTools 执行器使用的工具
+func ExecutorTools(ctx context.Context) ([]tool.BaseTool, error) {
+	// mcp，如日志查询
+	toolList := tools.GetMcpTools(ctx)
+	// alerts & metrics
+	toolList = append(toolList, tools.NewPrometheusAlertsQueryTool(), tools.NewPrometheusMetricsQueryTool())
+	// alertmanager，排查智能体不支持审批，只提供只读工具
+	toolList = append(toolList, tools.NewAlertmanagerAlertGroupsTool(), tools.NewAlertmanagerSilencesTool())
 	// file
 	toolList = append(toolList, tools.NewQueryInternalDocsTool())
 	// time
 	toolList = append(toolList, tools.NewGetCurrentTimeTool())
+	// database schema
+	toolList = append(toolList, tools.NewListTablesTool(), tools.NewDescribeTableTool(), tools.NewSampleRowsTool())
+	return toolList, nil
+}
+
+func NewExecutor(ctx context.Context, toolList []tool.BaseTool) (adk.Agent, error) {
 	execModel, err := models.OpenAIForDeepSeekV3Quick(ctx)
 	if err != nil {
 		return nil, err
@@ -3,23 +3,34 @@ package plan_execute_replan
 import (
 	"context"
 	"fmt"
-	"github.com/cloudwego/eino-examples/adk/common/prints"
 	"github.com/cloudwego/eino/adk"
 	"github.com/cloudwego/eino/adk/prebuilt/planexecute"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/gogf/gf/v2/frame/g"
 )
 
-func BuildPlanAgent(ctx context.Context, query string) (string, []string, error) {
+// NewPlanExecuteAgent 构建 plan-execute-replan 智能体，构建后可在多个请求间共享
+func NewPlanExecuteAgent(ctx context.Context) (adk.Agent, error) {
+	toolList, err := ExecutorTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewPlanExecuteAgentWithTools(ctx, toolList)
+}
+
+// NewPlanExecuteAgentWithTools 使用指定的执行器工具构建 plan-execute-replan 智能体
+func NewPlanExecuteAgentWithTools(ctx context.Context, toolList []tool.BaseTool) (adk.Agent, error) {
 	planAgent, err := NewPlanner(ctx)
 	if err != nil {
-		return "", []string{}, err
+		return nil, err
 	}
-	executeAgent, err := NewExecutor(ctx)
+	executeAgent, err := NewExecutor(ctx, toolList)
 	if err != nil {
-		return "", []string{}, err
+		return nil, err
 	}
 	replanAgent, err := NewRePlanAgent(ctx)
 	if err != nil {
-		return "", []string{}, err
+		return nil, err
 	}
 	planExecuteAgent, err := planexecute.New(ctx, &planexecute.Config{
 		Planner:       planAgent,
@@ -28,10 +39,15 @@ func BuildPlanAgent(ctx context.Context, query string) (string, []string, error)
 		MaxIterations: 20,
 	})
 	if err != nil {
-		return "", []string{}, fmt.Errorf("build PlanExecuteAgent Error: %v", err)
+		return nil, fmt.Errorf("build PlanExecuteAgent Error: %v", err)
 	}
+	return planExecuteAgent, nil
+}
+
+// RunPlanAgent 使用已构建的智能体处理查询，返回最终结果与各步骤的输出
+func RunPlanAgent(ctx context.Context, agent adk.Agent, query string) (string, []string, error) {
 	r := adk.NewRunner(ctx, adk.RunnerConfig{
-		Agent: planExecuteAgent,
+		Agent: agent,
 	})
 	iter := r.Query(ctx, query)
 	var lastMessage adk.Message
@@ -41,10 +57,17 @@ func BuildPlanAgent(ctx context.Context, query string) (string, []string, error)
 		if !ok {
 			break
 		}
-		fmt.Println("------------- Event -------------")
-		prints.Event(event)
+		if event.Err != nil {
+			return "", detail, event.Err
+		}
 		if event.Output != nil {
-			lastMessage, _, err = adk.GetMessage(event)
+			msg, _, err := adk.GetMessage(event)
+			if err != nil {
+				return "", detail, err
+			}
+			// 事件输出写入调试日志，不占用标准输出（stdio MCP 服务以标准输出传输协议消息）
+			g.Log().Debugf(ctx, "plan agent event: agent=%s path=%v message=%s", event.AgentName, event.RunPath, msg.String())
+			lastMessage = msg
 			detail = append(detail, lastMessage.String())
 		}
 	}
@@ -53,3 +76,51 @@ func BuildPlanAgent(ctx context.Context, query string) (string, []string, error)
 	}
 	return lastMessage.Content, detail, nil
 }
+
+// BuildPlanAgent 构建智能体并处理查询，用于命令行等一次性调用
+func BuildPlanAgent(ctx context.Context, query string) (string, []string, error) {
+	agent, err := NewPlanExecuteAgent(ctx)
+	if err != nil {
+		return "", []string{}, err
+	}
+	return RunPlanAgent(ctx, agent, query)
+}
+
+// AIOpsQuery 分析全部活跃告警并生成告警运维分析报告的查询，供 AI 运维接口与 MCP 工具使用
+var AIOpsQuery = `
+"1. 你是一个智能的服务告警分析助手,首先调用工具query_prometheus_alerts获取所有活跃的告警。"
+"2. 分别根据告警的名称调用工具query_internal_docs，获取告警名对应的处理方案。"
+"3. 完全遵循内部文档的内容进行查询和分析,不允许使用文档外的任何信息。"
+"4. 涉及到时间的参数都需要先通过工具get_current_time获取当前时间,再结合工具的时间要求进行传参。"
+"5. 涉及到日志的查询,需要先通过日志工具获取相关日志信息，参数必须携带地域和日志主题。"
+"6. 分别将告警对应查询到的信息进行总结分析,最后生成告警运维分析报告，格式如下：
+告警分析报告
+---
+# 告警处理详情
+## 活跃告警清单
+## 告警根因分析N(第N个告警)
+## 处理方案执行N(第N个告警)
+## 结论
+`
+
+// AlertInvestigationQuery 排查 Alertmanager 推送的一组告警并生成报告的查询，alerts 为告警清单，
+// 与 AIOpsQuery 的区别是只分析这组告警，供告警 webhook 自动排查使用
+func AlertInvestigationQuery(alerts string) string {
+	return fmt.Sprintf(`
+"1. 你是一个智能的服务告警分析助手，以下是 Alertmanager 推送的同一分组中正在触发的告警，只分析这些告警，不要分析清单之外的告警：
+%s"
+"2. 可以调用工具query_prometheus_metrics查询告警相关的指标，确认告警的影响范围与开始时间。"
+"3. 分别根据告警的名称调用工具query_internal_docs，获取告警名对应的处理方案。"
+"4. 完全遵循内部文档的内容进行查询和分析,不允许使用文档外的任何信息。"
+"5. 涉及到时间的参数都需要先通过工具get_current_time获取当前时间,再结合工具的时间要求进行传参。"
+"6. 涉及到日志的查询,需要先通过日志工具获取相关日志信息，参数必须携带地域和日志主题。"
+"7. 分别将告警对应查询到的信息进行总结分析,最后生成告警运维分析报告，格式如下：
+告警分析报告
+---
+# 告警处理详情
+## 活跃告警清单
+## 告警根因分析N(第N个告警)
+## 处理方案执行N(第N个告警)
+## 结论
+`, alerts)
+}
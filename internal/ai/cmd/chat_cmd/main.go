@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/NuyoahCh/eocall/internal/ai/agent/chat_pipeline"
+	"github.com/NuyoahCh/eocall/internal/logic/agents"
 	"github.com/NuyoahCh/eocall/utility/mem"
 	"github.com/cloudwego/eino/schema"
 )
@@ -16,10 +17,11 @@ func main() {
 		Query:   "你好",
 		History: mem.GetSimpleMemory(id).GetMessages(),
 	}
-	runner, err := chat_pipeline.BuildChatAgent(ctx)
+	runner, release, err := agents.New(ctx).Chat()
 	if err != nil {
 		panic(err)
 	}
+	defer release()
 	// 第一次对话
 	out, err := runner.Invoke(ctx, userMessage)
 	if err != nil {
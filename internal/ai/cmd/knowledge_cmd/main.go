@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/NuyoahCh/eocall/internal/ai/agent/knowledge_index_pipeline"
+	"github.com/NuyoahCh/eocall/internal/ai/indexer"
 	loader2 "github.com/NuyoahCh/eocall/internal/ai/loader"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
 	"github.com/NuyoahCh/eocall/utility/client"
-	"github.com/NuyoahCh/eocall/utility/common"
 	"github.com/NuyoahCh/eocall/utility/log_call_back"
 	"github.com/cloudwego/eino/components/document"
 	"github.com/cloudwego/eino/compose"
@@ -17,6 +18,8 @@ import (
 
 func main() {
 	ctx := context.Background()
+	defer client.CloseMilvusClient()
+	defer vectorstore.CloseStore()
 	r, err := knowledge_index_pipeline.BuildKnowledgeIndexing(ctx)
 	if err != nil {
 		panic(err)
@@ -44,38 +47,12 @@ func main() {
 		if err != nil {
 			return err
 		}
-		cli, err := client.NewMilvusClient(ctx)
+		// 删除所有metadata中_source一样的数据
+		deleted, err := indexer.DeleteBySource(ctx, docs[0].MetaData["_source"])
 		if err != nil {
-			return err
-		}
-		// 查询所有metadata中_source一样的数据并删除
-		expr := fmt.Sprintf(`metadata["_source"] == "%s"`, docs[0].MetaData["_source"])
-		queryResult, err := cli.Query(ctx, common.MilvusCollectionName, []string{}, expr, []string{"id"})
-		if err != nil {
-			return err
-		} else if len(queryResult) > 0 {
-			// 提取所有需要删除的id
-			var idsToDelete []string
-			for _, column := range queryResult {
-				if column.Name() == "id" {
-					for i := 0; i < column.Len(); i++ {
-						id, err := column.GetAsString(i)
-						if err == nil {
-							idsToDelete = append(idsToDelete, id)
-						}
-					}
-				}
-			}
-			// 删除这些数据
-			if len(idsToDelete) > 0 {
-				deleteExpr := fmt.Sprintf(`id in ["%s"]`, strings.Join(idsToDelete, `","`))
-				err = cli.Delete(ctx, common.MilvusCollectionName, "", deleteExpr)
-				if err != nil {
-					fmt.Printf("[warn] delete existing data failed: %v\n", err)
-				} else {
-					fmt.Printf("[info] deleted %d existing records with _source: %s\n", len(idsToDelete), docs[0].MetaData["_source"])
-				}
-			}
+			fmt.Printf("[warn] delete existing data failed: %v\n", err)
+		} else if deleted > 0 {
+			fmt.Printf("[info] deleted %d existing records with _source: %s\n", deleted, docs[0].MetaData["_source"])
 		}
 		// 重新构建
 		ids, err := r.Invoke(ctx, document.Source{URI: path}, compose.WithCallbacks(log_call_back.LogCallback(nil)))
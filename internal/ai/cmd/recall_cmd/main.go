@@ -8,7 +8,7 @@ import (
 
 func main() {
 	ctx := context.Background()
-	r, err := retriever2.NewMilvusRetriever(ctx)
+	r, err := retriever2.NewRetriever(ctx)
 	if err != nil {
 		panic(err)
 	}
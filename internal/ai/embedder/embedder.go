@@ -2,13 +2,23 @@ package embedder
 
 import (
 	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/utility/common"
 	"github.com/cloudwego/eino-ext/components/embedding/dashscope"
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/gogf/gf/v2/frame/g"
 	"log"
+	"sync"
 )
 
-// DoubaoEmbedding 引入豆包向量化模型
+// 按模型、密钥与维度复用，重新构建智能体时不重复创建，配置变更后替换
+var (
+	mu        sync.Mutex
+	sharedKey string
+	shared    embedding.Embedder
+)
+
+// DoubaoEmbedding 引入豆包向量化模型，配置不变时返回进程内共享的实例
 func DoubaoEmbedding(ctx context.Context) (eb embedding.Embedder, err error) {
 	model, err := g.Cfg().Get(ctx, "doubao_embedding_model.model")
 	if err != nil {
@@ -18,7 +28,13 @@ func DoubaoEmbedding(ctx context.Context) (eb embedding.Embedder, err error) {
 	if err != nil {
 		return nil, err
 	}
-	dim := 2048
+	dim := Dimensions(ctx)
+	key := fmt.Sprintf("%s|%s|%d", model.String(), api_key.String(), dim)
+	mu.Lock()
+	defer mu.Unlock()
+	if shared != nil && sharedKey == key {
+		return shared, nil
+	}
 	embedder, err := dashscope.NewEmbedder(ctx, &dashscope.EmbeddingConfig{
 		Model:      model.String(),
 		APIKey:     api_key.String(),
@@ -28,5 +44,20 @@ func DoubaoEmbedding(ctx context.Context) (eb embedding.Embedder, err error) {
 		log.Printf("new embedder error: %v\n", err)
 		return nil, err
 	}
+	sharedKey, shared = key, embedder
 	return embedder, nil
 }
+
+// ModelName 获取配置的向量化模型名称
+func ModelName(ctx context.Context) string {
+	return g.Cfg().MustGet(ctx, "doubao_embedding_model.model").String()
+}
+
+// Dimensions 获取配置的向量维度，未配置时使用默认维度
+func Dimensions(ctx context.Context) int {
+	dim := g.Cfg().MustGet(ctx, "doubao_embedding_model.dimensions").Int()
+	if dim <= 0 {
+		dim = common.EmbeddingDim
+	}
+	return dim
+}
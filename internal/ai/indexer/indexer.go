@@ -2,61 +2,87 @@ package indexer
 
 import (
 	"context"
+	"fmt"
 	embedder2 "github.com/NuyoahCh/eocall/internal/ai/embedder"
-	"github.com/NuyoahCh/eocall/utility/client"
-	"github.com/NuyoahCh/eocall/utility/common"
-	"github.com/cloudwego/eino-ext/components/indexer/milvus"
-	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/NuyoahCh/eocall/internal/ai/lexical"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/schema"
 )
 
-// NewMilvusIndexer 初始化数据库索引组件
-func NewMilvusIndexer(ctx context.Context) (*milvus.Indexer, error) {
-	cli, err := client.NewMilvusClient(ctx)
+// Indexer 将文档向量化后写入向量存储，写入的知识库由调用上下文决定
+type Indexer struct {
+	embedding embedding.Embedder
+}
+
+// NewIndexer 初始化索引组件，向量存储后端由配置 vector_store.type 决定
+func NewIndexer(ctx context.Context) (*Indexer, error) {
+	eb, err := embedder2.DoubaoEmbedding(ctx)
 	if err != nil {
 		return nil, err
 	}
-	eb, err := embedder2.DoubaoEmbedding(ctx)
+	return &Indexer{embedding: eb}, nil
+}
+
+// Store 向量化文档内容并写入向量存储
+func (i *Indexer) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) ([]string, error) {
+	co := indexer.GetCommonOptions(&indexer.Options{Embedding: i.embedding}, opts...)
+	if len(docs) == 0 {
+		return []string{}, nil
+	}
+	texts := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		texts = append(texts, doc.Content)
+	}
+	vectors, err := co.Embedding.EmbedStrings(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("embed documents failed: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return nil, fmt.Errorf("embedding result length not match, need: %d, got: %d", len(docs), len(vectors))
+	}
+	store, err := vectorstore.GetContextStore(ctx)
 	if err != nil {
 		return nil, err
 	}
-	config := &milvus.IndexerConfig{
-		Client:     cli,
-		Collection: common.MilvusCollectionName,
-		Fields:     fields,
-		Embedding:  eb,
+	ids, err := store.Insert(ctx, docs, vectors)
+	if err != nil {
+		return nil, err
 	}
-	indexer, err := milvus.NewIndexer(ctx, config)
+	// 同步写入词法索引，供混合检索使用
+	idx, err := lexical.GetContextIndex(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return indexer, nil
+	if err = idx.Add(ctx, docs); err != nil {
+		return nil, fmt.Errorf("add documents to lexical index failed: %w", err)
+	}
+	return ids, nil
+}
+
+// DeleteBySource 从上下文中知识库的向量存储与词法索引删除同一来源文件的文档，返回删除的数量
+func DeleteBySource(ctx context.Context, source any) (int, error) {
+	filter := vectorstore.Filter{"_source": source}
+	store, err := vectorstore.GetContextStore(ctx)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := store.DeleteByFilter(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	idx, err := lexical.GetContextIndex(ctx)
+	if err != nil {
+		return deleted, err
+	}
+	if _, err = idx.DeleteByFilter(ctx, filter); err != nil {
+		return deleted, fmt.Errorf("delete documents from lexical index failed: %w", err)
+	}
+	return deleted, nil
 }
 
-var fields = []*entity.Field{
-	{
-		Name:     "id",
-		DataType: entity.FieldTypeVarChar,
-		TypeParams: map[string]string{
-			"max_length": "255",
-		},
-		PrimaryKey: true,
-	},
-	{
-		Name:     "vector", // 确保字段名匹配
-		DataType: entity.FieldTypeBinaryVector,
-		TypeParams: map[string]string{
-			"dim": "65536",
-		},
-	},
-	{
-		Name:     "content",
-		DataType: entity.FieldTypeVarChar,
-		TypeParams: map[string]string{
-			"max_length": "8192",
-		},
-	},
-	{
-		Name:     "metadata",
-		DataType: entity.FieldTypeJSON,
-	},
+// GetType 组件类型，用于回调中展示
+func (i *Indexer) GetType() string {
+	return "VectorStore"
 }
@@ -0,0 +1,324 @@
+package lexical
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/cloudwego/eino/schema"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// lexicalDoc 词法索引中的单个文档
+type lexicalDoc struct {
+	ID       string         `json:"id"`
+	Content  string         `json:"content"`
+	MetaData map[string]any `json:"metadata"`
+
+	length int // 词项总数
+}
+
+// Index 基于 BM25 的词法索引，用于精确匹配错误码、告警名、地域 ID 等向量检索容易遗漏的词
+// 数据保存在内存中，配置路径后每次写入都会落盘；索引只在本进程内维护，不与其它实例同步
+type Index struct {
+	mu       sync.RWMutex
+	path     string
+	docs     map[string]*lexicalDoc
+	postings map[string]map[string]int // 词项到文档 ID 及词频
+	totalLen int
+}
+
+// NewIndex 创建词法索引，path 为空时仅保存在内存中，否则从该文件加载已有数据
+func NewIndex(path string) (*Index, error) {
+	idx := &Index{
+		path:     path,
+		docs:     map[string]*lexicalDoc{},
+		postings: map[string]map[string]int{},
+	}
+	if path == "" {
+		return idx, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lexical index %s: %w", path, err)
+	}
+	var docs []*lexicalDoc
+	if err = json.Unmarshal(data, &docs); err != nil {
+		return nil, fmt.Errorf("failed to parse lexical index %s: %w", path, err)
+	}
+	for _, d := range docs {
+		idx.add(d)
+	}
+	return idx, nil
+}
+
+// Add 将文档加入索引，ID 已存在时覆盖，文档 ID 不能为空
+func (idx *Index) Add(ctx context.Context, docs []*schema.Document) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, doc := range docs {
+		if doc.ID == "" {
+			return fmt.Errorf("lexical index requires document id")
+		}
+		idx.remove(doc.ID)
+		idx.add(&lexicalDoc{ID: doc.ID, Content: doc.Content, MetaData: doc.MetaData})
+	}
+	return idx.persist()
+}
+
+// Reset 以 docs 替换索引中的全部文档，文档 ID 不能为空
+func (idx *Index) Reset(ctx context.Context, docs []*schema.Document) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.docs = map[string]*lexicalDoc{}
+	idx.postings = map[string]map[string]int{}
+	idx.totalLen = 0
+	for _, doc := range docs {
+		if doc.ID == "" {
+			return fmt.Errorf("lexical index requires document id")
+		}
+		idx.add(&lexicalDoc{ID: doc.ID, Content: doc.Content, MetaData: doc.MetaData})
+	}
+	return idx.persist()
+}
+
+// contains 索引中的文档与 docs 的 ID 及内容是否完全一致
+func (idx *Index) contains(docs []*schema.Document) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if len(idx.docs) != len(docs) {
+		return false
+	}
+	for _, doc := range docs {
+		d, ok := idx.docs[doc.ID]
+		if !ok || d.Content != doc.Content {
+			return false
+		}
+	}
+	return true
+}
+
+// DeleteByFilter 删除元数据满足过滤条件的文档，返回删除的数量
+func (idx *Index) DeleteByFilter(ctx context.Context, filter vectorstore.Filter) (int, error) {
+	if len(filter) == 0 {
+		return 0, fmt.Errorf("delete filter must not be empty")
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	deleted := 0
+	for id, d := range idx.docs {
+		if filter.Match(d.MetaData) {
+			idx.remove(id)
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+	return deleted, idx.persist()
+}
+
+// Len 索引中的文档数量
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Search 按 BM25 分值检索文档，只返回至少命中一个词项的文档
+func (idx *Index) Search(ctx context.Context, query string, topK int) []*schema.Document {
+	if topK <= 0 {
+		topK = 1
+	}
+	terms := unique(Tokenize(query))
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if len(idx.docs) == 0 || len(terms) == 0 {
+		return []*schema.Document{}
+	}
+	n := float64(len(idx.docs))
+	avgLen := float64(idx.totalLen) / n
+	scores := map[string]float64{}
+	for _, term := range terms {
+		posting := idx.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		df := float64(len(posting))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range posting {
+			f := float64(tf)
+			norm := f + bm25K1*(1-bm25B+bm25B*float64(idx.docs[id].length)/avgLen)
+			scores[id] += idf * f * (bm25K1 + 1) / norm
+		}
+	}
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > topK {
+		ids = ids[:topK]
+	}
+	docs := make([]*schema.Document, 0, len(ids))
+	for _, id := range ids {
+		d := idx.docs[id]
+		docs = append(docs, (&schema.Document{
+			ID:       d.ID,
+			Content:  d.Content,
+			MetaData: vectorstore.CopyMetadata(d.MetaData),
+		}).WithScore(scores[id]))
+	}
+	return docs
+}
+
+// add 写入文档并更新词项统计，调用方需持有写锁
+func (idx *Index) add(d *lexicalDoc) {
+	tokens := Tokenize(d.Content)
+	d.length = len(tokens)
+	idx.docs[d.ID] = d
+	idx.totalLen += d.length
+	for _, t := range tokens {
+		posting, ok := idx.postings[t]
+		if !ok {
+			posting = map[string]int{}
+			idx.postings[t] = posting
+		}
+		posting[d.ID]++
+	}
+}
+
+// remove 删除文档并更新词项统计，调用方需持有写锁
+func (idx *Index) remove(id string) {
+	d, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, t := range unique(Tokenize(d.Content)) {
+		delete(idx.postings[t], id)
+		if len(idx.postings[t]) == 0 {
+			delete(idx.postings, t)
+		}
+	}
+	idx.totalLen -= d.length
+	delete(idx.docs, id)
+}
+
+// persist 将数据原子地写入文件，调用方需持有写锁
+func (idx *Index) persist() error {
+	if idx.path == "" {
+		return nil
+	}
+	docs := make([]*lexicalDoc, 0, len(idx.docs))
+	for _, d := range idx.docs {
+		docs = append(docs, d)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].ID < docs[j].ID
+	})
+	data, err := json.Marshal(docs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lexical index: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(idx.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create lexical index dir: %w", err)
+	}
+	tmp := idx.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write lexical index: %w", err)
+	}
+	return os.Rename(tmp, idx.path)
+}
+
+// Tokenize 切分词项：字母数字串整体作为一个词项并转为小写，便于精确匹配错误码与告警名；
+// 中文按单字与相邻双字切分，其余字符作为分隔符
+func Tokenize(text string) []string {
+	var (
+		tokens []string
+		word   strings.Builder
+		prev   rune // 上一个汉字，用于生成双字词项
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			tokens = append(tokens, string(r))
+			if prev != 0 {
+				tokens = append(tokens, string([]rune{prev, r}))
+			}
+			prev = r
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+		prev = 0
+	}
+	flush()
+	return tokens
+}
+
+// Significant 判断词项能否单独作为关键词命中的依据：单个汉字（如“的”“怎”）几乎出现在每篇文档中，
+// 只有相邻双字词项与至少两个字符的字母数字串才算
+func Significant(term string) bool {
+	return utf8.RuneCountInString(term) >= 2
+}
+
+// MatchedTerms 返回 query 中同时出现在 text 里的有效词项，按在 query 中首次出现的顺序排列
+func MatchedTerms(query, text string) []string {
+	inText := map[string]struct{}{}
+	for _, t := range Tokenize(text) {
+		if Significant(t) {
+			inText[t] = struct{}{}
+		}
+	}
+	var matched []string
+	for _, t := range unique(Tokenize(query)) {
+		if _, ok := inText[t]; ok {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+func unique(tokens []string) []string {
+	seen := make(map[string]struct{}, len(tokens))
+	out := tokens[:0:0]
+	for _, t := range tokens {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+package lexical
+
+import (
+	"context"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/cloudwego/eino/schema"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"ascii words lowercased", "ERR_5003 on Host-01", []string{"err_5003", "on", "host", "01"}},
+		{"han unigrams and bigrams", "磁盘满", []string{"磁", "盘", "磁盘", "满", "盘满"}},
+		{"mixed", "cn-hangzhou 磁盘", []string{"cn", "hangzhou", "磁", "盘", "磁盘"}},
+		{"bigram breaks at non han", "磁a盘", []string{"磁", "a", "盘"}},
+		{"punctuation separates", "告警，处理", []string{"告", "警", "告警", "处", "理", "处理"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Tokenize(c.text); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", c.text, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMatchedTerms(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		text  string
+		want  []string
+	}{
+		{"single han ignored", "怎么处理的", "服务的日志", nil},
+		{"bigram", "磁盘满了", "磁盘告警处理手册", []string{"磁盘"}},
+		{"error code case insensitive", "遇到 err_5003 怎么办", "错误码 ERR_5003", []string{"err_5003"}},
+		{"single letter ignored", "a 告警", "a 磁盘", nil},
+		{"query order kept once", "告警 cpu 告警", "CPU 告警", []string{"告警", "cpu"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := MatchedTerms(c.query, c.text); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("MatchedTerms() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx, err := NewIndex("")
+	if err != nil {
+		t.Fatalf("NewIndex() error = %v", err)
+	}
+	err = idx.Add(context.Background(), []*schema.Document{
+		{ID: "a", Content: "错误码 ERR_5003 表示连接池耗尽", MetaData: map[string]any{"_source": "db.md"}},
+		{ID: "b", Content: "磁盘告警处理：清理日志目录", MetaData: map[string]any{"_source": "disk.md"}},
+		{ID: "c", Content: "磁盘告警 磁盘告警 磁盘使用率超过阈值", MetaData: map[string]any{"_source": "disk.md"}},
+		{ID: "d", Content: "CPU 使用率告警", MetaData: map[string]any{"_source": "cpu.md"}},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	cases := []struct {
+		name    string
+		query   string
+		topK    int
+		wantIDs []string
+	}{
+		{"exact error code", "err_5003", 5, []string{"a"}},
+		{"term frequency ranks first", "磁盘告警", 2, []string{"c", "b"}},
+		{"top k", "磁盘告警", 1, []string{"c"}},
+		{"shorter document ranks first", "告警", 1, []string{"d"}},
+		{"no hit", "kafka", 5, nil},
+		{"empty query", "，。", 5, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			docs := idx.Search(context.Background(), c.query, c.topK)
+			var ids []string
+			for _, d := range docs {
+				if d.Score() <= 0 {
+					t.Errorf("doc %s score = %v, want > 0", d.ID, d.Score())
+				}
+				ids = append(ids, d.ID)
+			}
+			if !reflect.DeepEqual(ids, c.wantIDs) {
+				t.Errorf("Search(%q) = %v, want %v", c.query, ids, c.wantIDs)
+			}
+		})
+	}
+}
+
+func TestIndexDeleteByFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lexical.json")
+	idx, err := NewIndex(path)
+	if err != nil {
+		t.Fatalf("NewIndex() error = %v", err)
+	}
+	ctx := context.Background()
+	err = idx.Add(ctx, []*schema.Document{
+		{ID: "a", Content: "磁盘告警", MetaData: map[string]any{"_source": "disk.md"}},
+		{ID: "b", Content: "磁盘清理", MetaData: map[string]any{"_source": "disk.md"}},
+		{ID: "c", Content: "CPU 告警", MetaData: map[string]any{"_source": "cpu.md"}},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err = idx.DeleteByFilter(ctx, nil); err == nil {
+		t.Error("DeleteByFilter() with empty filter should fail")
+	}
+	deleted, err := idx.DeleteByFilter(ctx, vectorstore.Filter{"_source": "disk.md"})
+	if err != nil || deleted != 2 {
+		t.Fatalf("DeleteByFilter() = %d, %v, want 2", deleted, err)
+	}
+	reloaded, err := NewIndex(path)
+	if err != nil {
+		t.Fatalf("NewIndex() error = %v", err)
+	}
+	for _, i := range []*Index{idx, reloaded} {
+		if i.Len() != 1 {
+			t.Errorf("Len() = %d, want 1", i.Len())
+		}
+		if docs := i.Search(ctx, "磁盘", 5); len(docs) != 0 {
+			t.Errorf("deleted docs still searchable: %v", docs)
+		}
+		if docs := i.Search(ctx, "告警", 5); len(docs) != 1 || docs[0].ID != "c" {
+			t.Errorf("Search(告警) = %v, want [c]", docs)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package lexical
+
+import (
+	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/gogf/gf/v2/frame/g"
+	"log"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultDir 词法索引文件的默认目录，每个知识库对应一个文件
+const DefaultDir = "./data/lexical"
+
+var (
+	mu      sync.Mutex
+	indexes = map[string]*Index{}
+)
+
+// GetIndex 获取知识库对应的进程级共享词法索引
+// 首次获取时与向量存储中的文档比对，索引文件不存在或文档不一致时从向量存储重建，
+// 保证升级前写入的知识库以及其它实例在本进程启动前导入的文档也能参与关键词检索；
+// 启动后其它实例导入的文档不会同步到本进程的索引，多个实例共用 Milvus 时需重启后才能检索到
+func GetIndex(ctx context.Context, kb string) (*Index, error) {
+	if kb == "" {
+		kb = vectorstore.DefaultKnowledgeBase
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if idx, ok := indexes[kb]; ok {
+		return idx, nil
+	}
+	path := filepath.Join(g.Cfg().MustGet(ctx, "lexical_index.dir", DefaultDir).String(), kb+".json")
+	idx, err := NewIndex(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = reconcile(ctx, kb, idx); err != nil {
+		log.Printf("[warn] reconcile lexical index of knowledge base %s with vector store failed: %v", kb, err)
+	}
+	indexes[kb] = idx
+	return idx, nil
+}
+
+// GetContextIndex 获取上下文中知识库对应的词法索引
+func GetContextIndex(ctx context.Context) (*Index, error) {
+	return GetIndex(ctx, vectorstore.KnowledgeBaseFromContext(ctx))
+}
+
+// reconcile 索引与向量存储中的文档不一致时，使用向量存储中的全部文档重建词法索引
+func reconcile(ctx context.Context, kb string, idx *Index) error {
+	store, err := vectorstore.GetStore(ctx, kb)
+	if err != nil {
+		return err
+	}
+	docs, err := store.QueryByMetadata(ctx, nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed to load documents from vector store: %w", err)
+	}
+	if idx.contains(docs) {
+		return nil
+	}
+	log.Printf("[info] lexical index of knowledge base %s differs from vector store, rebuild with %d documents", kb, len(docs))
+	return idx.Reset(ctx, docs)
+}
@@ -0,0 +1,73 @@
+package lexical
+
+import (
+	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"path/filepath"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	dir := t.TempDir()
+	adapter, err := gcfg.NewAdapterContent(fmt.Sprintf("vector_store:\n  type: local\n  local:\n    path: %s\n", filepath.Join(dir, "vectors.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Cfg().SetAdapter(adapter)
+	ctx := context.Background()
+	store, err := vectorstore.GetStore(ctx, "lexical-reconcile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Insert(ctx, []*schema.Document{
+		{ID: "a", Content: "磁盘告警"},
+		{ID: "b", Content: "CPU 告警"},
+	}, [][]float64{{1, 0}, {0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		index []*schema.Document
+	}{
+		{"missing index file", nil},
+		{"written by another instance", []*schema.Document{{ID: "a", Content: "磁盘告警"}}},
+		{"stale content", []*schema.Document{{ID: "a", Content: "磁盘清理"}, {ID: "b", Content: "CPU 告警"}}},
+		{"extra document", []*schema.Document{{ID: "a", Content: "磁盘告警"}, {ID: "b", Content: "CPU 告警"}, {ID: "c", Content: "网络抖动"}}},
+		{"in sync", []*schema.Document{{ID: "a", Content: "磁盘告警"}, {ID: "b", Content: "CPU 告警"}}},
+	}
+	for i, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("lexical-%d.json", i))
+			idx, err := NewIndex(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.index != nil {
+				if err = idx.Add(ctx, c.index); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err = reconcile(ctx, "lexical-reconcile", idx); err != nil {
+				t.Fatalf("reconcile() error = %v", err)
+			}
+			reloaded, err := NewIndex(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, i := range []*Index{idx, reloaded} {
+				if i.Len() != 2 {
+					t.Errorf("Len() = %d, want 2", i.Len())
+				}
+				if docs := i.Search(ctx, "磁盘告警", 1); len(docs) != 1 || docs[0].ID != "a" {
+					t.Errorf("Search() = %v, want document a", docs)
+				}
+			}
+		})
+	}
+}
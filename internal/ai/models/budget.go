@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"unicode/utf8"
+)
+
+// 模型配置节点名称，同时作为提示词预算的配置前缀
+const (
+	ProfileDeepSeekV31Think = "ds_think_chat_model"
+	ProfileDeepSeekV3Quick  = "ds_quick_chat_model"
+)
+
+// 提示词预算的默认配置
+const (
+	DefaultContextWindow = 65536
+	DefaultReserveOutput = 4096
+	DefaultReserveTools  = 8192
+)
+
+// messageOverhead 每条消息的角色、分隔符等额外 token
+const messageOverhead = 4
+
+// Budget 模型的上下文窗口与预留空间，对应模型配置节点下的 context_window、reserve_output、reserve_tools
+type Budget struct {
+	Profile       string // 模型配置节点名称
+	ContextWindow int    // 模型上下文窗口大小
+	ReserveOutput int    // 为模型输出预留的 token
+	ReserveTools  int    // 为工具定义、工具调用及其返回结果预留的 token
+}
+
+// GetBudget 读取模型配置节点下的提示词预算，未配置的项使用默认值
+func GetBudget(ctx context.Context, profile string) *Budget {
+	cfg := g.Cfg()
+	b := &Budget{
+		Profile:       profile,
+		ContextWindow: cfg.MustGet(ctx, profile+".context_window", DefaultContextWindow).Int(),
+		ReserveOutput: cfg.MustGet(ctx, profile+".reserve_output", DefaultReserveOutput).Int(),
+		ReserveTools:  cfg.MustGet(ctx, profile+".reserve_tools", DefaultReserveTools).Int(),
+	}
+	if b.ContextWindow <= 0 {
+		b.ContextWindow = DefaultContextWindow
+	}
+	if b.ReserveOutput < 0 {
+		b.ReserveOutput = DefaultReserveOutput
+	}
+	if b.ReserveTools < 0 {
+		b.ReserveTools = DefaultReserveTools
+	}
+	return b
+}
+
+// Reserved 预留给输出与工具调用的 token
+func (b *Budget) Reserved() int {
+	return b.ReserveOutput + b.ReserveTools
+}
+
+// Prompt 可用于提示词的 token
+func (b *Budget) Prompt() int {
+	return b.ContextWindow - b.Reserved()
+}
+
+// CountTokens 估算文本的 token 数：非 ASCII 字符（主要是中文）按每字 1 个计算，ASCII 字符按每 4 个 1 个计算
+// 估算值略高于 DeepSeek 等模型分词器的实际结果，用于预算控制时偏保守
+func CountTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return other + (ascii+3)/4
+}
+
+// CountMessageTokens 估算消息的 token 数，包括工具调用参数
+func CountMessageTokens(msg *schema.Message) int {
+	if msg == nil {
+		return 0
+	}
+	n := messageOverhead + CountTokens(msg.Content) + CountTokens(msg.ReasoningContent)
+	for _, tc := range msg.ToolCalls {
+		n += CountTokens(tc.Function.Name) + CountTokens(tc.Function.Arguments)
+	}
+	return n
+}
+
+// TruncateTokens 截断文本使其估算 token 数不超过 limit
+func TruncateTokens(text string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if CountTokens(text) <= limit {
+		return text
+	}
+	ascii, other := 0, 0
+	for i, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+		if other+(ascii+3)/4 > limit {
+			return text[:i]
+		}
+	}
+	return text
+}
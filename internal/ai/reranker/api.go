@@ -0,0 +1,111 @@
+package reranker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cloudwego/eino/schema"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// APIReranker 调用 OpenAI 兼容的 /rerank 接口（Jina、Cohere、vLLM、Xinference 等均支持该格式）
+// 调用失败时退化为词项重叠度重排，保证对话不因重排服务不可用而中断
+type APIReranker struct {
+	config   *Config
+	client   *http.Client
+	fallback Reranker
+}
+
+// NewAPIReranker 创建调用重排服务的重排组件
+func NewAPIReranker(c *Config) *APIReranker {
+	return &APIReranker{
+		config:   c,
+		client:   &http.Client{Timeout: c.Timeout},
+		fallback: NewLexicalReranker(),
+	}
+}
+
+type rerankRequest struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopN            int      `json:"top_n,omitempty"`
+	ReturnDocuments bool     `json:"return_documents"`
+}
+
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// Rerank 按重排服务返回的相关性排序
+func (r *APIReranker) Rerank(ctx context.Context, query string, docs []*schema.Document, topN int) ([]*schema.Document, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+	out, err := r.rerank(ctx, query, docs, topN)
+	if err != nil {
+		log.Printf("[warn] rerank service failed, fallback to lexical rerank: %v", err)
+		return r.fallback.Rerank(ctx, query, docs, topN)
+	}
+	return out, nil
+}
+
+func (r *APIReranker) rerank(ctx context.Context, query string, docs []*schema.Document, topN int) ([]*schema.Document, error) {
+	texts := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		texts = append(texts, doc.Content)
+	}
+	body, err := json.Marshal(&rerankRequest{
+		Model:     r.config.Model,
+		Query:     query,
+		Documents: texts,
+		TopN:      topN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rerank request: %w", err)
+	}
+	url := strings.TrimSuffix(r.config.BaseURL, "/") + "/rerank"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.config.APIKey)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call rerank service: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rerank response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank service returned %d: %s", resp.StatusCode, data)
+	}
+	var result rerankResponse
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse rerank response: %w", err)
+	}
+	out := make([]*schema.Document, 0, len(result.Results))
+	for _, item := range result.Results {
+		if item.Index < 0 || item.Index >= len(docs) {
+			return nil, fmt.Errorf("rerank result index out of range: %d", item.Index)
+		}
+		out = append(out, withRelevance(docs[item.Index], item.RelevanceScore))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Score() > out[j].Score()
+	})
+	return limit(out, topN), nil
+}
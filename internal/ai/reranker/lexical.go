@@ -0,0 +1,42 @@
+package reranker
+
+import (
+	"context"
+	"github.com/NuyoahCh/eocall/internal/ai/lexical"
+	"github.com/cloudwego/eino/schema"
+	"sort"
+)
+
+// LexicalReranker 按查询词项在文档中的覆盖率重排，未配置重排服务时使用
+type LexicalReranker struct{}
+
+// NewLexicalReranker 创建词项重叠度重排组件
+func NewLexicalReranker() *LexicalReranker {
+	return &LexicalReranker{}
+}
+
+// Rerank 相关性为文档覆盖的查询词项占比，分值相同时保持召回顺序
+func (r *LexicalReranker) Rerank(ctx context.Context, query string, docs []*schema.Document, topN int) ([]*schema.Document, error) {
+	terms := map[string]struct{}{}
+	for _, t := range lexical.Tokenize(query) {
+		terms[t] = struct{}{}
+	}
+	out := make([]*schema.Document, 0, len(docs))
+	for _, doc := range docs {
+		var relevance float64
+		if len(terms) > 0 {
+			hit := map[string]struct{}{}
+			for _, t := range lexical.Tokenize(doc.Content) {
+				if _, ok := terms[t]; ok {
+					hit[t] = struct{}{}
+				}
+			}
+			relevance = float64(len(hit)) / float64(len(terms))
+		}
+		out = append(out, withRelevance(doc, relevance))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Score() > out[j].Score()
+	})
+	return limit(out, topN), nil
+}
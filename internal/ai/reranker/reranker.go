@@ -0,0 +1,79 @@
+package reranker
+
+import (
+	"context"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"time"
+)
+
+// MetaRetrievalScore 重排后文档的 Score 为相关性分值，召回阶段的分值保存在该元数据字段中
+const MetaRetrievalScore = "_retrieval_score"
+
+// 重排参数默认值
+const (
+	DefaultCandidates = 20
+	DefaultTimeout    = "10s"
+)
+
+// Reranker 对召回的候选文档按与查询的相关性重新排序
+type Reranker interface {
+	// Rerank 返回相关性最高的 topN 个文档，文档 Score 为相关性分值
+	Rerank(ctx context.Context, query string, docs []*schema.Document, topN int) ([]*schema.Document, error)
+}
+
+// Config 重排配置，对应配置文件中的 rerank 节点
+type Config struct {
+	Enabled    bool          // 是否在检索与提示词之间插入重排节点
+	Model      string        // 重排模型名称
+	APIKey     string        // 重排服务 API Key
+	BaseURL    string        // OpenAI 兼容的重排服务地址，为空时使用词项重叠度重排
+	TopN       int           // 重排后注入提示词的文档数量，0 表示沿用 retrieval.top_k
+	Candidates int           // 重排前召回的候选文档数量
+	Timeout    time.Duration // 调用重排服务的超时时间
+}
+
+// GetConfig 从配置文件读取重排配置，未配置的项使用默认值
+func GetConfig(ctx context.Context) *Config {
+	cfg := g.Cfg()
+	c := &Config{
+		Enabled:    cfg.MustGet(ctx, "rerank.enabled").Bool(),
+		Model:      cfg.MustGet(ctx, "rerank.model").String(),
+		APIKey:     cfg.MustGet(ctx, "rerank.api_key").String(),
+		BaseURL:    cfg.MustGet(ctx, "rerank.base_url").String(),
+		TopN:       cfg.MustGet(ctx, "rerank.top_n").Int(),
+		Candidates: cfg.MustGet(ctx, "rerank.candidates", DefaultCandidates).Int(),
+		Timeout:    cfg.MustGet(ctx, "rerank.timeout", DefaultTimeout).Duration(),
+	}
+	if c.Candidates <= 0 {
+		c.Candidates = DefaultCandidates
+	}
+	return c
+}
+
+// NewReranker 根据配置创建重排组件，配置了 rerank.base_url 时调用重排服务，否则使用词项重叠度重排
+func NewReranker(ctx context.Context) Reranker {
+	c := GetConfig(ctx)
+	if c.BaseURL == "" {
+		return NewLexicalReranker()
+	}
+	return NewAPIReranker(c)
+}
+
+// limit 按 topN 截断，topN 不大于 0 时不截断
+func limit(docs []*schema.Document, topN int) []*schema.Document {
+	if topN > 0 && len(docs) > topN {
+		return docs[:topN]
+	}
+	return docs
+}
+
+// withRelevance 复制文档并将相关性写入 Score，原分值保存到元数据中
+func withRelevance(doc *schema.Document, relevance float64) *schema.Document {
+	d := &schema.Document{ID: doc.ID, Content: doc.Content, MetaData: make(map[string]any, len(doc.MetaData)+1)}
+	for k, v := range doc.MetaData {
+		d.MetaData[k] = v
+	}
+	d.MetaData[MetaRetrievalScore] = doc.Score()
+	return d.WithScore(relevance)
+}
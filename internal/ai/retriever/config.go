@@ -0,0 +1,89 @@
+package retriever
+
+import (
+	"context"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 检索模式
+const (
+	ModeHybrid = "hybrid" // 向量检索与 BM25 关键词检索按 RRF 融合
+	ModeVector = "vector" // 仅向量检索
+)
+
+// 检索参数默认值
+const (
+	DefaultTopK                = 3
+	DefaultSearchLevel         = 1
+	DefaultRRFK                = 60
+	DefaultCandidateMultiplier = 4
+	MaxTopK                    = 50
+)
+
+// Config 检索配置，对应配置文件中的 retrieval 节点
+type Config struct {
+	TopK           int     // 返回的文档数量
+	ScoreThreshold float64 // 最低向量相似度，低于该值的文档会被丢弃，混合模式下包括只由关键词召回的文档，0 表示不过滤
+	SearchLevel    int     // Milvus AUTOINDEX 检索精度 1~5，越大召回越准但越慢
+	Mode           string  // 检索模式 hybrid | vector
+
+	RRFK                  int     // RRF 融合常数，越大排名靠后的结果权重越高
+	CandidateMultiplier   int     // 混合检索时每一路召回 TopK 的倍数作为融合候选
+	LexicalScoreThreshold float64 // 最低 BM25 分值，低于该值的关键词召回结果在融合前丢弃，0 表示只要求命中有效词项
+}
+
+// GetConfig 从配置文件读取检索配置，未配置的项使用默认值
+func GetConfig(ctx context.Context) *Config {
+	cfg := g.Cfg()
+	c := &Config{
+		TopK:           cfg.MustGet(ctx, "retrieval.top_k", DefaultTopK).Int(),
+		ScoreThreshold: cfg.MustGet(ctx, "retrieval.score_threshold").Float64(),
+		SearchLevel:    cfg.MustGet(ctx, "retrieval.search_level", DefaultSearchLevel).Int(),
+		Mode:           cfg.MustGet(ctx, "retrieval.mode", ModeHybrid).String(),
+
+		RRFK:                  cfg.MustGet(ctx, "retrieval.rrf_k", DefaultRRFK).Int(),
+		CandidateMultiplier:   cfg.MustGet(ctx, "retrieval.candidate_multiplier", DefaultCandidateMultiplier).Int(),
+		LexicalScoreThreshold: cfg.MustGet(ctx, "retrieval.lexical_score_threshold").Float64(),
+	}
+	if c.TopK <= 0 {
+		c.TopK = DefaultTopK
+	}
+	if c.TopK > MaxTopK {
+		c.TopK = MaxTopK
+	}
+	if c.SearchLevel < 1 || c.SearchLevel > 5 {
+		c.SearchLevel = DefaultSearchLevel
+	}
+	if c.Mode != ModeVector {
+		c.Mode = ModeHybrid
+	}
+	if c.RRFK <= 0 {
+		c.RRFK = DefaultRRFK
+	}
+	if c.CandidateMultiplier <= 0 {
+		c.CandidateMultiplier = DefaultCandidateMultiplier
+	}
+	return c
+}
+
+// Overrides 单次请求对检索配置的覆盖，字段为空时沿用配置
+type Overrides struct {
+	TopK           int      // 大于 0 时生效，最大为 MaxTopK
+	ScoreThreshold *float64 // 不为空时生效
+}
+
+// Options 将单次请求的覆盖项转换为检索选项
+func (o *Overrides) Options() []retriever.Option {
+	if o == nil {
+		return nil
+	}
+	var opts []retriever.Option
+	if o.TopK > 0 {
+		opts = append(opts, retriever.WithTopK(min(o.TopK, MaxTopK)))
+	}
+	if o.ScoreThreshold != nil {
+		opts = append(opts, retriever.WithScoreThreshold(*o.ScoreThreshold))
+	}
+	return opts
+}
@@ -0,0 +1,136 @@
+package retriever
+
+import (
+	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"sort"
+	"strings"
+)
+
+// 切片与父章节关联的元数据字段
+const (
+	ParentHeaderKey = "title"         // 划分父章节的标题字段，对应一级标题
+	MetaParentID    = "_parent_id"    // 父章节 ID
+	MetaChunkIndex  = "_chunk_index"  // 切片在文件中的顺序
+	MetaExpanded    = "_expanded"     // 文档已扩展为父章节（或其中的连续片段）
+	MetaExpandedIDs = "_expanded_ids" // 扩展结果包含的切片 ID
+)
+
+// DefaultParentMaxChars 单个父章节扩展后的默认最大字符数
+const DefaultParentMaxChars = 3000
+
+// ParentConfig 父章节扩展配置，对应配置文件中的 parent_retrieval 节点
+type ParentConfig struct {
+	Enabled  bool // 是否将命中的切片扩展为父章节
+	MaxChars int  // 单个父章节扩展后的最大字符数，超出时只保留命中切片附近的连续切片
+}
+
+// GetParentConfig 从配置文件读取父章节扩展配置，未配置的项使用默认值
+func GetParentConfig(ctx context.Context) *ParentConfig {
+	cfg := g.Cfg()
+	c := &ParentConfig{
+		Enabled:  cfg.MustGet(ctx, "parent_retrieval.enabled", true).Bool(),
+		MaxChars: cfg.MustGet(ctx, "parent_retrieval.max_chars", DefaultParentMaxChars).Int(),
+	}
+	if c.MaxChars <= 0 {
+		c.MaxChars = DefaultParentMaxChars
+	}
+	return c
+}
+
+// ExpandParents 将命中的切片扩展为所属父章节，同一父章节的多个命中只保留排名最高的一个
+// 扩展结果沿用命中切片的 ID、分值与元数据；未记录父章节的切片保持不变
+func ExpandParents(ctx context.Context, docs []*schema.Document, c *ParentConfig) ([]*schema.Document, error) {
+	if c == nil || !c.Enabled || len(docs) == 0 {
+		return docs, nil
+	}
+	store, err := vectorstore.GetContextStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hits := map[string][]*schema.Document{}
+	order := make([]string, 0, len(docs))
+	out := make([]*schema.Document, 0, len(docs))
+	for _, doc := range docs {
+		pid, _ := doc.MetaData[MetaParentID].(string)
+		if pid == "" {
+			order = append(order, "")
+			out = append(out, doc)
+			continue
+		}
+		if _, ok := hits[pid]; !ok {
+			order = append(order, pid)
+			out = append(out, nil)
+		}
+		hits[pid] = append(hits[pid], doc)
+	}
+	for i, pid := range order {
+		if pid == "" {
+			continue
+		}
+		siblings, err := store.QueryByMetadata(ctx, vectorstore.Filter{MetaParentID: pid}, 0)
+		if err != nil {
+			return nil, fmt.Errorf("query parent section %s failed: %w", pid, err)
+		}
+		out[i] = expand(hits[pid], siblings, c.MaxChars)
+	}
+	return out, nil
+}
+
+// expand 从排名最高的命中切片开始，交替向前后相邻切片扩展，直到超出字符预算
+func expand(hits, siblings []*schema.Document, maxChars int) *schema.Document {
+	best := hits[0]
+	if len(siblings) == 0 {
+		return best
+	}
+	sort.SliceStable(siblings, func(i, j int) bool {
+		return chunkIndex(siblings[i]) < chunkIndex(siblings[j])
+	})
+	center := -1
+	for i, s := range siblings {
+		if s.ID == best.ID {
+			center = i
+			break
+		}
+	}
+	if center < 0 {
+		return best
+	}
+	lo, hi := center, center
+	size := len(siblings[center].Content)
+	for {
+		grown := false
+		if hi+1 < len(siblings) && size+len(siblings[hi+1].Content) <= maxChars {
+			hi++
+			size += len(siblings[hi].Content)
+			grown = true
+		}
+		if lo > 0 && size+len(siblings[lo-1].Content) <= maxChars {
+			lo--
+			size += len(siblings[lo].Content)
+			grown = true
+		}
+		if !grown {
+			break
+		}
+	}
+	parts := make([]string, 0, hi-lo+1)
+	ids := make([]string, 0, hi-lo+1)
+	for _, s := range siblings[lo : hi+1] {
+		parts = append(parts, strings.TrimSpace(s.Content))
+		ids = append(ids, s.ID)
+	}
+	d := &schema.Document{ID: best.ID, Content: strings.Join(parts, "\n\n"), MetaData: vectorstore.CopyMetadata(best.MetaData)}
+	d.MetaData[MetaExpanded] = true
+	d.MetaData[MetaExpandedIDs] = ids
+	return d.WithScore(best.Score())
+}
+
+// chunkIndex 读取切片顺序，兼容 JSON 反序列化得到的 float64
+func chunkIndex(doc *schema.Document) float64 {
+	v, _ := vectorstore.ToFloat(doc.MetaData[MetaChunkIndex])
+	return v
+}
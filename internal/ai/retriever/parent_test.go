@@ -0,0 +1,128 @@
+package retriever
+
+import (
+	"context"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"reflect"
+	"testing"
+)
+
+func chunk(id, parent string, index int, content string) *schema.Document {
+	return &schema.Document{ID: id, Content: content, MetaData: map[string]any{MetaParentID: parent, MetaChunkIndex: index}}
+}
+
+func TestExpand(t *testing.T) {
+	// 每个切片 10 个字符，按 JSON 反序列化的 float64 记录顺序且乱序给出
+	siblings := func() []*schema.Document {
+		docs := []*schema.Document{
+			chunk("c3", "p", 3, "cccccccc33"),
+			chunk("c0", "p", 0, "cccccccc00"),
+			chunk("c2", "p", 2, "cccccccc22"),
+			chunk("c1", "p", 1, "cccccccc11"),
+			chunk("c4", "p", 4, "cccccccc44"),
+		}
+		for _, d := range docs {
+			d.MetaData[MetaChunkIndex] = float64(d.MetaData[MetaChunkIndex].(int))
+		}
+		return docs
+	}
+	cases := []struct {
+		name     string
+		hits     []*schema.Document
+		siblings []*schema.Document
+		maxChars int
+		wantIDs  []string // nil 表示未扩展
+	}{
+		{"whole section fits", []*schema.Document{chunk("c2", "p", 2, "")}, siblings(), 100, []string{"c0", "c1", "c2", "c3", "c4"}},
+		{"grows around hit", []*schema.Document{chunk("c2", "p", 2, "")}, siblings(), 30, []string{"c1", "c2", "c3"}},
+		{"prefers following chunk", []*schema.Document{chunk("c2", "p", 2, "")}, siblings(), 20, []string{"c2", "c3"}},
+		{"first chunk grows forward", []*schema.Document{chunk("c0", "p", 0, "")}, siblings(), 30, []string{"c0", "c1", "c2"}},
+		{"hit alone over budget", []*schema.Document{chunk("c4", "p", 4, "")}, siblings(), 5, []string{"c4"}},
+		{"uses best hit", []*schema.Document{chunk("c4", "p", 4, ""), chunk("c0", "p", 0, "")}, siblings(), 20, []string{"c3", "c4"}},
+		{"no siblings", []*schema.Document{chunk("c2", "p", 2, "hit")}, nil, 100, nil},
+		{"hit missing from section", []*schema.Document{chunk("x", "p", 9, "hit")}, siblings(), 100, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			best := c.hits[0].WithScore(0.8)
+			got := expand(c.hits, c.siblings, c.maxChars)
+			if c.wantIDs == nil {
+				if got != best {
+					t.Errorf("expand() = %v, want the hit unchanged", got)
+				}
+				return
+			}
+			if got.ID != best.ID || got.Score() != 0.8 || got.MetaData[MetaExpanded] != true {
+				t.Errorf("expand() = %s score %v expanded %v, want %s score 0.8 expanded", got.ID, got.Score(), got.MetaData[MetaExpanded], best.ID)
+			}
+			if ids := got.MetaData[MetaExpandedIDs]; !reflect.DeepEqual(ids, c.wantIDs) {
+				t.Errorf("expanded ids = %v, want %v", ids, c.wantIDs)
+			}
+			if _, ok := best.MetaData[MetaExpanded]; ok {
+				t.Error("expand() modified the hit metadata")
+			}
+		})
+	}
+}
+
+func TestExpandParents(t *testing.T) {
+	adapter, err := gcfg.NewAdapterContent("vector_store:\n  type: local\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Cfg().SetAdapter(adapter)
+	ctx := vectorstore.WithKnowledgeBase(context.Background(), "expand_parents_test")
+	t.Cleanup(func() { _ = vectorstore.CloseStore() })
+	store, err := vectorstore.GetContextStore(ctx)
+	if err != nil {
+		t.Fatalf("GetContextStore() error = %v", err)
+	}
+	sections := []*schema.Document{
+		chunk("a0", "pa", 0, "section a part 0"),
+		chunk("a1", "pa", 1, "section a part 1"),
+		chunk("b0", "pb", 0, "section b part 0"),
+	}
+	if _, err = store.Insert(ctx, sections, [][]float64{{1}, {1}, {1}}); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	orphan := &schema.Document{ID: "o", Content: "no parent", MetaData: map[string]any{}}
+	cases := []struct {
+		name        string
+		docs        []*schema.Document
+		config      *ParentConfig
+		wantIDs     []string
+		wantContent []string
+	}{
+		{"disabled", []*schema.Document{chunk("a1", "pa", 1, "section a part 1")}, &ParentConfig{Enabled: false, MaxChars: 100}, []string{"a1"}, []string{"section a part 1"}},
+		{"expands to section", []*schema.Document{chunk("a1", "pa", 1, "section a part 1")}, &ParentConfig{Enabled: true, MaxChars: 100}, []string{"a1"}, []string{"section a part 0\n\nsection a part 1"}},
+		{
+			"dedupes section and keeps order",
+			[]*schema.Document{chunk("b0", "pb", 0, "section b part 0"), orphan, chunk("a0", "pa", 0, "section a part 0"), chunk("a1", "pa", 1, "section a part 1")},
+			&ParentConfig{Enabled: true, MaxChars: 100},
+			[]string{"b0", "o", "a0"},
+			[]string{"section b part 0", "no parent", "section a part 0\n\nsection a part 1"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			docs, err := ExpandParents(ctx, c.docs, c.config)
+			if err != nil {
+				t.Fatalf("ExpandParents() error = %v", err)
+			}
+			var ids, contents []string
+			for _, d := range docs {
+				ids = append(ids, d.ID)
+				contents = append(contents, d.Content)
+			}
+			if !reflect.DeepEqual(ids, c.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, c.wantIDs)
+			}
+			if !reflect.DeepEqual(contents, c.wantContent) {
+				t.Errorf("contents = %q, want %q", contents, c.wantContent)
+			}
+		})
+	}
+}
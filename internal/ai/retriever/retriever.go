@@ -2,37 +2,191 @@ package retriever
 
 import (
 	"context"
+	"fmt"
 	"github.com/NuyoahCh/eocall/internal/ai/embedder"
-	"github.com/NuyoahCh/eocall/utility/client"
-	"github.com/NuyoahCh/eocall/utility/common"
-	"github.com/cloudwego/eino-ext/components/retriever/milvus"
+	"github.com/NuyoahCh/eocall/internal/ai/lexical"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"sort"
 )
 
-// NewMilvusRetriever 引入 Retriever 组件进行查询召回
-func NewMilvusRetriever(ctx context.Context) (rtr retriever.Retriever, err error) {
-	cli, err := client.NewMilvusClient(ctx)
+// 检索写入文档元数据的分值字段，文档自身的 Score 始终为向量相似度，仅由关键词召回的文档为 0
+const (
+	MetaVectorScore  = "_vector_score"  // 向量相似度
+	MetaLexicalScore = "_lexical_score" // BM25 分值
+	MetaRRFScore     = "_rrf_score"     // RRF 融合分值，融合结果按其排序
+)
+
+// Retriever 基于向量存储与词法索引的召回组件，检索的知识库由调用上下文决定
+type Retriever struct {
+	embedding embedding.Embedder
+	config    *Config
+}
+
+// NewRetriever 引入 Retriever 组件进行查询召回，向量存储后端由配置 vector_store.type 决定，检索参数由配置 retrieval 决定
+func NewRetriever(ctx context.Context) (*Retriever, error) {
+	eb, err := embedder.DoubaoEmbedding(ctx)
 	if err != nil {
 		return nil, err
 	}
-	eb, err := embedder.DoubaoEmbedding(ctx)
+	return &Retriever{
+		embedding: eb,
+		config:    GetConfig(ctx),
+	}, nil
+}
+
+// Retrieve 检索与查询语句相关的文档，相似度低于阈值的向量召回结果会被丢弃
+// 混合模式下同时进行 BM25 关键词检索，关键词召回结果需命中有效词项且 BM25 分值不低于 lexical_score_threshold，
+// 两路结果各自过滤后再按 RRF 融合；融合后同样按相似度阈值过滤，阈值大于 0 时只由关键词召回、没有向量相似度的文档也会被丢弃
+// 可通过 retriever.WithTopK、retriever.WithScoreThreshold 覆盖配置
+func (r *Retriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	topK, threshold := r.config.TopK, r.config.ScoreThreshold
+	co := retriever.GetCommonOptions(&retriever.Options{
+		TopK:           &topK,
+		ScoreThreshold: &threshold,
+		Embedding:      r.embedding,
+	}, opts...)
+	topK, threshold = *co.TopK, *co.ScoreThreshold
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+	candidates := topK
+	if r.config.Mode == ModeHybrid {
+		candidates = topK * r.config.CandidateMultiplier
+	}
+
+	vectorDocs, err := r.vectorSearch(ctx, co.Embedding, query, candidates)
 	if err != nil {
 		return nil, err
 	}
-	r, err := milvus.NewRetriever(ctx, &milvus.RetrieverConfig{
-		Client:      cli,
-		Collection:  common.MilvusCollectionName,
-		VectorField: "vector",
-		OutputFields: []string{
-			"id",
-			"content",
-			"metadata",
-		},
-		TopK:      1,
-		Embedding: eb,
-	})
+	vectorDocs = filterByScore(vectorDocs, threshold)
+	if r.config.Mode != ModeHybrid {
+		for _, doc := range vectorDocs {
+			if doc.MetaData == nil {
+				doc.MetaData = map[string]any{}
+			}
+			doc.MetaData[MetaVectorScore] = doc.Score()
+		}
+		return truncate(vectorDocs, topK), nil
+	}
+
+	idx, err := lexical.GetContextIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lexicalDocs := filterLexical(idx.Search(ctx, query, candidates), query, r.config.LexicalScoreThreshold)
+	return truncate(filterByScore(fuse(r.config.RRFK, vectorDocs, lexicalDocs), threshold), topK), nil
+}
+
+// vectorSearch 向量化查询语句并检索相似文档
+func (r *Retriever) vectorSearch(ctx context.Context, eb embedding.Embedder, query string, topK int) ([]*schema.Document, error) {
+	store, err := vectorstore.GetContextStore(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return r, nil
+	vectors, err := eb.EmbedStrings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query failed: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("invalid return length of vector, got=%d, expected=1", len(vectors))
+	}
+	return store.Search(ctx, vectors[0], &vectorstore.SearchOptions{
+		TopK:  topK,
+		Level: r.config.SearchLevel,
+	})
+}
+
+// GetType 组件类型，用于回调中展示
+func (r *Retriever) GetType() string {
+	return "VectorStore"
+}
+
+// fuse 按 reciprocal rank fusion 融合向量与关键词两路召回结果，并在元数据中记录各路分值
+func fuse(k int, vectorDocs, lexicalDocs []*schema.Document) []*schema.Document {
+	return rrf(k, [][]*schema.Document{vectorDocs, lexicalDocs}, []string{MetaVectorScore, MetaLexicalScore})
+}
+
+// Fuse 按 reciprocal rank fusion 融合多路召回结果，用于多个子查询的结果合并，k 不大于 0 时使用 DefaultRRFK
+func Fuse(k int, lists ...[]*schema.Document) []*schema.Document {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+	return rrf(k, lists, nil)
+}
+
+// rrf 计算 score = Σ 1/(k + rank) 并写入 MetaRRFScore，metaKeys 不为空时将各路原始分值写入对应的元数据字段
+// 融合结果按 RRF 分值排序，Score 取各路结果中最高的向量相似度
+func rrf(k int, lists [][]*schema.Document, metaKeys []string) []*schema.Document {
+	fused := map[string]*schema.Document{}
+	scores := map[string]float64{}
+	order := make([]string, 0)
+	for i, docs := range lists {
+		for rank, doc := range docs {
+			d, ok := fused[doc.ID]
+			if !ok {
+				d = &schema.Document{ID: doc.ID, Content: doc.Content, MetaData: vectorstore.CopyMetadata(doc.MetaData)}
+				fused[doc.ID] = d
+				order = append(order, doc.ID)
+			}
+			if i < len(metaKeys) {
+				d.MetaData[metaKeys[i]] = doc.Score()
+			}
+			if v, ok := doc.MetaData[MetaVectorScore].(float64); ok && v > similarity(d) {
+				d.MetaData[MetaVectorScore] = v
+			}
+			scores[doc.ID] += 1 / float64(k+rank+1)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	docs := make([]*schema.Document, 0, len(order))
+	for _, id := range order {
+		d := fused[id]
+		d.MetaData[MetaRRFScore] = scores[id]
+		docs = append(docs, d.WithScore(similarity(d)))
+	}
+	return docs
+}
+
+// similarity 文档元数据中记录的向量相似度，仅由关键词召回时为 0
+func similarity(doc *schema.Document) float64 {
+	v, _ := doc.MetaData[MetaVectorScore].(float64)
+	return v
+}
+
+// filterLexical 丢弃没有命中有效词项（见 lexical.Significant）或 BM25 分值低于阈值的关键词召回结果，
+// 避免只命中“的”“怎”等常见单字的文档进入融合结果
+func filterLexical(docs []*schema.Document, query string, threshold float64) []*schema.Document {
+	kept := docs[:0]
+	for _, doc := range docs {
+		if doc.Score() >= threshold && len(lexical.MatchedTerms(query, doc.Content)) > 0 {
+			kept = append(kept, doc)
+		}
+	}
+	return kept
+}
+
+// filterByScore 丢弃相似度低于阈值的文档，阈值不大于 0 时不过滤
+func filterByScore(docs []*schema.Document, threshold float64) []*schema.Document {
+	if threshold <= 0 {
+		return docs
+	}
+	kept := docs[:0]
+	for _, doc := range docs {
+		if doc.Score() >= threshold {
+			kept = append(kept, doc)
+		}
+	}
+	return kept
+}
+
+func truncate(docs []*schema.Document, topK int) []*schema.Document {
+	if len(docs) > topK {
+		return docs[:topK]
+	}
+	return docs
 }
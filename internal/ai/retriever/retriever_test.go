@@ -0,0 +1,174 @@
+package retriever
+
+import (
+	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRRF(t *testing.T) {
+	vector := func(id string, score float64) *schema.Document {
+		return (&schema.Document{ID: id, MetaData: map[string]any{MetaVectorScore: score}}).WithScore(score)
+	}
+	lexicalDoc := func(id string, score float64) *schema.Document {
+		return (&schema.Document{ID: id, MetaData: map[string]any{}}).WithScore(score)
+	}
+	keys := []string{MetaVectorScore, MetaLexicalScore}
+	cases := []struct {
+		name      string
+		lists     [][]*schema.Document
+		wantIDs   []string
+		wantScore []float64 // 融合后 Score 仍为向量相似度
+	}{
+		{
+			name:      "vector only keeps order",
+			lists:     [][]*schema.Document{{vector("a", 0.9), vector("b", 0.7)}, nil},
+			wantIDs:   []string{"a", "b"},
+			wantScore: []float64{0.9, 0.7},
+		},
+		{
+			name:      "hit in both lists ranks first",
+			lists:     [][]*schema.Document{{vector("a", 0.9), vector("b", 0.7)}, {lexicalDoc("b", 3.2), lexicalDoc("c", 2.0)}},
+			wantIDs:   []string{"b", "a", "c"},
+			wantScore: []float64{0.7, 0.9, 0},
+		},
+		{
+			name:      "lexical only has zero similarity",
+			lists:     [][]*schema.Document{nil, {lexicalDoc("c", 2.0)}},
+			wantIDs:   []string{"c"},
+			wantScore: []float64{0},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			docs := rrf(60, c.lists, keys)
+			var ids []string
+			var scores []float64
+			for _, d := range docs {
+				ids = append(ids, d.ID)
+				scores = append(scores, d.Score())
+				if _, ok := d.MetaData[MetaRRFScore].(float64); !ok {
+					t.Errorf("doc %s missing %s", d.ID, MetaRRFScore)
+				}
+			}
+			if !reflect.DeepEqual(ids, c.wantIDs) {
+				t.Errorf("rrf() ids = %v, want %v", ids, c.wantIDs)
+			}
+			if !reflect.DeepEqual(scores, c.wantScore) {
+				t.Errorf("rrf() scores = %v, want %v", scores, c.wantScore)
+			}
+		})
+	}
+}
+
+func TestRRFRecordsLexicalScore(t *testing.T) {
+	docs := rrf(60, [][]*schema.Document{
+		{(&schema.Document{ID: "a", MetaData: map[string]any{MetaVectorScore: 0.8}}).WithScore(0.8)},
+		{(&schema.Document{ID: "a", MetaData: map[string]any{}}).WithScore(4.5)},
+	}, []string{MetaVectorScore, MetaLexicalScore})
+	if len(docs) != 1 {
+		t.Fatalf("rrf() returned %d docs, want 1", len(docs))
+	}
+	if got := docs[0].MetaData[MetaLexicalScore]; got != 4.5 {
+		t.Errorf("%s = %v, want 4.5", MetaLexicalScore, got)
+	}
+	if got := docs[0].MetaData[MetaVectorScore]; got != 0.8 {
+		t.Errorf("%s = %v, want 0.8", MetaVectorScore, got)
+	}
+}
+
+func TestFilterLexical(t *testing.T) {
+	doc := func(id, content string, score float64) *schema.Document {
+		return (&schema.Document{ID: id, Content: content}).WithScore(score)
+	}
+	cases := []struct {
+		name      string
+		docs      []*schema.Document
+		query     string
+		threshold float64
+		wantIDs   []string
+	}{
+		{"keeps significant match", []*schema.Document{doc("a", "磁盘告警处理", 2.0)}, "磁盘满了", 1.0, []string{"a"}},
+		{"drops below threshold", []*schema.Document{doc("a", "磁盘告警处理", 0.5)}, "磁盘满了", 1.0, nil},
+		{"drops single han match", []*schema.Document{doc("a", "服务的日志", 3.0)}, "怎么处理的", 1.0, nil},
+		{"error code", []*schema.Document{doc("a", "ERR_5003 连接池耗尽", 1.2), doc("b", "日志", 1.5)}, "err_5003", 1.0, []string{"a"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var ids []string
+			for _, d := range filterLexical(c.docs, c.query, c.threshold) {
+				ids = append(ids, d.ID)
+			}
+			if !reflect.DeepEqual(ids, c.wantIDs) {
+				t.Errorf("filterLexical() = %v, want %v", ids, c.wantIDs)
+			}
+		})
+	}
+}
+
+// fixedEmbedder 查询向量固定为 [1, 0]
+type fixedEmbedder struct{}
+
+func (fixedEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	out := make([][]float64, len(texts))
+	for i := range texts {
+		out[i] = []float64{1, 0}
+	}
+	return out, nil
+}
+
+func TestRetrieveHybridScoreThreshold(t *testing.T) {
+	dir := t.TempDir()
+	adapter, err := gcfg.NewAdapterContent(fmt.Sprintf("vector_store:\n  type: local\n  local:\n    path: %s\nlexical_index:\n  dir: %s\n",
+		filepath.Join(dir, "vectors.json"), filepath.Join(dir, "lexical")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Cfg().SetAdapter(adapter)
+	ctx := vectorstore.WithKnowledgeBase(context.Background(), "hybrid-threshold")
+	store, err := vectorstore.GetContextStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// lexical 只与查询词面相同，向量与查询正交；vector 与查询向量一致但不含查询词
+	_, err = store.Insert(ctx, []*schema.Document{
+		{ID: "lexical", Content: "磁盘告警处理手册"},
+		{ID: "vector", Content: "存储容量规划"},
+	}, [][]float64{{0, 1}, {1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Retriever{embedding: fixedEmbedder{}, config: GetConfig(ctx)}
+
+	cases := []struct {
+		name      string
+		threshold float64
+		wantIDs   []string
+	}{
+		{"no threshold keeps lexical hits", 0, []string{"lexical", "vector"}},
+		{"high threshold drops lexical only hits", 0.8, []string{"vector"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			docs, err := r.Retrieve(ctx, "磁盘告警", retriever.WithScoreThreshold(c.threshold))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]string, 0, len(docs))
+			for _, doc := range docs {
+				ids = append(ids, doc.ID)
+			}
+			if !reflect.DeepEqual(ids, c.wantIDs) {
+				t.Errorf("Retrieve() ids = %v, want %v", ids, c.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultAlertmanagerURL 未配置 alertmanager.url 时使用的地址
+const DefaultAlertmanagerURL = "http://127.0.0.1:9093"
+
+// MaxAlertmanagerGroupAlerts 每个告警分组最多列出的告警数
+const MaxAlertmanagerGroupAlerts = 10
+
+// MaxAlertmanagerSilences 最多返回的静默数
+const MaxAlertmanagerSilences = 50
+
+// GetAlertmanager 读取配置文件 alertmanager 节点，告警分组与静默工具共用
+func GetAlertmanager(ctx context.Context) *HTTPEndpoint {
+	return getHTTPEndpoint(ctx, "alertmanager", DefaultAlertmanagerURL)
+}
+
+// alertmanagerDo 调用 Alertmanager v2 API，out 不为 nil 时解析响应体
+func alertmanagerDo(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	data, status, err := GetAlertmanager(ctx).do(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case status >= 200 && status < 300:
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return &endpointError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("alertmanager rejected the request: %s", snippet(data))}
+	case status == http.StatusNotFound:
+		return &endpointError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("alertmanager %s not found: %s", path, snippet(data))}
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return &endpointError{Code: ErrCodeServerUnavailable, Message: fmt.Sprintf("alertmanager denied access (status %d): %s", status, snippet(data))}
+	default:
+		return &endpointError{Code: ErrCodeExecution, Message: fmt.Sprintf("alertmanager error (status %d): %s", status, snippet(data))}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err = json.Unmarshal(data, out); err != nil {
+		return &endpointError{Code: ErrCodeServerUnavailable, Message: fmt.Sprintf("unexpected alertmanager response: %s", snippet(data))}
+	}
+	return nil
+}
+
+// String 还原为 Alertmanager filter 参数与静默展示使用的格式，如 severity="critical"
+func (m *labelMatcher) String() string {
+	return m.Name + m.Op + strconv.Quote(m.Value)
+}
+
+// matcherFilter 将标签匹配条件转换为 Alertmanager 的 filter 查询参数
+func matcherFilter(matchers []*labelMatcher) url.Values {
+	query := url.Values{}
+	for _, m := range matchers {
+		query.Add("filter", m.String())
+	}
+	return query
+}
+
+// amAlert Alertmanager v2 API 返回的告警
+type amAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	Fingerprint string            `json:"fingerprint"`
+	Status      struct {
+		State       string   `json:"state"` // active | suppressed | unprocessed
+		SilencedBy  []string `json:"silencedBy"`
+		InhibitedBy []string `json:"inhibitedBy"`
+	} `json:"status"`
+}
+
+// amAlertGroup Alertmanager v2 API 返回的告警分组
+type amAlertGroup struct {
+	Labels   map[string]string `json:"labels"`
+	Receiver struct {
+		Name string `json:"name"`
+	} `json:"receiver"`
+	Alerts []amAlert `json:"alerts"`
+}
+
+// AlertmanagerAlertsInput 告警分组查询参数
+type AlertmanagerAlertsInput struct {
+	Matchers          []string `json:"matchers,omitempty" jsonschema:"description=Label matchers that alerts must all match. Each is name=value or name!=value or name=~regex or name!~regex (for example severity=critical)"`
+	Receiver          string   `json:"receiver,omitempty" jsonschema:"description=Regular expression matching the receiver name. Leave empty for all receivers"`
+	IncludeSuppressed bool     `json:"include_suppressed,omitempty" jsonschema:"description=Also return alerts that are silenced or inhibited. Defaults to false"`
+}
+
+// AlertmanagerAlert 分组中的一条告警，Labels 不含分组标签
+type AlertmanagerAlert struct {
+	Fingerprint string            `json:"fingerprint"`
+	State       string            `json:"state"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Summary     string            `json:"summary,omitempty"`
+	StartsAt    string            `json:"starts_at"`
+	Duration    string            `json:"duration"`
+	SilencedBy  []string          `json:"silenced_by,omitempty"`
+	InhibitedBy []string          `json:"inhibited_by,omitempty"`
+}
+
+// AlertmanagerGroup Alertmanager 按路由分组后的告警
+type AlertmanagerGroup struct {
+	Receiver   string              `json:"receiver"`
+	Labels     map[string]string   `json:"labels"`
+	Total      int                 `json:"total"`
+	Active     int                 `json:"active"`
+	Suppressed int                 `json:"suppressed"`
+	Alerts     []AlertmanagerAlert `json:"alerts"`
+	Omitted    int                 `json:"omitted,omitempty"` // 超过上限未列出的告警数
+}
+
+// AlertmanagerAlertsOutput 告警分组查询输出
+type AlertmanagerAlertsOutput struct {
+	Success bool                `json:"success"`
+	Total   int                 `json:"total"`
+	Groups  []AlertmanagerGroup `json:"groups,omitempty"`
+	Message string              `json:"message,omitempty"`
+	Error   *ToolError          `json:"error,omitempty"`
+}
+
+// summarizeAlertGroup 汇总告警分组，活跃告警排在前面，其次按开始时间从早到晚排列
+func summarizeAlertGroup(g *amAlertGroup) AlertmanagerGroup {
+	out := AlertmanagerGroup{Receiver: g.Receiver.Name, Labels: g.Labels, Total: len(g.Alerts)}
+	sort.SliceStable(g.Alerts, func(i, j int) bool {
+		ai, aj := g.Alerts[i].Status.State == "active", g.Alerts[j].Status.State == "active"
+		if ai != aj {
+			return ai
+		}
+		return g.Alerts[i].StartsAt.Before(g.Alerts[j].StartsAt)
+	})
+	for _, a := range g.Alerts {
+		if a.Status.State == "suppressed" {
+			out.Suppressed++
+		} else {
+			out.Active++
+		}
+		if len(out.Alerts) >= MaxAlertmanagerGroupAlerts {
+			out.Omitted++
+			continue
+		}
+		labels := make(map[string]string, len(a.Labels))
+		for k, v := range a.Labels {
+			if _, grouped := g.Labels[k]; !grouped {
+				labels[k] = v
+			}
+		}
+		startsAt := a.StartsAt.Format(time.RFC3339)
+		out.Alerts = append(out.Alerts, AlertmanagerAlert{
+			Fingerprint: a.Fingerprint,
+			State:       a.Status.State,
+			Labels:      labels,
+			Summary:     a.Annotations["summary"],
+			StartsAt:    startsAt,
+			Duration:    calculateDuration(startsAt),
+			SilencedBy:  a.Status.SilencedBy,
+			InhibitedBy: a.Status.InhibitedBy,
+		})
+	}
+	return out
+}
+
+// NewAlertmanagerAlertGroupsTool 创建 Alertmanager 告警分组查询工具，地址与鉴权来自配置文件 alertmanager 节点
+func NewAlertmanagerAlertGroupsTool() tool.InvokableTool {
+	t, err := utils.InferOptionableTool(
+		"alertmanager_list_alert_groups",
+		"List the alert groups currently held by Alertmanager as routed to receivers. Each group has its group labels and receiver and the alerts in it with their state (active or suppressed) and the silences or inhibitions suppressing them. By default only active alerts are returned. Use this tool to see what on-call is being notified about and which alerts are already silenced.",
+		func(ctx context.Context, input *AlertmanagerAlertsInput, opts ...tool.Option) (output string, err error) {
+			log.Printf("Querying Alertmanager alert groups")
+			out := &AlertmanagerAlertsOutput{}
+			matchers, err := parseLabelMatchers(input.Matchers)
+			if err != nil {
+				out.Error = &ToolError{Code: ErrCodeInvalidArgument, Message: err.Error()}
+				return marshalToolOutput(out), nil
+			}
+			query := matcherFilter(matchers)
+			if input.Receiver != "" {
+				query.Set("receiver", input.Receiver)
+			}
+			if !input.IncludeSuppressed {
+				query.Set("silenced", "false")
+				query.Set("inhibited", "false")
+			}
+			var groups []amAlertGroup
+			if err = alertmanagerDo(ctx, http.MethodGet, "/api/v2/alerts/groups", query, nil, &groups); err != nil {
+				log.Printf("[warn] query alertmanager alert groups failed: %v", err)
+				out.Error = toolErrorOf(err)
+				return marshalToolOutput(out), nil
+			}
+			out.Success = true
+			for i := range groups {
+				if len(groups[i].Alerts) == 0 {
+					continue
+				}
+				g := summarizeAlertGroup(&groups[i])
+				out.Total += g.Total
+				out.Groups = append(out.Groups, g)
+			}
+			out.Message = fmt.Sprintf("Found %d alerts in %d groups", out.Total, len(out.Groups))
+			return marshalToolOutput(out), nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
+
+// amMatcher Alertmanager v2 API 的静默匹配条件
+type amMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual *bool  `json:"isEqual,omitempty"`
+}
+
+// String 以标签匹配条件的格式展示
+func (m *amMatcher) String() string {
+	equal := m.IsEqual == nil || *m.IsEqual
+	op := "="
+	switch {
+	case m.IsRegex && equal:
+		op = "=~"
+	case m.IsRegex:
+		op = "!~"
+	case !equal:
+		op = "!="
+	}
+	return m.Name + op + strconv.Quote(m.Value)
+}
+
+// amSilence Alertmanager v2 API 返回的静默
+type amSilence struct {
+	ID        string      `json:"id"`
+	Matchers  []amMatcher `json:"matchers"`
+	StartsAt  time.Time   `json:"startsAt"`
+	EndsAt    time.Time   `json:"endsAt"`
+	CreatedBy string      `json:"createdBy"`
+	Comment   string      `json:"comment"`
+	Status    struct {
+		State string `json:"state"` // active | pending | expired
+	} `json:"status"`
+}
+
+// AlertmanagerSilencesInput 静默查询参数
+type AlertmanagerSilencesInput struct {
+	Matchers       []string `json:"matchers,omitempty" jsonschema:"description=Label matchers that silences must contain. Each is name=value or name!=value or name=~regex or name!~regex"`
+	IncludeExpired bool     `json:"include_expired,omitempty" jsonschema:"description=Also return expired silences. Defaults to false"`
+}
+
+// AlertmanagerSilence 静默信息，EOCall 表示由本服务创建
+type AlertmanagerSilence struct {
+	ID        string   `json:"id"`
+	State     string   `json:"state"`
+	Matchers  []string `json:"matchers"`
+	StartsAt  string   `json:"starts_at"`
+	EndsAt    string   `json:"ends_at"`
+	CreatedBy string   `json:"created_by"`
+	Comment   string   `json:"comment"`
+	EOCall    bool     `json:"eocall"`
+}
+
+// AlertmanagerSilencesOutput 静默查询输出
+type AlertmanagerSilencesOutput struct {
+	Success  bool                  `json:"success"`
+	Total    int                   `json:"total"`
+	Silences []AlertmanagerSilence `json:"silences,omitempty"`
+	Message  string                `json:"message,omitempty"`
+	Error    *ToolError            `json:"error,omitempty"`
+}
+
+var silenceStateOrder = map[string]int{"active": 0, "pending": 1, "expired": 2}
+
+// NewAlertmanagerSilencesTool 创建 Alertmanager 静默查询工具
+func NewAlertmanagerSilencesTool() tool.InvokableTool {
+	t, err := utils.InferOptionableTool(
+		"alertmanager_list_silences",
+		"List Alertmanager silences with their matchers and time range and who created them and why. Active and pending silences are returned by default. Silences created through EOCall are marked with eocall=true. Use this tool before creating a silence to avoid duplicates and to find the ID of a silence to expire.",
+		func(ctx context.Context, input *AlertmanagerSilencesInput, opts ...tool.Option) (output string, err error) {
+			log.Printf("Querying Alertmanager silences")
+			out := &AlertmanagerSilencesOutput{}
+			matchers, err := parseLabelMatchers(input.Matchers)
+			if err != nil {
+				out.Error = &ToolError{Code: ErrCodeInvalidArgument, Message: err.Error()}
+				return marshalToolOutput(out), nil
+			}
+			var silences []amSilence
+			if err = alertmanagerDo(ctx, http.MethodGet, "/api/v2/silences", matcherFilter(matchers), nil, &silences); err != nil {
+				log.Printf("[warn] query alertmanager silences failed: %v", err)
+				out.Error = toolErrorOf(err)
+				return marshalToolOutput(out), nil
+			}
+			kept := silences[:0]
+			for _, s := range silences {
+				if input.IncludeExpired || s.Status.State != "expired" {
+					kept = append(kept, s)
+				}
+			}
+			sort.SliceStable(kept, func(i, j int) bool {
+				if oi, oj := silenceStateOrder[kept[i].Status.State], silenceStateOrder[kept[j].Status.State]; oi != oj {
+					return oi < oj
+				}
+				return kept[i].EndsAt.Before(kept[j].EndsAt)
+			})
+			out.Success = true
+			out.Total = len(kept)
+			for _, s := range kept {
+				if len(out.Silences) >= MaxAlertmanagerSilences {
+					break
+				}
+				out.Silences = append(out.Silences, toSilence(&s))
+			}
+			out.Message = fmt.Sprintf("Found %d silences", out.Total)
+			if out.Total > len(out.Silences) {
+				out.Message += fmt.Sprintf(", showing the first %d", len(out.Silences))
+			}
+			return marshalToolOutput(out), nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
+
+func toSilence(s *amSilence) AlertmanagerSilence {
+	matchers := make([]string, 0, len(s.Matchers))
+	for i := range s.Matchers {
+		matchers = append(matchers, s.Matchers[i].String())
+	}
+	return AlertmanagerSilence{
+		ID:        s.ID,
+		State:     s.Status.State,
+		Matchers:  matchers,
+		StartsAt:  s.StartsAt.Format(time.RFC3339),
+		EndsAt:    s.EndsAt.Format(time.RFC3339),
+		CreatedBy: s.CreatedBy,
+		Comment:   s.Comment,
+		EOCall:    isEOCallSilence(s),
+	}
+}
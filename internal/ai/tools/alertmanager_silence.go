@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 静默时长的默认值与上限，可通过配置 alertmanager.default_silence_duration 与 alertmanager.max_silence_duration 覆盖
+const (
+	DefaultSilenceDuration    = time.Hour
+	DefaultMaxSilenceDuration = 24 * time.Hour
+)
+
+// silenceTag EOCall 创建的静默在注释末尾追加的标记，记录发起的用户与会话
+const silenceTag = "[eocall"
+
+// silenceTools 创建与解除静默的工具，无论 approval.tools 如何配置都需要人工审批
+var silenceTools = []string{"alertmanager_create_silence", "alertmanager_expire_silence"}
+
+// isEOCallSilence 判断静默是否由 EOCall 创建
+func isEOCallSilence(s *amSilence) bool {
+	return strings.Contains(s.Comment, silenceTag+" ")
+}
+
+// silenceComment 在注释末尾追加发起的用户与会话
+func silenceComment(ctx context.Context, comment string) string {
+	session := SessionFromContext(ctx)
+	if session == "" {
+		session = "-"
+	}
+	return fmt.Sprintf("%s %s user=%s session=%s]", strings.TrimSpace(comment), silenceTag, auth.FromContext(ctx).User, session)
+}
+
+// silenceDurations 读取静默的默认时长与上限
+func silenceDurations(ctx context.Context) (def, max time.Duration) {
+	def = g.Cfg().MustGet(ctx, "alertmanager.default_silence_duration", DefaultSilenceDuration).Duration()
+	max = g.Cfg().MustGet(ctx, "alertmanager.max_silence_duration", DefaultMaxSilenceDuration).Duration()
+	if max <= 0 {
+		max = DefaultMaxSilenceDuration
+	}
+	if def <= 0 || def > max {
+		def = min(DefaultSilenceDuration, max)
+	}
+	return def, max
+}
+
+// CreateSilenceInput 创建静默的参数
+type CreateSilenceInput struct {
+	Matchers []string `json:"matchers" jsonschema:"description=Label matchers selecting the alerts to silence. Each is name=value or name!=value or name=~regex or name!~regex. Be as specific as possible (for example alertname=HighCPU and instance=10.0.0.1:9100)"`
+	Duration string   `json:"duration,omitempty" jsonschema:"description=How long the silence lasts from now (for example 30m or 2h). Defaults to the configured default and is capped by the configured maximum"`
+	Comment  string   `json:"comment" jsonschema:"description=Why the alerts are being silenced (for example the incident and the ongoing mitigation)"`
+}
+
+// CreateSilenceOutput 创建静默的结果
+type CreateSilenceOutput struct {
+	Success   bool       `json:"success"`
+	SilenceID string     `json:"silence_id,omitempty"`
+	Matchers  []string   `json:"matchers,omitempty"`
+	StartsAt  string     `json:"starts_at,omitempty"`
+	EndsAt    string     `json:"ends_at,omitempty"`
+	Message   string     `json:"message,omitempty"`
+	Error     *ToolError `json:"error,omitempty"`
+}
+
+// NewAlertmanagerCreateSilenceTool 创建 Alertmanager 静默创建工具，调用前需要人工审批
+func NewAlertmanagerCreateSilenceTool() tool.InvokableTool {
+	t, err := utils.InferOptionableTool(
+		silenceTools[0],
+		"Create an Alertmanager silence starting now so that matching alerts stop notifying on-call. Requires human approval before it takes effect. The silence is tagged with the requesting user and session. Check alertmanager_list_silences first to avoid duplicates and keep the matchers as narrow as possible.",
+		func(ctx context.Context, input *CreateSilenceInput, opts ...tool.Option) (output string, err error) {
+			return marshalToolOutput(createSilence(ctx, input, time.Now())), nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
+
+func createSilence(ctx context.Context, input *CreateSilenceInput, now time.Time) *CreateSilenceOutput {
+	out := &CreateSilenceOutput{}
+	invalid := func(format string, args ...any) *CreateSilenceOutput {
+		out.Error = &ToolError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf(format, args...)}
+		return out
+	}
+	matchers, err := parseLabelMatchers(input.Matchers)
+	if err != nil {
+		return invalid("%v", err)
+	}
+	if len(matchers) == 0 {
+		return invalid("at least one matcher is required")
+	}
+	// 与 Alertmanager 的校验一致：全部条件都匹配空值的静默会屏蔽所有告警
+	matchesAll := true
+	for _, m := range matchers {
+		matchesAll = matchesAll && m.Matches(map[string]string{})
+	}
+	if matchesAll {
+		return invalid("at least one matcher must not match an empty label value, the silence would mute every alert")
+	}
+	if strings.TrimSpace(input.Comment) == "" {
+		return invalid("comment is required")
+	}
+	duration, max := silenceDurations(ctx)
+	if input.Duration != "" {
+		if duration, err = parsePromDuration(input.Duration); err != nil || duration <= 0 {
+			return invalid("invalid duration %q", input.Duration)
+		}
+	}
+	if duration > max {
+		return invalid("duration %s exceeds the maximum of %s", duration, max)
+	}
+
+	body := struct {
+		Matchers  []amMatcher `json:"matchers"`
+		StartsAt  string      `json:"startsAt"`
+		EndsAt    string      `json:"endsAt"`
+		CreatedBy string      `json:"createdBy"`
+		Comment   string      `json:"comment"`
+	}{
+		StartsAt:  now.UTC().Format(time.RFC3339),
+		EndsAt:    now.Add(duration).UTC().Format(time.RFC3339),
+		CreatedBy: auth.FromContext(ctx).User,
+		Comment:   silenceComment(ctx, input.Comment),
+	}
+	for _, m := range matchers {
+		equal := m.Op == "=" || m.Op == "=~"
+		body.Matchers = append(body.Matchers, amMatcher{Name: m.Name, Value: m.Value, IsRegex: m.re != nil, IsEqual: &equal})
+		out.Matchers = append(out.Matchers, m.String())
+	}
+	var resp struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err = alertmanagerDo(ctx, http.MethodPost, "/api/v2/silences", nil, &body, &resp); err != nil {
+		log.Printf("[warn] create alertmanager silence failed: %v", err)
+		out.Error = toolErrorOf(err)
+		return out
+	}
+	log.Printf("Alertmanager silence %s created by %s: %s", resp.SilenceID, body.CreatedBy, strings.Join(out.Matchers, ","))
+	out.Success = true
+	out.SilenceID = resp.SilenceID
+	out.StartsAt = body.StartsAt
+	out.EndsAt = body.EndsAt
+	out.Message = fmt.Sprintf("Silence %s created until %s", resp.SilenceID, body.EndsAt)
+	return out
+}
+
+// ExpireSilenceInput 解除静默的参数
+type ExpireSilenceInput struct {
+	SilenceID string `json:"silence_id" jsonschema:"description=ID of the silence to expire as returned by alertmanager_list_silences"`
+}
+
+// ExpireSilenceOutput 解除静默的结果
+type ExpireSilenceOutput struct {
+	Success bool       `json:"success"`
+	Message string     `json:"message,omitempty"`
+	Error   *ToolError `json:"error,omitempty"`
+}
+
+// NewAlertmanagerExpireSilenceTool 创建 Alertmanager 静默解除工具，调用前需要人工审批
+func NewAlertmanagerExpireSilenceTool() tool.InvokableTool {
+	t, err := utils.InferOptionableTool(
+		silenceTools[1],
+		"Expire an Alertmanager silence immediately so that matching alerts notify again. Requires human approval before it takes effect.",
+		func(ctx context.Context, input *ExpireSilenceInput, opts ...tool.Option) (output string, err error) {
+			out := &ExpireSilenceOutput{}
+			id := strings.TrimSpace(input.SilenceID)
+			if id == "" {
+				out.Error = &ToolError{Code: ErrCodeInvalidArgument, Message: "silence_id is required"}
+				return marshalToolOutput(out), nil
+			}
+			if err = alertmanagerDo(ctx, http.MethodDelete, "/api/v2/silence/"+url.PathEscape(id), nil, nil, nil); err != nil {
+				log.Printf("[warn] expire alertmanager silence %s failed: %v", id, err)
+				out.Error = toolErrorOf(err)
+				return marshalToolOutput(out), nil
+			}
+			log.Printf("Alertmanager silence %s expired by %s", id, auth.FromContext(ctx).User)
+			out.Success = true
+			out.Message = fmt.Sprintf("Silence %s expired", id)
+			return marshalToolOutput(out), nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
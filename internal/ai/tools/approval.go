@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// sideEffectingTools 默认需要人工审批的工具，可通过配置 approval.tools 覆盖
+var sideEffectingTools = []string{"mysql_crud"}
+
+// ApprovalRequest 工具调用暂停时抛出的中断信息，包含模型生成的原始参数
+type ApprovalRequest struct {
+	Tool      string `json:"tool"`
+	Arguments string `json:"arguments"`
+}
+
+// ApprovalDecision 恢复执行时传入的审批结果
+type ApprovalDecision struct {
+	Approved bool   `json:"approved"`
+	Approver string `json:"approver"`
+	Reason   string `json:"reason"`
+}
+
+func init() {
+	schema.RegisterName[*ApprovalRequest]("eocall_approval_request")
+	schema.RegisterName[*ApprovalDecision]("eocall_approval_decision")
+}
+
+// SideEffecting 判断工具是否有副作用、调用前需要人工审批，工具名支持通配符
+// 创建与解除 Alertmanager 静默的工具始终需要审批
+func SideEffecting(ctx context.Context, name string) bool {
+	return matchAny(silenceTools, name) || matchAny(g.Cfg().MustGet(ctx, "approval.tools", sideEffectingTools).Strings(), name)
+}
+
+// ApprovalChecker 工具可按本次调用的参数判断是否需要审批，如只读查询无需审批
+type ApprovalChecker interface {
+	RequiresApproval(ctx context.Context, argumentsInJSON string) bool
+}
+
+// WithApproval 为有副作用的工具增加人工审批：首次调用时以 ApprovalRequest 中断图的执行并保存检查点，
+// 调用方通过 compose.ResumeWithData 传入 ApprovalDecision 恢复执行，批准后才真正调用工具
+// 其他工具原样返回
+func WithApproval(ctx context.Context, t tool.BaseTool) (tool.BaseTool, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !SideEffecting(ctx, info.Name) {
+		return t, nil
+	}
+	it, ok := t.(tool.InvokableTool)
+	if !ok {
+		return nil, fmt.Errorf("tool %s requires approval but is not invokable", info.Name)
+	}
+	return &approvalTool{InvokableTool: it, name: info.Name}, nil
+}
+
+// WithApprovals 为工具列表中有副作用的工具增加人工审批
+func WithApprovals(ctx context.Context, tools []tool.BaseTool) ([]tool.BaseTool, error) {
+	out := make([]tool.BaseTool, 0, len(tools))
+	for _, t := range tools {
+		wrapped, err := WithApproval(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, wrapped)
+	}
+	return out, nil
+}
+
+// approvalTool 调用前需要人工审批的工具
+type approvalTool struct {
+	tool.InvokableTool
+	name string
+}
+
+// InvokableRun 未审批时中断执行；恢复执行时只有本次调用被明确批准才执行工具
+func (a *approvalTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	wasInterrupted, _, _ := compose.GetInterruptState[string](ctx)
+	req := &ApprovalRequest{Tool: a.name, Arguments: argumentsInJSON}
+	if !wasInterrupted {
+		if checker, ok := a.InvokableTool.(ApprovalChecker); ok && !checker.RequiresApproval(ctx, argumentsInJSON) {
+			return a.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+		}
+		return "", compose.StatefulInterrupt(ctx, req, argumentsInJSON)
+	}
+	isResume, hasData, decision := compose.GetResumeContext[*ApprovalDecision](ctx)
+	if !isResume {
+		// 本次恢复针对的是其他中断点，继续等待审批
+		return "", compose.StatefulInterrupt(ctx, req, argumentsInJSON)
+	}
+	if !hasData || decision == nil || !decision.Approved {
+		return fmt.Sprintf("操作未获批准，工具 %s 没有执行", a.name), nil
+	}
+	return a.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+}
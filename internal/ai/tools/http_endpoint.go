@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gogf/gf/v2/frame/g"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultEndpointTimeout 调用外部 HTTP 服务的默认超时
+const DefaultEndpointTimeout = 10 * time.Second
+
+// maxResponseSize 外部服务响应体的大小上限，防止异常响应占满内存
+const maxResponseSize = 32 << 20
+
+// HTTPEndpoint 外部 HTTP 服务的地址与鉴权配置，如 Prometheus、Alertmanager
+type HTTPEndpoint struct {
+	URL         string
+	BearerToken string
+	Username    string // Basic 认证，与 BearerToken 二选一
+	Password    string
+	Headers     map[string]string
+	Timeout     time.Duration
+}
+
+// getHTTPEndpoint 从配置节点读取服务地址与鉴权配置
+func getHTTPEndpoint(ctx context.Context, key, defaultURL string) *HTTPEndpoint {
+	m := g.Cfg().MustGet(ctx, key).MapStrVar()
+	e := &HTTPEndpoint{
+		URL:         m["url"].String(),
+		BearerToken: m["bearer_token"].String(),
+		Username:    m["username"].String(),
+		Password:    m["password"].String(),
+		Headers:     m["headers"].MapStrStr(),
+		Timeout:     m["timeout"].Duration(),
+	}
+	if e.URL == "" {
+		e.URL = defaultURL
+	}
+	if e.Timeout <= 0 {
+		e.Timeout = DefaultEndpointTimeout
+	}
+	return e
+}
+
+// endpointError 调用外部服务失败，Code 为返回给模型的错误码
+type endpointError struct {
+	Code    string
+	Message string
+}
+
+func (e *endpointError) Error() string {
+	return e.Message
+}
+
+// toolErrorOf 将调用外部服务的错误转换为返回给模型的结构化错误
+func toolErrorOf(err error) *ToolError {
+	var ee *endpointError
+	if errors.As(err, &ee) {
+		return &ToolError{Code: ee.Code, Message: ee.Message}
+	}
+	return &ToolError{Code: ErrCodeExecution, Message: err.Error()}
+}
+
+// do 发送请求并返回响应体与状态码，body 不为 nil 时以 JSON 发送
+// 连接失败与超时返回 endpointError，非 2xx 状态码由调用方按服务的响应格式处理
+func (e *HTTPEndpoint) do(ctx context.Context, method, path string, query url.Values, body any) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+	u := strings.TrimRight(e.URL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, 0, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, 0, &endpointError{Code: ErrCodeInvalidArgument, Message: err.Error()}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	if e.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+e.BearerToken)
+	} else if e.Username != "" {
+		req.SetBasicAuth(e.Username, e.Password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, 0, &endpointError{Code: ErrCodeTimeout, Message: fmt.Sprintf("request %s timed out after %s", e.URL, e.Timeout)}
+		}
+		return nil, 0, &endpointError{Code: ErrCodeServerUnavailable, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, resp.StatusCode, &endpointError{Code: ErrCodeServerUnavailable, Message: fmt.Sprintf("read response of %s failed: %v", e.URL, err)}
+	}
+	return data, resp.StatusCode, nil
+}
+
+// snippet 截取响应体的开头，用于错误信息
+func snippet(data []byte) string {
+	const n = 200
+	s := strings.TrimSpace(string(data))
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
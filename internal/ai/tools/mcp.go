@@ -0,0 +1,349 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/NuyoahCh/eocall/utility/closer"
+	e_mcp "github.com/cloudwego/eino-ext/components/tool/mcp"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"log"
+	"sync"
+	"time"
+)
+
+// MCP 服务的传输方式
+const (
+	McpTransportSSE            = "sse"
+	McpTransportStreamableHTTP = "streamable_http"
+	McpTransportStdio          = "stdio"
+)
+
+// DefaultMcpTimeout 连接并初始化 MCP 服务的默认超时
+const DefaultMcpTimeout = 10 * time.Second
+
+// MCP 服务断开后重连的退避时间，按连续连接失败的次数指数增长
+const (
+	DefaultMcpReconnectBackoff    = time.Second
+	DefaultMcpMaxReconnectBackoff = time.Minute
+)
+
+// McpServer 配置文件 mcp_servers 中的一个 MCP 服务
+// 参考：https://www.cloudwego.io/zh/docs/eino/ecosystem_integration/tool/tool_mcp/ 与 https://mcp-go.dev/clients
+type McpServer struct {
+	Name      string            `json:"name"`
+	Transport string            `json:"transport"` // sse | streamable_http | stdio，默认 sse
+	URL       string            `json:"url"`       // sse 与 streamable_http 的服务地址
+	Headers   map[string]string `json:"headers"`   // 请求头，如 Authorization
+	Command   string            `json:"command"`   // stdio 启动的命令
+	Args      []string          `json:"args"`
+	Env       []string          `json:"env"`     // stdio 子进程的环境变量，格式为 KEY=VALUE
+	Include   []string          `json:"include"` // 只加载匹配的工具，支持通配符，为空表示全部
+	Exclude   []string          `json:"exclude"` // 不加载匹配的工具，支持通配符
+	Prefix    string            `json:"prefix"`  // 工具名前缀，用于区分不同服务的同名工具
+	Timeout   string            `json:"timeout"` // 连接并初始化的超时，默认 10s
+}
+
+// GetMcpServers 从配置文件读取 MCP 服务列表
+func GetMcpServers(ctx context.Context) []*McpServer {
+	var servers []*McpServer
+	if err := g.Cfg().MustGet(ctx, "mcp_servers").Scan(&servers); err != nil {
+		log.Printf("[warn] parse mcp_servers failed: %v", err)
+		return nil
+	}
+	return servers
+}
+
+// key 服务配置的唯一标识，配置不变时复用已建立的连接
+func (s *McpServer) key() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// mcpConn 单个 MCP 服务的连接，配置不变时在多次构建之间复用
+// 调用因传输错误失败时断开，下一次调用时重新连接；连续连接失败时按指数退避等待后再重连
+type mcpConn struct {
+	server *McpServer
+	key    string
+	ctx    context.Context // 建立连接使用的上下文，SSE 等长连接随之存活
+	refs   int             // 持有该连接的智能体构建数，由 mcpMu 保护
+
+	mu       sync.Mutex
+	cli      *client.Client // 为空表示尚未连接或已断开
+	failures int            // 连续连接失败的次数
+	retryAt  time.Time      // 允许下一次重连的时间
+	closed   bool
+}
+
+var (
+	mcpMu    sync.Mutex
+	mcpConns = map[string]*mcpConn{}
+)
+
+// GetMcpTools 连接全部 MCP 服务并加载工具，对话智能体与排查智能体共享连接
+// 连接失败的服务记录告警后跳过，下一次构建智能体时重试，不影响其他工具
+// ctx 需在智能体的生命周期内保持有效，SSE 等长连接随之存活；ctx 中有 closer.Group 时登记连接的引用，
+// 构建结果被替换后释放，没有智能体引用的连接（如配置中已删除的服务、stdio 子进程）随之关闭
+func GetMcpTools(ctx context.Context) []tool.BaseTool {
+	var out []tool.BaseTool
+	seen := map[string]string{}
+	for _, s := range GetMcpServers(ctx) {
+		loaded, err := loadMcpTools(ctx, s)
+		if err != nil {
+			log.Printf("[warn] load tools of mcp server %s failed, skipped: %v", s.Name, err)
+			continue
+		}
+		for _, t := range loaded {
+			info, err := t.Info(ctx)
+			if err != nil {
+				continue
+			}
+			if other, ok := seen[info.Name]; ok {
+				log.Printf("[warn] mcp tool %s of server %s conflicts with server %s, skipped, set a prefix to load both", info.Name, s.Name, other)
+				continue
+			}
+			seen[info.Name] = s.Name
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// loadMcpTools 加载单个服务的工具并按配置筛选、加前缀
+func loadMcpTools(ctx context.Context, s *McpServer) ([]tool.BaseTool, error) {
+	conn := acquireMcpConn(ctx, s)
+	cli, err := conn.client()
+	if err != nil {
+		releaseMcpConn(conn)
+		return nil, err
+	}
+	listCtx, cancel := context.WithTimeout(ctx, mcpTimeout(s))
+	defer cancel()
+	all, err := e_mcp.GetTools(listCtx, &e_mcp.Config{Cli: cli, CustomHeaders: s.Headers})
+	if err != nil {
+		conn.evictOn(cli, err)
+		releaseMcpConn(conn)
+		return nil, err
+	}
+	closer.Add(ctx, func() error {
+		releaseMcpConn(conn)
+		return nil
+	})
+	var out []tool.BaseTool
+	for _, t := range all {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(s.Include) > 0 && !matchAny(s.Include, info.Name) || matchAny(s.Exclude, info.Name) {
+			continue
+		}
+		if _, ok := t.(tool.InvokableTool); !ok {
+			continue
+		}
+		renamed := *info
+		renamed.Name = s.Prefix + info.Name
+		out = append(out, &mcpTool{conn: conn, name: info.Name, info: &renamed})
+	}
+	return out, nil
+}
+
+// acquireMcpConn 获取服务的连接并增加引用，配置不变时复用，变更后重新建立，旧连接在引用全部释放后关闭
+func acquireMcpConn(ctx context.Context, s *McpServer) *mcpConn {
+	key := s.key()
+	mcpMu.Lock()
+	defer mcpMu.Unlock()
+	if conn, ok := mcpConns[s.Name]; ok {
+		if conn.key == key {
+			conn.refs++
+			return conn
+		}
+		delete(mcpConns, s.Name)
+		if conn.refs == 0 {
+			conn.close()
+		}
+	}
+	conn := &mcpConn{server: s, key: key, ctx: context.WithoutCancel(ctx), refs: 1}
+	mcpConns[s.Name] = conn
+	return conn
+}
+
+// releaseMcpConn 释放连接的一个引用，没有引用时关闭并移除连接
+func releaseMcpConn(conn *mcpConn) {
+	mcpMu.Lock()
+	defer mcpMu.Unlock()
+	conn.refs--
+	if conn.refs > 0 {
+		return
+	}
+	if mcpConns[conn.server.Name] == conn {
+		delete(mcpConns, conn.server.Name)
+	}
+	conn.close()
+}
+
+// client 获取已初始化的客户端，未连接时重新连接，退避期间直接返回错误
+// 建立连接期间持有锁，并发调用同一服务时只连接一次
+func (c *mcpConn) client() (*client.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, fmt.Errorf("mcp client of server %s is closed", c.server.Name)
+	}
+	if c.cli != nil {
+		return c.cli, nil
+	}
+	if wait := time.Until(c.retryAt); wait > 0 {
+		return nil, fmt.Errorf("mcp server %s is unavailable, reconnect in %s", c.server.Name, wait.Round(time.Millisecond))
+	}
+	cli, err := dialMcp(c.ctx, c.server)
+	if err != nil {
+		c.failures++
+		c.retryAt = time.Now().Add(mcpBackoff(c.failures))
+		return nil, err
+	}
+	c.cli, c.failures, c.retryAt = cli, 0, time.Time{}
+	return cli, nil
+}
+
+// evictOn 请求因传输错误失败时断开连接，下一次调用时重新连接；调用方取消或超时不视为连接断开
+func (c *mcpConn) evictOn(cli *client.Client, err error) {
+	var te *transport.Error
+	if !errors.As(err, &te) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 连接已被其他调用断开或重连
+	if c.cli != cli {
+		return
+	}
+	log.Printf("[warn] mcp server %s disconnected, reconnect on next call: %v", c.server.Name, err)
+	c.cli = nil
+	_ = cli.Close()
+}
+
+// close 关闭连接，之后的调用直接返回错误
+func (c *mcpConn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.cli == nil {
+		return
+	}
+	if err := c.cli.Close(); err != nil {
+		log.Printf("[warn] close mcp client of server %s failed: %v", c.server.Name, err)
+	}
+	c.cli = nil
+}
+
+// callTool 调用服务端的工具，返回序列化后的调用结果
+func (c *mcpConn) callTool(ctx context.Context, name, argumentsInJSON string) (string, error) {
+	cli, err := c.client()
+	if err != nil {
+		return "", err
+	}
+	result, err := cli.CallTool(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      name,
+			Arguments: json.RawMessage(argumentsInJSON),
+		},
+	})
+	if err != nil {
+		c.evictOn(cli, err)
+		return "", fmt.Errorf("failed to call mcp tool: %w", err)
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal mcp tool result: %w", err)
+	}
+	if result.IsError {
+		return "", fmt.Errorf("mcp server return error: %s", out)
+	}
+	return string(out), nil
+}
+
+// dialMcp 建立连接并完成初始化，stdio 传输会启动子进程
+func dialMcp(ctx context.Context, s *McpServer) (*client.Client, error) {
+	var (
+		cli *client.Client
+		err error
+	)
+	switch s.Transport {
+	case "", McpTransportSSE:
+		cli, err = client.NewSSEMCPClient(s.URL, transport.WithHeaders(s.Headers))
+	case McpTransportStreamableHTTP:
+		cli, err = client.NewStreamableHttpClient(s.URL, transport.WithHTTPHeaders(s.Headers))
+	case McpTransportStdio:
+		// stdio 客户端创建时即启动子进程
+		cli, err = client.NewStdioMCPClient(s.Command, s.Env, s.Args...)
+	default:
+		return nil, fmt.Errorf("unsupported mcp transport %q", s.Transport)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.Transport != McpTransportStdio {
+		if err = cli.Start(ctx); err != nil {
+			_ = cli.Close()
+			return nil, err
+		}
+	}
+	initCtx, cancel := context.WithTimeout(ctx, mcpTimeout(s))
+	defer cancel()
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{
+		Name:    "eocall",
+		Version: "1.0.0",
+	}
+	if _, err = cli.Initialize(initCtx, initRequest); err != nil {
+		_ = cli.Close()
+		return nil, err
+	}
+	return cli, nil
+}
+
+// mcpBackoff 第 failures 次连续连接失败后的退避时间
+func mcpBackoff(failures int) time.Duration {
+	d := DefaultMcpReconnectBackoff
+	for i := 1; i < failures && d < DefaultMcpMaxReconnectBackoff; i++ {
+		d *= 2
+	}
+	return min(d, DefaultMcpMaxReconnectBackoff)
+}
+
+func mcpTimeout(s *McpServer) time.Duration {
+	d, err := time.ParseDuration(s.Timeout)
+	if err != nil || d <= 0 {
+		return DefaultMcpTimeout
+	}
+	return d
+}
+
+// mcpTool MCP 服务提供的工具，按配置加前缀，调用失败时把错误返回给模型而不是中断智能体
+type mcpTool struct {
+	conn *mcpConn
+	name string // 服务端的工具名，不含前缀
+	info *schema.ToolInfo
+}
+
+func (t *mcpTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+func (t *mcpTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	out, err := t.conn.callTool(ctx, t.name, argumentsInJSON)
+	if err != nil {
+		server := t.conn.server.Name
+		log.Printf("[warn] call mcp tool %s of server %s failed: %v", t.info.Name, server, err)
+		return marshalToolOutput(map[string]any{"success": false, "error": &ToolError{Code: ErrCodeServerUnavailable, Message: fmt.Sprintf("mcp server %s: %v", server, err)}}), nil
+	}
+	return out, nil
+}
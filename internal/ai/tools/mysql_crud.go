@@ -1,67 +1,139 @@
 package tools
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
-	"gorm.io/driver/mysql"
-	"gorm.io/gorm"
+	"github.com/gogf/gf/v2/os/gctx"
 	"log"
-	"os"
+	"strings"
 )
 
 // MysqlCrudInput 输入参数
 type MysqlCrudInput struct {
-	DSN         string `json:"dsn" jsonschema:"description=The Data Source Name for connecting to the MySQL database, including username, password, host, port, and database name"`
-	SQL         string `json:"sql" jsonschema:"description=The SQL query to execute against the MySQL database"`
-	OperateType string `json:"operate_type" jsonschema:"description=The type of SQL operation to perform: query, insert, update, or delete"`
+	Datasource string `json:"datasource" jsonschema:"description=Name of the configured datasource to run the statement against"`
+	SQL        string `json:"sql" jsonschema:"description=A single MySQL statement. Read-only datasources only accept SELECT, SHOW, DESCRIBE and EXPLAIN"`
 }
 
-// NewMysqlCrudTool 实现工具包
+// MysqlCrudOutput 输出结果，查询返回 columns 与 rows，修改返回 rows_affected，失败时返回 error
+type MysqlCrudOutput struct {
+	Success    bool   `json:"success"`
+	Datasource string `json:"datasource,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	*QueryResult
+	RowsAffected *int64     `json:"rows_affected,omitempty"`
+	Error        *ToolError `json:"error,omitempty"`
+}
+
+// NewMysqlCrudTool 实现工具包，只能访问配置文件 mysql.datasources 中的具名数据源
 func NewMysqlCrudTool() tool.InvokableTool {
 	t, err := utils.InferOptionableTool(
 		"mysql_crud",
-		"Execute SQL queries against the MySQL database and return results in JSON format. Use this tool when you need to query, insert, update or delete data from the database. The results will be formatted as JSON for easy parsing.",
+		mysqlCrudDescription(gctx.GetInitCtx()),
 		func(ctx context.Context, input *MysqlCrudInput, opts ...tool.Option) (output string, err error) {
-			// 1. 建立数据库连接
-			db, err := gorm.Open(mysql.Open(input.DSN), &gorm.Config{})
+			out := runMysqlCrud(ctx, input)
+			b, err := json.Marshal(out)
 			if err != nil {
-				log.Fatal(err)
-			}
-			scanner := bufio.NewScanner(os.Stdin)
-			fmt.Print("\n请确定是否执行本sql(y/n): ", input.SQL)
-			scanner.Scan()
-			fmt.Println()
-			nInput := scanner.Text()
-			if nInput != "y" {
-				return "用户取消执行sql", nil
+				return "", err
 			}
+			return string(b), nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return &mysqlCrudTool{InvokableTool: t}
+}
 
-			// 2. 执行 SQL 查询
-			err = db.Exec(input.SQL).Error
-			if err != nil {
-				log.Fatal(err)
-			}
+// mysqlCrudDescription 工具描述中列出可用的数据源
+func mysqlCrudDescription(ctx context.Context) string {
+	var b strings.Builder
+	b.WriteString("Execute a single SQL statement against a configured MySQL datasource and return results in JSON format. Queries return columns and rows (truncated to the row limit), modifications return rows_affected, failures return an error with a code. Modifications need human approval and are only allowed on writable datasources.")
+	datasources := GetDatasources(ctx)
+	if len(datasources) == 0 {
+		b.WriteString(" No datasource is configured.")
+		return b.String()
+	}
+	b.WriteString(" Available datasources:")
+	for _, ds := range datasources {
+		mode := "read-only"
+		if !ds.ReadOnly {
+			mode = "writable"
+		}
+		fmt.Fprintf(&b, "\n- %s (%s)", ds.Name, mode)
+		if ds.Description != "" {
+			b.WriteString(": " + ds.Description)
+		}
+	}
+	return b.String()
+}
 
-			// 3. 获取查询结果
-			if input.OperateType == "query" {
-				var results []interface{}
-				err = db.Raw(input.SQL).Scan(&results).Error
-				if err != nil {
-					log.Fatal(err)
-				}
+// runMysqlCrud 校验并执行语句，所有错误都以结构化结果返回，不中断智能体
+func runMysqlCrud(ctx context.Context, input *MysqlCrudInput) *MysqlCrudOutput {
+	fail := func(code, format string, args ...any) *MysqlCrudOutput {
+		return &MysqlCrudOutput{Datasource: input.Datasource, Error: &ToolError{Code: code, Message: fmt.Sprintf(format, args...)}}
+	}
+	if input.Datasource == "" || strings.TrimSpace(input.SQL) == "" {
+		return fail(ErrCodeInvalidArgument, "datasource and sql are required")
+	}
+	ds, ok := GetDatasource(ctx, input.Datasource)
+	if !ok {
+		return fail(ErrCodeUnknownDatasource, "datasource %q is not configured, available: %s", input.Datasource, strings.Join(datasourceNames(ctx), ", "))
+	}
+	kind, err := ClassifySQL(input.SQL)
+	if err != nil {
+		return fail(ErrCodeForbidden, "%v", err)
+	}
+	if kind == SQLWrite && ds.ReadOnly {
+		return fail(ErrCodeReadOnly, "datasource %s is read-only, only SELECT, SHOW, DESCRIBE and EXPLAIN are allowed", ds.Name)
+	}
 
-				// 4. 将结果格式化为 JSON
-				resBytes, err := json.Marshal(results)
-				return string(resBytes), err
-			}
-			return "", nil
-		})
+	ctx, cancel := context.WithTimeout(ctx, ds.Timeout)
+	defer cancel()
+	out := &MysqlCrudOutput{Success: true, Datasource: ds.Name, Kind: kind}
+	if kind == SQLRead {
+		out.QueryResult, err = ds.Query(ctx, input.SQL, ds.MaxRows)
+	} else {
+		var affected int64
+		affected, err = ds.Exec(ctx, input.SQL)
+		out.RowsAffected = &affected
+	}
 	if err != nil {
-		log.Fatal(err)
+		return fail(classifyDBError(ctx, err), "%v", err)
+	}
+	return out
+}
+
+// classifyDBError 区分超时、连接失败与数据库返回的执行错误
+func classifyDBError(ctx context.Context, err error) string {
+	var openErr *datasourceOpenError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeTimeout
+	case errors.As(err, &openErr):
+		return ErrCodeUnavailable
+	default:
+		return ErrCodeExecution
+	}
+}
+
+// mysqlCrudTool 只有可写数据源上的修改语句需要人工审批，只读查询直接执行
+type mysqlCrudTool struct {
+	tool.InvokableTool
+}
+
+// RequiresApproval 实现 ApprovalChecker
+func (t *mysqlCrudTool) RequiresApproval(ctx context.Context, argumentsInJSON string) bool {
+	var input MysqlCrudInput
+	if err := json.Unmarshal([]byte(argumentsInJSON), &input); err != nil {
+		return false
+	}
+	ds, ok := GetDatasource(ctx, input.Datasource)
+	if !ok || ds.ReadOnly {
+		return false
 	}
-	return t
+	kind, err := ClassifySQL(input.SQL)
+	return err == nil && kind == SQLWrite
 }
@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/gogf/gf/v2/frame/g"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 数据源的默认配置
+const (
+	DefaultMaxRows         = 200
+	DefaultStatementTime   = 10 * time.Second
+	DefaultMaxOpenConns    = 5
+	DefaultMaxIdleConns    = 2
+	DefaultConnMaxLifetime = 30 * time.Minute
+)
+
+// Datasource 配置文件 mysql.datasources 中的具名数据源，模型只能按名称选择，无法指定连接串
+type Datasource struct {
+	Name            string
+	Description     string
+	DSN             string
+	ReadOnly        bool          // 只读数据源只允许执行查询，默认开启
+	MaxRows         int           // 单次查询最多返回的行数
+	Timeout         time.Duration // 单条语句的执行超时
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// GetDatasources 从配置文件读取全部数据源，按名称排序
+func GetDatasources(ctx context.Context) []*Datasource {
+	var out []*Datasource
+	for name, v := range g.Cfg().MustGet(ctx, "mysql.datasources").MapStrVar() {
+		m := v.MapStrVar()
+		ds := &Datasource{
+			Name:            name,
+			Description:     m["description"].String(),
+			DSN:             m["dsn"].String(),
+			ReadOnly:        m["read_only"].IsNil() || m["read_only"].Bool(),
+			MaxRows:         m["max_rows"].Int(),
+			Timeout:         m["timeout"].Duration(),
+			MaxOpenConns:    m["max_open_conns"].Int(),
+			MaxIdleConns:    m["max_idle_conns"].Int(),
+			ConnMaxLifetime: m["conn_max_lifetime"].Duration(),
+		}
+		if ds.MaxRows <= 0 {
+			ds.MaxRows = DefaultMaxRows
+		}
+		if ds.Timeout <= 0 {
+			ds.Timeout = DefaultStatementTime
+		}
+		if ds.MaxOpenConns <= 0 {
+			ds.MaxOpenConns = DefaultMaxOpenConns
+		}
+		if ds.MaxIdleConns <= 0 {
+			ds.MaxIdleConns = DefaultMaxIdleConns
+		}
+		if ds.ConnMaxLifetime <= 0 {
+			ds.ConnMaxLifetime = DefaultConnMaxLifetime
+		}
+		out = append(out, ds)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// GetDatasource 按名称查找数据源
+func GetDatasource(ctx context.Context, name string) (*Datasource, bool) {
+	for _, ds := range GetDatasources(ctx) {
+		if ds.Name == name {
+			return ds, true
+		}
+	}
+	return nil, false
+}
+
+// datasourceNames 全部数据源的名称
+func datasourceNames(ctx context.Context) []string {
+	var names []string
+	for _, ds := range GetDatasources(ctx) {
+		names = append(names, ds.Name)
+	}
+	return names
+}
+
+// pool 数据源的连接池，连接串或连接池参数变更后重新创建
+type pool struct {
+	db  *sql.DB
+	key string
+}
+
+var (
+	poolsMu sync.Mutex
+	pools   = map[string]*pool{}
+)
+
+// DB 获取数据源的连接池，所有工具调用共享
+func (ds *Datasource) DB() (*sql.DB, error) {
+	key := fmt.Sprintf("%s|%d|%d|%s", ds.DSN, ds.MaxOpenConns, ds.MaxIdleConns, ds.ConnMaxLifetime)
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	if p, ok := pools[ds.Name]; ok {
+		if p.key == key {
+			return p.db, nil
+		}
+		_ = p.db.Close()
+		delete(pools, ds.Name)
+	}
+	db, err := gorm.Open(mysql.Open(ds.DSN), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, &datasourceOpenError{name: ds.Name, err: err}
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, &datasourceOpenError{name: ds.Name, err: err}
+	}
+	sqlDB.SetMaxOpenConns(ds.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(ds.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(ds.ConnMaxLifetime)
+	pools[ds.Name] = &pool{db: sqlDB, key: key}
+	return sqlDB, nil
+}
+
+// datasourceOpenError 无法连接数据源
+type datasourceOpenError struct {
+	name string
+	err  error
+}
+
+func (e *datasourceOpenError) Error() string {
+	return fmt.Sprintf("open datasource %s failed: %v", e.name, e.err)
+}
+
+func (e *datasourceOpenError) Unwrap() error {
+	return e.err
+}
+
+// QueryResult 查询结果，Truncated 表示结果超出行数上限被截断
+type QueryResult struct {
+	Columns   []string `json:"columns"`
+	Rows      [][]any  `json:"rows"` // 与 Columns 顺序一致
+	RowCount  int      `json:"row_count"`
+	Truncated bool     `json:"truncated"`
+}
+
+// Query 在只读事务中执行查询，最多读取 maxRows 行，超时由 ctx 控制
+func (ds *Datasource) Query(ctx context.Context, query string, maxRows int, args ...any) (*QueryResult, error) {
+	db, err := ds.DB()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	// 服务端同样限制 SELECT 的执行时间，客户端超时后查询不会继续占用数据库
+	if deadline, ok := ctx.Deadline(); ok {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET SESSION MAX_EXECUTION_TIME=%d", time.Until(deadline).Milliseconds())); err != nil {
+			log.Printf("[warn] set max_execution_time on datasource %s failed: %v", ds.Name, err)
+		}
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	res := &QueryResult{Columns: columns, Rows: [][]any{}}
+	for rows.Next() {
+		if res.RowCount >= maxRows {
+			res.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		res.Rows = append(res.Rows, values)
+		res.RowCount++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Exec 执行数据修改语句，返回影响的行数
+func (ds *Datasource) Exec(ctx context.Context, stmt string, args ...any) (int64, error) {
+	db, err := ds.DB()
+	if err != nil {
+		return 0, err
+	}
+	result, err := db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
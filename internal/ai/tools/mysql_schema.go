@@ -0,0 +1,270 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/gogf/gf/v2/util/gconv"
+	"log"
+	"strings"
+)
+
+// 抽样行数的默认值与上限
+const (
+	DefaultSampleRows = 5
+	MaxSampleRows     = 20
+)
+
+// TableInfo 数据表概要，RowsEstimate 为存储引擎统计的估算行数
+type TableInfo struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	RowsEstimate int64  `json:"rows_estimate"`
+	Comment      string `json:"comment,omitempty"`
+}
+
+// ColumnInfo 字段定义
+type ColumnInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Key      string `json:"key,omitempty"`
+	Default  any    `json:"default,omitempty"`
+	Extra    string `json:"extra,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// IndexInfo 索引定义，Columns 按索引中的顺序排列
+type IndexInfo struct {
+	Name    string   `json:"name"`
+	Unique  bool     `json:"unique"`
+	Columns []string `json:"columns"`
+}
+
+// ListTablesInput 输入参数
+type ListTablesInput struct {
+	Datasource string `json:"datasource" jsonschema:"description=Name of the configured datasource"`
+}
+
+// ListTablesOutput 输出结果
+type ListTablesOutput struct {
+	Success    bool        `json:"success"`
+	Datasource string      `json:"datasource,omitempty"`
+	Tables     []TableInfo `json:"tables,omitempty"`
+	Error      *ToolError  `json:"error,omitempty"`
+}
+
+// DescribeTableInput 输入参数
+type DescribeTableInput struct {
+	Datasource string `json:"datasource" jsonschema:"description=Name of the configured datasource"`
+	Table      string `json:"table" jsonschema:"description=Table name as returned by mysql_list_tables"`
+}
+
+// DescribeTableOutput 输出结果
+type DescribeTableOutput struct {
+	Success    bool         `json:"success"`
+	Datasource string       `json:"datasource,omitempty"`
+	Table      *TableInfo   `json:"table,omitempty"`
+	Columns    []ColumnInfo `json:"columns,omitempty"`
+	Indexes    []IndexInfo  `json:"indexes,omitempty"`
+	Error      *ToolError   `json:"error,omitempty"`
+}
+
+// SampleRowsInput 输入参数
+type SampleRowsInput struct {
+	Datasource string `json:"datasource" jsonschema:"description=Name of the configured datasource"`
+	Table      string `json:"table" jsonschema:"description=Table name as returned by mysql_list_tables"`
+	Limit      int    `json:"limit,omitempty" jsonschema:"description=Number of rows to sample, default 5, at most 20"`
+}
+
+// SampleRowsOutput 输出结果
+type SampleRowsOutput struct {
+	Success    bool   `json:"success"`
+	Datasource string `json:"datasource,omitempty"`
+	Table      string `json:"table,omitempty"`
+	*QueryResult
+	Error *ToolError `json:"error,omitempty"`
+}
+
+// NewListTablesTool 列出数据源中的数据表
+func NewListTablesTool() tool.InvokableTool {
+	t, err := utils.InferOptionableTool(
+		"mysql_list_tables",
+		"List tables of a configured MySQL datasource with type, estimated row count and comment. Call this before writing SQL for mysql_crud instead of guessing table names.",
+		func(ctx context.Context, input *ListTablesInput, opts ...tool.Option) (output string, err error) {
+			return cachedResult(ctx, "mysql_list_tables|"+input.Datasource, func() (string, bool) {
+				out := &ListTablesOutput{Datasource: input.Datasource}
+				ds, toolErr := schemaDatasource(ctx, input.Datasource)
+				if toolErr == nil {
+					out.Tables, toolErr = listTables(ctx, ds, "")
+				}
+				out.Error, out.Success = toolErr, toolErr == nil
+				return marshalToolOutput(out), out.Success
+			}), nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
+
+// NewDescribeTableTool 查看数据表的字段、索引与估算行数
+func NewDescribeTableTool() tool.InvokableTool {
+	t, err := utils.InferOptionableTool(
+		"mysql_describe_table",
+		"Describe a table of a configured MySQL datasource: columns with types, nullability, keys and comments, indexes, and estimated row count. Use it to write correct diagnostic queries for mysql_crud.",
+		func(ctx context.Context, input *DescribeTableInput, opts ...tool.Option) (output string, err error) {
+			return cachedResult(ctx, "mysql_describe_table|"+input.Datasource+"|"+input.Table, func() (string, bool) {
+				out := &DescribeTableOutput{Datasource: input.Datasource}
+				out.Error = describeTable(ctx, input, out)
+				out.Success = out.Error == nil
+				return marshalToolOutput(out), out.Success
+			}), nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
+
+// NewSampleRowsTool 抽样查看数据表中的若干行
+func NewSampleRowsTool() tool.InvokableTool {
+	t, err := utils.InferOptionableTool(
+		"mysql_sample_rows",
+		"Return a few sample rows of a table from a configured MySQL datasource to learn the value formats before writing queries.",
+		func(ctx context.Context, input *SampleRowsInput, opts ...tool.Option) (output string, err error) {
+			limit := input.Limit
+			if limit <= 0 {
+				limit = DefaultSampleRows
+			}
+			limit = min(limit, MaxSampleRows)
+			key := fmt.Sprintf("mysql_sample_rows|%s|%s|%d", input.Datasource, input.Table, limit)
+			return cachedResult(ctx, key, func() (string, bool) {
+				out := &SampleRowsOutput{Datasource: input.Datasource, Table: input.Table}
+				out.Error = sampleRows(ctx, input, limit, out)
+				out.Success = out.Error == nil
+				return marshalToolOutput(out), out.Success
+			}), nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
+
+// schemaDatasource 按名称查找数据源，未配置时返回结构化错误
+func schemaDatasource(ctx context.Context, name string) (*Datasource, *ToolError) {
+	if name == "" {
+		return nil, &ToolError{Code: ErrCodeInvalidArgument, Message: "datasource is required"}
+	}
+	ds, ok := GetDatasource(ctx, name)
+	if !ok {
+		return nil, &ToolError{Code: ErrCodeUnknownDatasource, Message: fmt.Sprintf("datasource %q is not configured, available: %s", name, strings.Join(datasourceNames(ctx), ", "))}
+	}
+	return ds, nil
+}
+
+// schemaQuery 在数据源的超时时间内执行元数据查询
+func schemaQuery(ctx context.Context, ds *Datasource, query string, maxRows int, args ...any) (*QueryResult, *ToolError) {
+	ctx, cancel := context.WithTimeout(ctx, ds.Timeout)
+	defer cancel()
+	res, err := ds.Query(ctx, query, maxRows, args...)
+	if err != nil {
+		return nil, &ToolError{Code: classifyDBError(ctx, err), Message: err.Error()}
+	}
+	return res, nil
+}
+
+// listTables 列出当前库的数据表，table 不为空时只查询该表
+func listTables(ctx context.Context, ds *Datasource, table string) ([]TableInfo, *ToolError) {
+	query := "SELECT TABLE_NAME, TABLE_TYPE, TABLE_ROWS, TABLE_COMMENT FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE()"
+	var args []any
+	if table != "" {
+		query += " AND TABLE_NAME = ?"
+		args = append(args, table)
+	}
+	res, toolErr := schemaQuery(ctx, ds, query+" ORDER BY TABLE_NAME", 1000, args...)
+	if toolErr != nil {
+		return nil, toolErr
+	}
+	tables := make([]TableInfo, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		tables = append(tables, TableInfo{
+			Name:         gconv.String(row[0]),
+			Type:         gconv.String(row[1]),
+			RowsEstimate: gconv.Int64(row[2]),
+			Comment:      gconv.String(row[3]),
+		})
+	}
+	return tables, nil
+}
+
+// findTable 确认数据表存在，模型传入的表名只有在元数据中存在时才会拼接进 SQL
+func findTable(ctx context.Context, ds *Datasource, table string) (*TableInfo, *ToolError) {
+	if table == "" {
+		return nil, &ToolError{Code: ErrCodeInvalidArgument, Message: "table is required"}
+	}
+	tables, toolErr := listTables(ctx, ds, table)
+	if toolErr != nil {
+		return nil, toolErr
+	}
+	if len(tables) == 0 {
+		return nil, &ToolError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("table %q does not exist in datasource %s, call mysql_list_tables first", table, ds.Name)}
+	}
+	return &tables[0], nil
+}
+
+func describeTable(ctx context.Context, input *DescribeTableInput, out *DescribeTableOutput) *ToolError {
+	ds, toolErr := schemaDatasource(ctx, input.Datasource)
+	if toolErr != nil {
+		return toolErr
+	}
+	if out.Table, toolErr = findTable(ctx, ds, input.Table); toolErr != nil {
+		return toolErr
+	}
+	res, toolErr := schemaQuery(ctx, ds, "SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_KEY, COLUMN_DEFAULT, EXTRA, COLUMN_COMMENT FROM information_schema.COLUMNS "+
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION", 1000, input.Table)
+	if toolErr != nil {
+		return toolErr
+	}
+	for _, row := range res.Rows {
+		out.Columns = append(out.Columns, ColumnInfo{
+			Name:     gconv.String(row[0]),
+			Type:     gconv.String(row[1]),
+			Nullable: gconv.String(row[2]) == "YES",
+			Key:      gconv.String(row[3]),
+			Default:  row[4],
+			Extra:    gconv.String(row[5]),
+			Comment:  gconv.String(row[6]),
+		})
+	}
+	res, toolErr = schemaQuery(ctx, ds, "SELECT INDEX_NAME, NON_UNIQUE, COLUMN_NAME FROM information_schema.STATISTICS "+
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX", 1000, input.Table)
+	if toolErr != nil {
+		return toolErr
+	}
+	for _, row := range res.Rows {
+		name := gconv.String(row[0])
+		if n := len(out.Indexes); n == 0 || out.Indexes[n-1].Name != name {
+			out.Indexes = append(out.Indexes, IndexInfo{Name: name, Unique: gconv.Int(row[1]) == 0})
+		}
+		idx := &out.Indexes[len(out.Indexes)-1]
+		idx.Columns = append(idx.Columns, gconv.String(row[2]))
+	}
+	return nil
+}
+
+func sampleRows(ctx context.Context, input *SampleRowsInput, limit int, out *SampleRowsOutput) *ToolError {
+	ds, toolErr := schemaDatasource(ctx, input.Datasource)
+	if toolErr != nil {
+		return toolErr
+	}
+	if _, toolErr = findTable(ctx, ds, input.Table); toolErr != nil {
+		return toolErr
+	}
+	limit = min(limit, ds.MaxRows)
+	query := fmt.Sprintf("SELECT * FROM `%s` LIMIT %d", strings.ReplaceAll(input.Table, "`", "``"), limit)
+	out.QueryResult, toolErr = schemaQuery(ctx, ds, query, limit)
+	return toolErr
+}
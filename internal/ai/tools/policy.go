@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/gogf/gf/v2/frame/g"
+	"log"
+	"path"
+)
+
+// AllTools 工具策略中表示全部工具的模式
+const AllTools = "*"
+
+// Policy 工具访问策略，对应配置文件中的 tool_policy 节点，工具名支持 path.Match 通配符
+// 用户规则优先；未配置用户规则时取调用方各角色规则的并集；都未命中时使用默认规则
+type Policy struct {
+	Default []string            // 默认允许的工具
+	Roles   map[string][]string // 按角色允许的工具
+	Users   map[string][]string // 按用户允许的工具
+}
+
+// GetPolicy 从配置文件读取工具访问策略，未配置时允许全部工具
+func GetPolicy(ctx context.Context) *Policy {
+	cfg := g.Cfg()
+	p := &Policy{
+		Default: cfg.MustGet(ctx, "tool_policy.default", []string{AllTools}).Strings(),
+		Roles:   map[string][]string{},
+		Users:   map[string][]string{},
+	}
+	for role, v := range cfg.MustGet(ctx, "tool_policy.roles").MapStrVar() {
+		p.Roles[role] = v.Strings()
+	}
+	for user, v := range cfg.MustGet(ctx, "tool_policy.users").MapStrVar() {
+		p.Users[user] = v.Strings()
+	}
+	return p
+}
+
+// patterns 调用方适用的工具模式
+func (p *Policy) patterns(id *auth.Identity) []string {
+	if rules, ok := p.Users[id.User]; ok && !id.Anonymous {
+		return rules
+	}
+	var rules []string
+	matched := false
+	for _, role := range id.Roles {
+		if r, ok := p.Roles[role]; ok {
+			rules = append(rules, r...)
+			matched = true
+		}
+	}
+	if matched {
+		return rules
+	}
+	return p.Default
+}
+
+// Allow 判断调用方是否可以使用指定工具
+func (p *Policy) Allow(id *auth.Identity, name string) bool {
+	return matchAny(p.patterns(id), name)
+}
+
+type requestedKey struct{}
+
+// WithRequested 将单次请求选择的工具写入上下文，names 为 nil 表示不限制，空切片表示不使用任何工具
+func WithRequested(ctx context.Context, names []string) context.Context {
+	return context.WithValue(ctx, requestedKey{}, names)
+}
+
+// RequestedFromContext 读取上下文中请求选择的工具，未选择时返回 nil
+func RequestedFromContext(ctx context.Context) []string {
+	names, _ := ctx.Value(requestedKey{}).([]string)
+	return names
+}
+
+// Permitted 返回调用方可以使用的工具名：服务端策略与请求选择的交集，保持 names 的顺序
+func Permitted(ctx context.Context, names []string) []string {
+	p := GetPolicy(ctx)
+	id := auth.FromContext(ctx)
+	requested, limited := ctx.Value(requestedKey{}).([]string)
+	limited = limited && requested != nil
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if !p.Allow(id, name) {
+			continue
+		}
+		if limited && !matchAny(requested, name) {
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// Names 读取工具名，读取失败的工具会被忽略
+func Names(ctx context.Context, tools []tool.BaseTool) []string {
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			log.Printf("[warn] get tool info failed: %v", err)
+			continue
+		}
+		names = append(names, info.Name)
+	}
+	return names
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == AllTools || pattern == name {
+			return true
+		}
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
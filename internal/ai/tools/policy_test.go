@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"reflect"
+	"testing"
+)
+
+const testPolicyConfig = `
+tool_policy:
+  default: ["query_internal_docs", "get_current_time"]
+  roles:
+    sre: ["query_*", "get_current_time"]
+    dba: ["mysql_crud"]
+  users:
+    alice: ["*"]
+`
+
+func TestPermitted(t *testing.T) {
+	adapter, err := gcfg.NewAdapterContent(testPolicyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Cfg().SetAdapter(adapter)
+	all := []string{"query_internal_docs", "query_prometheus_alerts", "get_current_time", "mysql_crud"}
+	cases := []struct {
+		name      string
+		id        *auth.Identity
+		requested []string
+		limit     bool // 是否在上下文中写入请求选择的工具
+		want      []string
+	}{
+		{"anonymous uses default", &auth.Identity{Anonymous: true}, nil, false, []string{"query_internal_docs", "get_current_time"}},
+		{"role wildcard", &auth.Identity{User: "bob", Roles: []string{"sre"}}, nil, false, []string{"query_internal_docs", "query_prometheus_alerts", "get_current_time"}},
+		{"union of roles", &auth.Identity{User: "bob", Roles: []string{"sre", "dba"}}, nil, false, all},
+		{"unknown role uses default", &auth.Identity{User: "bob", Roles: []string{"viewer"}}, nil, false, []string{"query_internal_docs", "get_current_time"}},
+		{"user rule wins over roles", &auth.Identity{User: "alice", Roles: []string{"dba"}}, nil, false, all},
+		{"anonymous ignores user rule", &auth.Identity{User: "alice", Anonymous: true}, nil, false, []string{"query_internal_docs", "get_current_time"}},
+		{"request narrows policy", &auth.Identity{User: "alice"}, []string{"query_*"}, true, []string{"query_internal_docs", "query_prometheus_alerts"}},
+		{"request cannot widen policy", &auth.Identity{Anonymous: true}, []string{"mysql_crud", "get_current_time"}, true, []string{"get_current_time"}},
+		{"empty request disables tools", &auth.Identity{User: "alice"}, []string{}, true, []string{}},
+		{"nil request is unlimited", &auth.Identity{User: "alice"}, nil, true, all},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := auth.WithIdentity(context.Background(), c.id)
+			if c.limit {
+				ctx = WithRequested(ctx, c.requested)
+			}
+			if got := Permitted(ctx, all); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Permitted() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DefaultPrometheusURL 未配置 prometheus.url 时使用的地址
+const DefaultPrometheusURL = "http://127.0.0.1:9090"
+
+// GetPrometheus 读取配置文件 prometheus 节点，告警与指标查询工具共用
+func GetPrometheus(ctx context.Context) *HTTPEndpoint {
+	return getHTTPEndpoint(ctx, "prometheus", DefaultPrometheusURL)
+}
+
+// prometheusResponse Prometheus HTTP API 的响应格式
+type prometheusResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+	Warnings  []string        `json:"warnings"`
+}
+
+// prometheusGet 调用 Prometheus HTTP API 并将 data 字段解析到 data 中
+func prometheusGet(ctx context.Context, path string, query url.Values, data any) error {
+	body, status, err := GetPrometheus(ctx).do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return err
+	}
+	var resp prometheusResponse
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return &endpointError{Code: ErrCodeServerUnavailable, Message: fmt.Sprintf("unexpected prometheus response (status %d): %s", status, snippet(body))}
+	}
+	if resp.Status != "success" {
+		code := ErrCodeExecution
+		switch resp.ErrorType {
+		case "bad_data":
+			code = ErrCodeInvalidArgument
+		case "timeout", "canceled":
+			code = ErrCodeTimeout
+		case "unavailable":
+			code = ErrCodeServerUnavailable
+		}
+		return &endpointError{Code: code, Message: fmt.Sprintf("prometheus %s: %s", resp.ErrorType, resp.Error)}
+	}
+	return json.Unmarshal(resp.Data, data)
+}
+
+// labelMatcher PromQL 风格的标签匹配条件，如 severity="critical"、instance=~"10\..*"
+type labelMatcher struct {
+	Name  string
+	Op    string // = | != | =~ | !~
+	Value string
+	re    *regexp.Regexp
+}
+
+var labelMatcherPattern = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`)
+
+// parseLabelMatcher 解析标签匹配条件，值可以带引号；正则与 Prometheus 一致，需要匹配完整的值
+func parseLabelMatcher(s string) (*labelMatcher, error) {
+	parts := labelMatcherPattern.FindStringSubmatch(s)
+	if parts == nil {
+		return nil, fmt.Errorf("invalid label matcher %q, expected name=\"value\", name!=\"value\", name=~\"regex\" or name!~\"regex\"", s)
+	}
+	m := &labelMatcher{Name: parts[1], Op: parts[2], Value: parts[3]}
+	if len(m.Value) >= 2 && (m.Value[0] == '"' || m.Value[0] == '\'' || m.Value[0] == '`') && m.Value[len(m.Value)-1] == m.Value[0] {
+		m.Value = m.Value[1 : len(m.Value)-1]
+	}
+	if m.Op == "=~" || m.Op == "!~" {
+		re, err := regexp.Compile("^(?:" + m.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in label matcher %q: %v", s, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// Matches 判断标签集是否满足条件，不存在的标签视为空值
+func (m *labelMatcher) Matches(labels map[string]string) bool {
+	v := labels[m.Name]
+	switch m.Op {
+	case "=":
+		return v == m.Value
+	case "!=":
+		return v != m.Value
+	case "=~":
+		return m.re.MatchString(v)
+	default:
+		return !m.re.MatchString(v)
+	}
+}
+
+// parseLabelMatchers 解析全部匹配条件
+func parseLabelMatchers(list []string) ([]*labelMatcher, error) {
+	out := make([]*labelMatcher, 0, len(list))
+	for _, s := range list {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		m, err := parseLabelMatcher(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package tools
+
+import "testing"
+
+func TestParseLabelMatcher(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     string
+		wantErr   bool
+		wantName  string
+		wantOp    string
+		wantValue string
+		match     map[string]string
+		mismatch  map[string]string
+	}{
+		{"equal quoted", `severity="critical"`, false, "severity", "=", "critical", map[string]string{"severity": "critical"}, map[string]string{"severity": "warning"}},
+		{"equal unquoted with spaces", ` severity = critical `, false, "severity", "=", "critical", map[string]string{"severity": "critical"}, map[string]string{}},
+		{"single quoted", `team='db'`, false, "team", "=", "db", map[string]string{"team": "db"}, map[string]string{"team": "app"}},
+		{"not equal", `env!="prod"`, false, "env", "!=", "prod", map[string]string{"env": "test"}, map[string]string{"env": "prod"}},
+		{"not equal missing label", `env!="prod"`, false, "env", "!=", "prod", map[string]string{}, map[string]string{"env": "prod"}},
+		{"regex anchored", `instance=~"10\..*"`, false, "instance", "=~", `10\..*`, map[string]string{"instance": "10.0.0.1:9100"}, map[string]string{"instance": "110.0.0.1:9100"}},
+		{"regex alternation", `severity=~"critical|warning"`, false, "severity", "=~", "critical|warning", map[string]string{"severity": "warning"}, map[string]string{"severity": "critical_low"}},
+		{"negative regex", `job!~"node.*"`, false, "job", "!~", "node.*", map[string]string{"job": "mysql"}, map[string]string{"job": "node_exporter"}},
+		{"empty value matches missing label", `team=""`, false, "team", "=", "", map[string]string{}, map[string]string{"team": "db"}},
+		{"invalid name", `1severity="critical"`, true, "", "", "", nil, nil},
+		{"missing operator", `severity critical`, true, "", "", "", nil, nil},
+		{"invalid regex", `job=~"("`, true, "", "", "", nil, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m, err := parseLabelMatcher(c.input)
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("parseLabelMatcher(%q) = %+v, want error", c.input, m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLabelMatcher(%q) error = %v", c.input, err)
+			}
+			if m.Name != c.wantName || m.Op != c.wantOp || m.Value != c.wantValue {
+				t.Errorf("parseLabelMatcher(%q) = %s %s %q, want %s %s %q", c.input, m.Name, m.Op, m.Value, c.wantName, c.wantOp, c.wantValue)
+			}
+			if !m.Matches(c.match) {
+				t.Errorf("Matches(%v) = false, want true", c.match)
+			}
+			if m.Matches(c.mismatch) {
+				t.Errorf("Matches(%v) = true, want false", c.mismatch)
+			}
+		})
+	}
+}
+
+func TestParseLabelMatchers(t *testing.T) {
+	ms, err := parseLabelMatchers([]string{`severity="critical"`, "  ", `team=~"db|app"`})
+	if err != nil || len(ms) != 2 {
+		t.Fatalf("parseLabelMatchers() = %d matchers, %v, want 2", len(ms), err)
+	}
+	if _, err = parseLabelMatchers([]string{`severity="critical"`, "bad"}); err == nil {
+		t.Error("parseLabelMatchers() with an invalid matcher should fail")
+	}
+}
@@ -10,7 +10,9 @@ import (
 )
 
 type QueryInternalDocsInput struct {
-	Query string `json:"query" jsonschema:"description=The query string to search in internal documentation for relevant information and processing steps"`
+	Query          string   `json:"query" jsonschema:"description=The query string to search in internal documentation for relevant information and processing steps"`
+	TopK           int      `json:"top_k,omitempty" jsonschema:"description=Optional number of document chunks to return. Leave empty to use the server default"`
+	ScoreThreshold *float64 `json:"score_threshold,omitempty" jsonschema:"description=Optional minimum similarity score between 0 and 1. Chunks scoring lower are dropped. Leave empty to use the server default"`
 }
 
 func NewQueryInternalDocsTool() tool.InvokableTool {
@@ -18,13 +20,18 @@ func NewQueryInternalDocsTool() tool.InvokableTool {
 		"query_internal_docs",
 		"Use this tool to search internal documentation and knowledge base for relevant information. It performs RAG (Retrieval-Augmented Generation) to find similar documents and extract processing steps. This is useful when you need to understand internal procedures, best practices, or step-by-step guides stored in the company's documentation.",
 		func(ctx context.Context, input *QueryInternalDocsInput, opts ...tool.Option) (output string, err error) {
-			rr, err := retriever.NewMilvusRetriever(ctx)
+			rr, err := retriever.NewRetriever(ctx)
 			if err != nil {
-				log.Fatal(err)
+				return "", err
 			}
-			resp, err := rr.Retrieve(ctx, input.Query)
+			overrides := &retriever.Overrides{TopK: input.TopK, ScoreThreshold: input.ScoreThreshold}
+			resp, err := rr.Retrieve(ctx, input.Query, overrides.Options()...)
 			if err != nil {
-				log.Fatal(err)
+				return "", err
+			}
+			resp, err = retriever.ExpandParents(ctx, resp, retriever.GetParentConfig(ctx))
+			if err != nil {
+				return "", err
 			}
 			respBytes, _ := json.Marshal(resp)
 			output = string(respBytes)
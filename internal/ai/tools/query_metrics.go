@@ -0,0 +1,457 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"log"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 指标查询结果的默认上限，超出部分截断或降采样，避免结果超出模型上下文
+const (
+	DefaultMetricsMaxSeries  = 20   // 最多返回的序列数
+	DefaultMetricsMaxSamples = 30   // 每个序列降采样后最多返回的点数
+	DefaultRangePoints       = 120  // 未指定 step 时按该点数自动计算
+	MaxRangePoints           = 5000 // 单个序列的最大原始点数，超出时要求增大 step
+	minRangeStep             = 15 * time.Second
+	maxChangePoints          = 3
+)
+
+// PrometheusMetricsInput 输入参数，不指定 start 时执行即时查询
+type PrometheusMetricsInput struct {
+	Query string `json:"query" jsonschema:"description=PromQL expression to evaluate"`
+	Start string `json:"start,omitempty" jsonschema:"description=Start of the range query. RFC3339 time or unix seconds or a time relative to now such as now-1h or -30m. Leave empty for an instant query"`
+	End   string `json:"end,omitempty" jsonschema:"description=End of the range query in the same formats as start. Defaults to now"`
+	Step  string `json:"step,omitempty" jsonschema:"description=Resolution step of the range query such as 30s or 5m. Leave empty to choose one automatically"`
+	Time  string `json:"time,omitempty" jsonschema:"description=Evaluation time of the instant query in the same formats as start. Defaults to now"`
+}
+
+// MetricSample 降采样后的数据点
+type MetricSample struct {
+	Time  string  `json:"t"`
+	Value float64 `json:"v"`
+}
+
+// ChangePoint 序列中的突变点，Before 与 After 为突变前后的值
+type ChangePoint struct {
+	Time   string  `json:"time"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+}
+
+// SeriesSummary 序列的统计摘要，忽略 NaN 与 Inf
+type SeriesSummary struct {
+	Min          float64       `json:"min"`
+	Max          float64       `json:"max"`
+	Avg          float64       `json:"avg"`
+	Last         float64       `json:"last"`
+	Trend        string        `json:"trend"`                   // rising | falling | flat
+	ChangeRate   float64       `json:"change_rate"`             // 末段均值相对首段均值的变化比例
+	ChangePoints []ChangePoint `json:"change_points,omitempty"` // 按时间排列
+}
+
+// MetricSeries 区间查询返回的一个序列
+type MetricSeries struct {
+	Metric  map[string]string `json:"metric"`
+	Points  int               `json:"points"` // 原始点数
+	Summary *SeriesSummary    `json:"summary,omitempty"`
+	Samples []MetricSample    `json:"samples,omitempty"`
+}
+
+// MetricValue 即时查询返回的一个值，保留 Prometheus 的字符串表示以支持 NaN 与 Inf
+type MetricValue struct {
+	Metric map[string]string `json:"metric,omitempty"`
+	Time   string            `json:"time"`
+	Value  string            `json:"value"`
+}
+
+// PrometheusMetricsOutput 输出结果，即时查询返回 values，区间查询返回 series
+type PrometheusMetricsOutput struct {
+	Success    bool           `json:"success"`
+	ResultType string         `json:"result_type,omitempty"`
+	Step       string         `json:"step,omitempty"`
+	Values     []MetricValue  `json:"values,omitempty"`
+	Series     []MetricSeries `json:"series,omitempty"`
+	Total      int            `json:"total"`               // 查询返回的序列数
+	Truncated  bool           `json:"truncated,omitempty"` // 是否只返回了部分序列
+	Error      *ToolError     `json:"error,omitempty"`
+}
+
+// metricsLimits 读取配置的结果上限
+func metricsLimits(ctx context.Context) (maxSeries, maxSamples int) {
+	maxSeries = g.Cfg().MustGet(ctx, "prometheus.max_series", DefaultMetricsMaxSeries).Int()
+	maxSamples = g.Cfg().MustGet(ctx, "prometheus.max_samples", DefaultMetricsMaxSamples).Int()
+	return max(maxSeries, 1), max(maxSamples, 2)
+}
+
+// NewPrometheusMetricsQueryTool 创建 PromQL 查询工具，与告警工具共用 prometheus 配置
+func NewPrometheusMetricsQueryTool() tool.InvokableTool {
+	t, err := utils.InferOptionableTool(
+		"query_prometheus_metrics",
+		"Run a PromQL query against Prometheus for root cause analysis. Without start it runs an instant query and returns the current value of each series. With start (and optionally end and step) it runs a range query: every series is summarized with min, max, avg, last, trend and change points, and downsampled to a few samples. Only the series with the highest last value are returned when there are many, so aggregate with sum/avg/topk by the labels you need. Call get_current_time first when an absolute time is required.",
+		func(ctx context.Context, input *PrometheusMetricsInput, opts ...tool.Option) (output string, err error) {
+			out := runMetricsQuery(ctx, input, time.Now())
+			if out.Error != nil {
+				log.Printf("[warn] query prometheus metrics %q failed: %s", input.Query, out.Error.Message)
+			}
+			return marshalToolOutput(out), nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
+
+// promQueryData query 与 query_range 接口返回的 data 字段
+type promQueryData struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+// runMetricsQuery 校验参数并执行即时或区间查询，所有错误都以结构化结果返回
+func runMetricsQuery(ctx context.Context, input *PrometheusMetricsInput, now time.Time) *PrometheusMetricsOutput {
+	out := &PrometheusMetricsOutput{}
+	fail := func(code, format string, args ...any) *PrometheusMetricsOutput {
+		out.Error = &ToolError{Code: code, Message: fmt.Sprintf(format, args...)}
+		return out
+	}
+	if strings.TrimSpace(input.Query) == "" {
+		return fail(ErrCodeInvalidArgument, "query is required")
+	}
+	params := url.Values{"query": {input.Query}}
+	params.Set("timeout", GetPrometheus(ctx).Timeout.String())
+	path := "/api/v1/query"
+	if input.Start == "" {
+		if input.Time != "" {
+			at, err := parsePromTime(input.Time, now)
+			if err != nil {
+				return fail(ErrCodeInvalidArgument, "invalid time: %v", err)
+			}
+			params.Set("time", formatPromTime(at))
+		}
+	} else {
+		start, err := parsePromTime(input.Start, now)
+		if err != nil {
+			return fail(ErrCodeInvalidArgument, "invalid start: %v", err)
+		}
+		end := now
+		if input.End != "" {
+			if end, err = parsePromTime(input.End, now); err != nil {
+				return fail(ErrCodeInvalidArgument, "invalid end: %v", err)
+			}
+		}
+		if !end.After(start) {
+			return fail(ErrCodeInvalidArgument, "end must be after start")
+		}
+		step, err := rangeStep(input.Step, end.Sub(start))
+		if err != nil {
+			return fail(ErrCodeInvalidArgument, "%v", err)
+		}
+		path = "/api/v1/query_range"
+		params.Set("start", formatPromTime(start))
+		params.Set("end", formatPromTime(end))
+		params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+		out.Step = step.String()
+	}
+
+	var data promQueryData
+	if err := prometheusGet(ctx, path, params, &data); err != nil {
+		out.Error = toolErrorOf(err)
+		return out
+	}
+	out.ResultType = data.ResultType
+	maxSeries, maxSamples := metricsLimits(ctx)
+	var err error
+	switch data.ResultType {
+	case "matrix":
+		err = fillMatrix(out, data.Result, maxSeries, maxSamples)
+	case "vector":
+		err = fillVector(out, data.Result, maxSeries)
+	case "scalar", "string":
+		var v [2]any
+		if err = json.Unmarshal(data.Result, &v); err == nil {
+			out.Values = []MetricValue{{Time: formatSampleTime(v[0]), Value: fmt.Sprint(v[1])}}
+			out.Total = 1
+		}
+	default:
+		err = fmt.Errorf("unsupported result type %q", data.ResultType)
+	}
+	if err != nil {
+		return fail(ErrCodeServerUnavailable, "unexpected prometheus result: %v", err)
+	}
+	out.Success = true
+	return out
+}
+
+// rangeStep 解析或自动计算区间查询的步长，原始点数超出上限时返回错误
+func rangeStep(s string, span time.Duration) (time.Duration, error) {
+	if s == "" {
+		step := (span / DefaultRangePoints).Round(time.Second)
+		return max(step, minRangeStep), nil
+	}
+	step, err := parsePromDuration(s)
+	if err != nil || step <= 0 {
+		return 0, fmt.Errorf("invalid step %q, expected a duration such as 30s or 5m", s)
+	}
+	if span/step > MaxRangePoints {
+		return 0, fmt.Errorf("step %s is too small for a %s range, at most %d points per series are allowed", s, span, MaxRangePoints)
+	}
+	return step, nil
+}
+
+// parsePromTime 解析 RFC3339 时间、unix 秒或相对当前时间的 now-1h、-30m
+func parsePromTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "now" {
+		return now, nil
+	}
+	if rel, ok := strings.CutPrefix(s, "now"); ok {
+		s = rel
+	}
+	if strings.HasPrefix(s, "-") {
+		d, err := parsePromDuration(s[1:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported time %q", s)
+}
+
+// parsePromDuration 解析 Go 时长，额外支持 Prometheus 的 d 与 w 单位
+func parsePromDuration(s string) (time.Duration, error) {
+	for unit, d := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, unit); ok {
+			if v, err := strconv.ParseFloat(n, 64); err == nil {
+				return time.Duration(v * float64(d)), nil
+			}
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+func formatPromTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
+
+// formatSampleTime 将 Prometheus 返回的 unix 秒转换为 RFC3339
+func formatSampleTime(v any) string {
+	f, ok := v.(float64)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)).Format(time.RFC3339)
+}
+
+// fillVector 即时查询结果按值从大到小排列，只保留前 maxSeries 个
+func fillVector(out *PrometheusMetricsOutput, raw json.RawMessage, maxSeries int) error {
+	var result []struct {
+		Metric map[string]string `json:"metric"`
+		Value  [2]any            `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return err
+	}
+	out.Total = len(result)
+	sort.SliceStable(result, func(i, j int) bool {
+		return sortKey(fmt.Sprint(result[i].Value[1])) > sortKey(fmt.Sprint(result[j].Value[1]))
+	})
+	if len(result) > maxSeries {
+		result, out.Truncated = result[:maxSeries], true
+	}
+	for _, r := range result {
+		out.Values = append(out.Values, MetricValue{Metric: r.Metric, Time: formatSampleTime(r.Value[0]), Value: fmt.Sprint(r.Value[1])})
+	}
+	return nil
+}
+
+// fillMatrix 区间查询结果按最后一个值从大到小排列，只保留前 maxSeries 个，逐个汇总并降采样
+func fillMatrix(out *PrometheusMetricsOutput, raw json.RawMessage, maxSeries, maxSamples int) error {
+	var result []struct {
+		Metric map[string]string `json:"metric"`
+		Values [][2]any          `json:"values"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return err
+	}
+	type parsed struct {
+		metric  map[string]string
+		times   []float64
+		values  []float64
+		raw     int
+		lastVal float64
+	}
+	series := make([]parsed, 0, len(result))
+	for _, r := range result {
+		p := parsed{metric: r.Metric, raw: len(r.Values), lastVal: math.Inf(-1)}
+		for _, v := range r.Values {
+			ts, ok := v[0].(float64)
+			val := parseSampleValue(fmt.Sprint(v[1]))
+			if !ok || math.IsNaN(val) || math.IsInf(val, 0) {
+				continue
+			}
+			p.times = append(p.times, ts)
+			p.values = append(p.values, val)
+		}
+		if n := len(p.values); n > 0 {
+			p.lastVal = p.values[n-1]
+		}
+		series = append(series, p)
+	}
+	out.Total = len(series)
+	sort.SliceStable(series, func(i, j int) bool { return series[i].lastVal > series[j].lastVal })
+	if len(series) > maxSeries {
+		series, out.Truncated = series[:maxSeries], true
+	}
+	for _, p := range series {
+		s := MetricSeries{Metric: p.metric, Points: p.raw}
+		if len(p.values) > 0 {
+			times, values := downsample(p.times, p.values, maxSamples)
+			s.Summary = summarize(p.times, p.values)
+			for i := range times {
+				s.Samples = append(s.Samples, MetricSample{Time: formatSampleTime(times[i]), Value: round(values[i])})
+			}
+		}
+		out.Series = append(out.Series, s)
+	}
+	return nil
+}
+
+// sortKey 排序使用的值，NaN 排在最后
+func sortKey(s string) float64 {
+	v := parseSampleValue(s)
+	if math.IsNaN(v) {
+		return math.Inf(-1)
+	}
+	return v
+}
+
+// parseSampleValue 解析样本值，无法解析时视为 NaN
+func parseSampleValue(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return v
+}
+
+// downsample 将序列均分为 n 个桶，每个桶取均值，时间取桶内第一个点；点数不超过 n 时原样返回
+func downsample(times, values []float64, n int) ([]float64, []float64) {
+	if len(values) <= n {
+		return times, values
+	}
+	outT := make([]float64, 0, n)
+	outV := make([]float64, 0, n)
+	for b := 0; b < n; b++ {
+		lo, hi := b*len(values)/n, (b+1)*len(values)/n
+		if lo >= hi {
+			continue
+		}
+		sum := 0.0
+		for _, v := range values[lo:hi] {
+			sum += v
+		}
+		outT = append(outT, times[lo])
+		outV = append(outV, sum/float64(hi-lo))
+	}
+	return outT, outV
+}
+
+// summarize 在降采样前统计原始序列，趋势比较首末三分之一的均值
+func summarize(times, values []float64) *SeriesSummary {
+	s := &SeriesSummary{Min: math.Inf(1), Max: math.Inf(-1), Last: values[len(values)-1]}
+	sum := 0.0
+	for _, v := range values {
+		s.Min, s.Max = math.Min(s.Min, v), math.Max(s.Max, v)
+		sum += v
+	}
+	s.Avg = sum / float64(len(values))
+
+	third := max(len(values)/3, 1)
+	head, tail := mean(values[:third]), mean(values[len(values)-third:])
+	if head != 0 {
+		s.ChangeRate = (tail - head) / math.Abs(head)
+	}
+	s.Trend = "flat"
+	if scale := math.Max(math.Abs(head), s.Max-s.Min); scale > 0 && math.Abs(tail-head)/scale >= 0.1 {
+		s.Trend = "rising"
+		if tail < head {
+			s.Trend = "falling"
+		}
+	}
+	s.ChangePoints = changePoints(times, values)
+	s.Min, s.Max, s.Avg, s.Last, s.ChangeRate = round(s.Min), round(s.Max), round(s.Avg), round(s.Last), round(s.ChangeRate)
+	return s
+}
+
+// changePoints 相邻两点的变化量超过全部变化量均值加三倍标准差，且超过取值范围的 20% 时视为突变，
+// 按变化量保留最大的几个，再按时间排列
+func changePoints(times, values []float64) []ChangePoint {
+	if len(values) < 4 {
+		return nil
+	}
+	deltas := make([]float64, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		deltas[i-1] = math.Abs(values[i] - values[i-1])
+	}
+	mu := mean(deltas)
+	variance := 0.0
+	for _, d := range deltas {
+		variance += (d - mu) * (d - mu)
+	}
+	sigma := math.Sqrt(variance / float64(len(deltas)))
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	var idx []int
+	for i, d := range deltas {
+		if d > mu+3*sigma && d > 0.2*(hi-lo) {
+			idx = append(idx, i+1)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return deltas[idx[a]-1] > deltas[idx[b]-1] })
+	if len(idx) > maxChangePoints {
+		idx = idx[:maxChangePoints]
+	}
+	sort.Ints(idx)
+	out := make([]ChangePoint, 0, len(idx))
+	for _, i := range idx {
+		out = append(out, ChangePoint{Time: formatSampleTime(times[i]), Before: round(values[i-1]), After: round(values[i])})
+	}
+	return out
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// round 保留 4 位有效数字，减少输出长度
+func round(v float64) float64 {
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return v
+	}
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 4, 64), 64)
+	return r
+}
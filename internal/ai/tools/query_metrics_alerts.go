@@ -2,13 +2,12 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
-	"io"
 	"log"
-	"net/http"
+	"regexp"
+	"sort"
 	"time"
 )
 
@@ -21,66 +20,172 @@ type PrometheusAlert struct {
 	Value       string            `json:"value"`
 }
 
-// PrometheusAlertsResult 告警查询结果
-type PrometheusAlertsResult struct {
-	Status string `json:"status"`
-	Data   struct {
-		Alerts []PrometheusAlert `json:"alerts"`
-	} `json:"data"`
-	Error     string `json:"error,omitempty"`
-	ErrorType string `json:"errorType,omitempty"`
+// MaxAlertLabelValues 告警分组中每个标签最多列出的不同取值个数
+const MaxAlertLabelValues = 10
+
+// PrometheusAlertsInput 输入参数，全部条件同时满足的告警才会返回
+type PrometheusAlertsInput struct {
+	State     string   `json:"state,omitempty" jsonschema:"description=Only return alerts in this state: firing or pending. Leave empty for both"`
+	AlertName string   `json:"alertname,omitempty" jsonschema:"description=Regular expression that must match the whole alertname (for example HighCPU.*). Leave empty for all alerts"`
+	Matchers  []string `json:"matchers,omitempty" jsonschema:"description=PromQL style label matchers that must all match. Each is name=value or name!=value or name=~regex or name!~regex with the value optionally quoted (for example severity=critical or instance=~10.0.*)"`
 }
 
-// SimplifiedAlert 简化的告警信息
-type SimplifiedAlert struct {
-	AlertName   string `json:"alert_name" jsonschema:"description=告警名称，从 Prometheus 告警的 labels.alertname 字段提取"`
-	Description string `json:"description" jsonschema:"description=告警描述信息，从 Prometheus 告警的 annotations.description 字段提取"`
-	State       string `json:"state" jsonschema:"description=告警状态，通常为 'firing'（触发中）或 'pending'（待触发）"`
-	ActiveAt    string `json:"active_at" jsonschema:"description=告警激活时间，RFC3339 格式的时间戳，例如 '2025-10-29T08:48:42.496134755Z'"`
-	Duration    string `json:"duration" jsonschema:"description=告警持续时间，从激活时间到当前时间的时长，格式如 '2h30m15s'、'30m15s' 或 '15s'"`
+// AlertGroup 同一 alertname 的告警汇总，Labels 为各标签的不同取值
+type AlertGroup struct {
+	AlertName   string              `json:"alertname"`
+	Instances   int                 `json:"instances"`
+	Firing      int                 `json:"firing"`
+	Pending     int                 `json:"pending"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	ActiveSince string              `json:"active_since,omitempty"` // 最早激活的实例的激活时间
+	Duration    string              `json:"duration,omitempty"`
+	Labels      map[string][]string `json:"labels,omitempty"`
 }
 
 // PrometheusAlertsOutput 告警查询输出
 type PrometheusAlertsOutput struct {
-	Success bool              `json:"success" jsonschema:"description=查询是否成功"`
-	Alerts  []SimplifiedAlert `json:"alerts,omitempty" jsonschema:"description=活动告警列表，每个告警包含名称、描述、状态、激活时间和持续时间。相同 alertname 的告警只保留第一个"`
-	Message string            `json:"message,omitempty" jsonschema:"description=操作结果的状态消息"`
-	Error   string            `json:"error,omitempty" jsonschema:"description=如果查询失败，包含错误信息"`
+	Success bool         `json:"success"`
+	Total   int          `json:"total"` // 满足条件的告警实例数
+	Groups  []AlertGroup `json:"groups,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Error   *ToolError   `json:"error,omitempty"`
 }
 
-// queryPrometheusAlerts 查询Prometheus告警
-func queryPrometheusAlerts() (PrometheusAlertsResult, error) {
-	return PrometheusAlertsResult{}, nil
-	baseURL := "http://127.0.0.1:9090"
-	apiURL := fmt.Sprintf("%s/api/v1/alerts", baseURL)
+// queryPrometheusAlerts 查询 Prometheus 当前的 pending 与 firing 告警
+func queryPrometheusAlerts(ctx context.Context) ([]PrometheusAlert, error) {
+	var data struct {
+		Alerts []PrometheusAlert `json:"alerts"`
+	}
+	if err := prometheusGet(ctx, "/api/v1/alerts", nil, &data); err != nil {
+		return nil, err
+	}
+	return data.Alerts, nil
+}
 
-	log.Printf("Querying Prometheus alerts: %s", apiURL)
+// alertFilter 编译后的筛选条件
+type alertFilter struct {
+	state    string
+	name     *regexp.Regexp
+	matchers []*labelMatcher
+}
 
-	// 创建HTTP客户端
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// newAlertFilter 校验并编译筛选条件，参数错误时返回结构化错误，不请求 Prometheus
+func newAlertFilter(input *PrometheusAlertsInput) (*alertFilter, *ToolError) {
+	if input.State != "" && input.State != "firing" && input.State != "pending" {
+		return nil, &ToolError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("invalid state %q, expected firing or pending", input.State)}
 	}
-	var result PrometheusAlertsResult
-
-	// 发送请求
-	resp, err := client.Get(apiURL)
+	f := &alertFilter{state: input.State}
+	if input.AlertName != "" {
+		re, err := regexp.Compile("^(?:" + input.AlertName + ")$")
+		if err != nil {
+			return nil, &ToolError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("invalid alertname regex: %v", err)}
+		}
+		f.name = re
+	}
+	matchers, err := parseLabelMatchers(input.Matchers)
 	if err != nil {
-		return result, fmt.Errorf("failed to query Prometheus alerts: %v", err)
+		return nil, &ToolError{Code: ErrCodeInvalidArgument, Message: err.Error()}
 	}
-	defer resp.Body.Close()
+	f.matchers = matchers
+	return f, nil
+}
 
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return result, fmt.Errorf("failed to read response: %v", err)
+// Match 判断告警是否满足全部条件
+func (f *alertFilter) Match(a *PrometheusAlert) bool {
+	if f.state != "" && a.State != f.state {
+		return false
+	}
+	if f.name != nil && !f.name.MatchString(a.Labels["alertname"]) {
+		return false
 	}
+	for _, m := range f.matchers {
+		if !m.Matches(a.Labels) {
+			return false
+		}
+	}
+	return true
+}
 
-	// 解析JSON响应
-	if err = json.Unmarshal(body, &result); err != nil {
-		return result, fmt.Errorf("failed to parse response: %v", err)
+// groupAlerts 按 alertname 分组，保留实例数与各标签的不同取值
+// 触发中的分组排在前面，其次按实例数从多到少排列
+func groupAlerts(alerts []PrometheusAlert) []AlertGroup {
+	type groupState struct {
+		group    *AlertGroup
+		earliest time.Time
+		values   map[string]map[string]struct{}
+	}
+	states := map[string]*groupState{}
+	var order []string
+	for _, a := range alerts {
+		name := a.Labels["alertname"]
+		st, ok := states[name]
+		if !ok {
+			st = &groupState{group: &AlertGroup{AlertName: name}, values: map[string]map[string]struct{}{}}
+			states[name] = st
+			order = append(order, name)
+		}
+		g := st.group
+		g.Instances++
+		switch a.State {
+		case "firing":
+			g.Firing++
+		case "pending":
+			g.Pending++
+		}
+		if g.Summary == "" {
+			g.Summary = a.Annotations["summary"]
+		}
+		if g.Description == "" {
+			g.Description = a.Annotations["description"]
+		}
+		if activeAt, err := time.Parse(time.RFC3339Nano, a.ActiveAt); err == nil && (st.earliest.IsZero() || activeAt.Before(st.earliest)) {
+			st.earliest = activeAt
+			g.ActiveSince = a.ActiveAt
+			g.Duration = calculateDuration(a.ActiveAt)
+		}
+		for k, v := range a.Labels {
+			if k == "alertname" {
+				continue
+			}
+			if st.values[k] == nil {
+				st.values[k] = map[string]struct{}{}
+			}
+			st.values[k][v] = struct{}{}
+		}
+	}
+	groups := make([]AlertGroup, 0, len(order))
+	for _, name := range order {
+		st := states[name]
+		st.group.Labels = make(map[string][]string, len(st.values))
+		for k, set := range st.values {
+			st.group.Labels[k] = distinctValues(set)
+		}
+		groups = append(groups, *st.group)
 	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Firing > 0) != (groups[j].Firing > 0) {
+			return groups[i].Firing > 0
+		}
+		if groups[i].Instances != groups[j].Instances {
+			return groups[i].Instances > groups[j].Instances
+		}
+		return groups[i].AlertName < groups[j].AlertName
+	})
+	return groups
+}
 
-	return result, nil
+// distinctValues 排序后的不同取值，超过上限时只保留前 MaxAlertLabelValues 个并注明省略的个数
+func distinctValues(set map[string]struct{}) []string {
+	values := make([]string, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	if len(values) > MaxAlertLabelValues {
+		values = append(values[:MaxAlertLabelValues], fmt.Sprintf("...(%d more)", len(values)-MaxAlertLabelValues))
+	}
+	return values
 }
 
 // calculateDuration 计算从 activeAt 到现在的持续时间
@@ -108,66 +213,38 @@ func calculateDuration(activeAtStr string) string {
 	}
 }
 
-// NewPrometheusAlertsQueryTool 创建Prometheus告警查询工具
+// NewPrometheusAlertsQueryTool 创建Prometheus告警查询工具，地址与鉴权来自配置文件 prometheus 节点
 func NewPrometheusAlertsQueryTool() tool.InvokableTool {
 	t, err := utils.InferOptionableTool(
 		"query_prometheus_alerts",
-		"Query active alerts from Prometheus alerting system. This tool retrieves all currently active/firing alerts including their labels, annotations, state, and values. Use this tool when you need to check what alerts are currently firing, investigate alert conditions, or monitor alert status.",
-		func(ctx context.Context, input *struct{}, opts ...tool.Option) (output string, err error) {
+		"Query active (pending or firing) alerts from Prometheus. Alerts can be filtered by state, an alertname regex and PromQL style label matchers. Results are grouped by alertname with the number of firing and pending instances, the earliest activation time, the summary and description, and the distinct values of every label (such as instance, job and severity). Use this tool to check what is currently alerting before investigating an incident.",
+		func(ctx context.Context, input *PrometheusAlertsInput, opts ...tool.Option) (output string, err error) {
 			log.Printf("Querying Prometheus active alerts")
-
-			// 调用Prometheus Alerts API
-			result, err := queryPrometheusAlerts()
+			out := &PrometheusAlertsOutput{}
+			filter, toolErr := newAlertFilter(input)
+			if toolErr != nil {
+				out.Error = toolErr
+				return marshalToolOutput(out), nil
+			}
+			alerts, err := queryPrometheusAlerts(ctx)
 			if err != nil {
-				alertsOut := PrometheusAlertsOutput{
-					Success: false,
-					Error:   err.Error(),
-					Message: "Failed to query Prometheus alerts",
-				}
-				jsonBytes, _ := json.MarshalIndent(alertsOut, "", "  ")
-				return string(jsonBytes), err
+				log.Printf("[warn] query prometheus alerts failed: %v", err)
+				out.Error = toolErrorOf(err)
+				return marshalToolOutput(out), nil
 			}
-
-			// 转换为简化格式，对于相同的 alertname，只保留第一个
-			seenAlertNames := make(map[string]bool)
-			simplifiedAlerts := make([]SimplifiedAlert, 0)
-			for _, alert := range result.Data.Alerts {
-				alertName := alert.Labels["alertname"]
-
-				// 如果这个 alertname 已经存在，跳过
-				if seenAlertNames[alertName] {
-					continue
+			matched := make([]PrometheusAlert, 0, len(alerts))
+			for i := range alerts {
+				if filter.Match(&alerts[i]) {
+					matched = append(matched, alerts[i])
 				}
-
-				// 标记为已见过
-				seenAlertNames[alertName] = true
-
-				simplified := SimplifiedAlert{
-					AlertName:   alertName,
-					Description: alert.Annotations["description"],
-					State:       alert.State,
-					ActiveAt:    alert.ActiveAt,
-					Duration:    calculateDuration(alert.ActiveAt),
-				}
-				simplifiedAlerts = append(simplifiedAlerts, simplified)
-			}
-
-			// 构建成功响应
-			alertsOut := PrometheusAlertsOutput{
-				Success: true,
-				Alerts:  simplifiedAlerts,
-				Message: fmt.Sprintf("Successfully retrieved %d active alerts", len(simplifiedAlerts)),
 			}
-
-			// 转换为JSON
-			jsonBytes, err := json.MarshalIndent(alertsOut, "", "  ")
-			if err != nil {
-				log.Printf("Error marshaling alerts result to JSON: %v", err)
-				return "", err
-			}
-
-			log.Printf("Prometheus alerts query completed: %d alerts found", len(simplifiedAlerts))
-			return string(jsonBytes), nil
+			alerts = matched
+			out.Success = true
+			out.Total = len(alerts)
+			out.Groups = groupAlerts(alerts)
+			out.Message = fmt.Sprintf("Found %d matching alerts in %d groups", out.Total, len(out.Groups))
+			log.Printf("Prometheus alerts query completed: %d alerts in %d groups", out.Total, len(out.Groups))
+			return marshalToolOutput(out), nil
 		})
 	if err != nil {
 		log.Fatal(err)
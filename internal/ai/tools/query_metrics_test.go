@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func seq(n int, f func(i int) float64) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = f(i)
+	}
+	return out
+}
+
+func TestDownsample(t *testing.T) {
+	cases := []struct {
+		name       string
+		times      []float64
+		values     []float64
+		n          int
+		wantTimes  []float64
+		wantValues []float64
+	}{
+		{"fewer points than buckets", []float64{1, 2, 3}, []float64{10, 20, 30}, 5, []float64{1, 2, 3}, []float64{10, 20, 30}},
+		{"even buckets", []float64{1, 2, 3, 4, 5, 6}, []float64{1, 3, 5, 7, 9, 11}, 3, []float64{1, 3, 5}, []float64{2, 6, 10}},
+		{"uneven buckets", []float64{1, 2, 3, 4, 5}, []float64{1, 2, 3, 4, 5}, 2, []float64{1, 3}, []float64{1.5, 4}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			times, values := downsample(c.times, c.values, c.n)
+			if !reflect.DeepEqual(times, c.wantTimes) || !reflect.DeepEqual(values, c.wantValues) {
+				t.Errorf("downsample() = %v, %v, want %v, %v", times, values, c.wantTimes, c.wantValues)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	cases := []struct {
+		name           string
+		values         []float64
+		wantMin        float64
+		wantMax        float64
+		wantAvg        float64
+		wantLast       float64
+		wantTrend      string
+		wantChangeRate float64
+	}{
+		{"single point", []float64{5}, 5, 5, 5, 5, "flat", 0},
+		{"flat", []float64{10, 10.2, 9.9, 10.1, 10, 10}, 9.9, 10.2, 10.03, 10, "flat", -0.009901},
+		{"rising", []float64{10, 10, 15, 15, 20, 20}, 10, 20, 15, 20, "rising", 1},
+		{"falling", []float64{20, 20, 15, 15, 10, 10}, 10, 20, 15, 10, "falling", -0.5},
+		{"from zero", []float64{0, 0, 0, 5, 5, 5}, 0, 5, 2.5, 5, "rising", 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			times := seq(len(c.values), func(i int) float64 { return float64(1700000000 + 60*i) })
+			s := summarize(times, c.values)
+			if s.Min != c.wantMin || s.Max != c.wantMax || s.Avg != c.wantAvg || s.Last != c.wantLast {
+				t.Errorf("summarize() min/max/avg/last = %v/%v/%v/%v, want %v/%v/%v/%v", s.Min, s.Max, s.Avg, s.Last, c.wantMin, c.wantMax, c.wantAvg, c.wantLast)
+			}
+			if s.Trend != c.wantTrend || s.ChangeRate != c.wantChangeRate {
+				t.Errorf("summarize() trend = %s %v, want %s %v", s.Trend, s.ChangeRate, c.wantTrend, c.wantChangeRate)
+			}
+		})
+	}
+}
+
+func TestChangePoints(t *testing.T) {
+	times := seq(200, func(i int) float64 { return float64(1700000000 + 60*i) })
+	// 平稳序列中叠加小幅波动
+	steady := seq(40, func(i int) float64 { return 50 + float64(i%3) })
+	cases := []struct {
+		name      string
+		values    []float64
+		wantAt    []int // 突变后第一个点的下标
+		wantAfter []float64
+	}{
+		{"too few points", []float64{1, 100, 1}, nil, nil},
+		{"steady", steady, nil, nil},
+		{"single jump", seq(40, func(i int) float64 {
+			if i >= 20 {
+				return 90 + float64(i%3)
+			}
+			return 50 + float64(i%3)
+		}), []int{20}, []float64{90 + 20%3}},
+		{"spike up and down", seq(40, func(i int) float64 {
+			if i == 10 {
+				return 200
+			}
+			return 50 + float64(i%3)
+		}), []int{10, 11}, []float64{200, 50 + 11%3}},
+		{"keeps largest in time order", seq(200, func(i int) float64 {
+			switch {
+			case i < 40:
+				return 0
+			case i < 80:
+				return 100
+			case i < 120:
+				return 190
+			case i < 160:
+				return 270
+			default:
+				return 390
+			}
+		}), []int{40, 80, 160}, []float64{100, 190, 390}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := changePoints(times[:len(c.values)], c.values)
+			if len(got) != len(c.wantAt) {
+				t.Fatalf("changePoints() = %+v, want %d points", got, len(c.wantAt))
+			}
+			for i, p := range got {
+				if p.Time != formatSampleTime(times[c.wantAt[i]]) || p.After != c.wantAfter[i] {
+					t.Errorf("changePoints()[%d] = %+v, want at %s after %v", i, p, formatSampleTime(times[c.wantAt[i]]), c.wantAfter[i])
+				}
+			}
+		})
+	}
+}
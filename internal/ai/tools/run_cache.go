@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"sync"
+)
+
+// runCache 单次智能体运行内的工具结果缓存，同一次运行中重复的查询直接返回缓存结果
+type runCache struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+type runCacheKey struct{}
+
+// WithRunCache 为一次智能体运行挂载工具结果缓存，上下文中没有缓存时工具每次都重新查询
+func WithRunCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, runCacheKey{}, &runCache{data: map[string]string{}})
+}
+
+// cachedResult 读取缓存，未命中时执行 fn，只缓存成功的结果
+func cachedResult(ctx context.Context, key string, fn func() (string, bool)) string {
+	c, ok := ctx.Value(runCacheKey{}).(*runCache)
+	if !ok || c == nil {
+		out, _ := fn()
+		return out
+	}
+	c.mu.Lock()
+	out, hit := c.data[key]
+	c.mu.Unlock()
+	if hit {
+		return out
+	}
+	out, success := fn()
+	if success {
+		c.mu.Lock()
+		c.data[key] = out
+		c.mu.Unlock()
+	}
+	return out
+}
@@ -0,0 +1,16 @@
+package tools
+
+import "context"
+
+type sessionKey struct{}
+
+// WithSession 将对话的会话 ID 写入上下文，工具据此标记自己在外部系统中创建的对象
+func WithSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sessionID)
+}
+
+// SessionFromContext 读取上下文中的会话 ID，不在对话中调用时返回空字符串
+func SessionFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SQL 语句的类别
+const (
+	SQLRead  = "read"  // 只读查询：SELECT、SHOW、DESCRIBE、EXPLAIN 等
+	SQLWrite = "write" // 数据修改：INSERT、UPDATE、DELETE、REPLACE
+)
+
+var (
+	// ErrMultipleStatements 一次只能执行一条语句
+	ErrMultipleStatements = errors.New("only a single statement is allowed")
+	// ErrEmptyStatement 没有可执行的语句
+	ErrEmptyStatement = errors.New("empty statement")
+)
+
+// readKeywords 只读语句的起始关键字
+var readKeywords = map[string]bool{"SELECT": true, "WITH": true, "SHOW": true, "DESCRIBE": true, "DESC": true, "EXPLAIN": true, "TABLE": true, "VALUES": true}
+
+// writeKeywords 数据修改语句的起始关键字，DDL、权限、会话设置等其他语句一律拒绝
+var writeKeywords = map[string]bool{"INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true}
+
+// deniedFunctions 有副作用或可能长时间占用连接的函数，只读查询中也不允许调用
+var deniedFunctions = map[string]bool{"SLEEP": true, "BENCHMARK": true, "GET_LOCK": true, "RELEASE_LOCK": true, "RELEASE_ALL_LOCKS": true, "LOAD_FILE": true}
+
+// sqlToken 词法分析得到的单词或符号，字符串、注释与反引号标识符已被跳过
+type sqlToken struct {
+	text string // 单词统一为大写
+	word bool
+}
+
+// ClassifySQL 解析单条 SQL 并返回其类别，多条语句、注释中的可执行代码、导出文件、加锁读与不支持的语句返回错误
+func ClassifySQL(query string) (string, error) {
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return "", err
+	}
+	// 去掉末尾的分号，之后不能再出现分号
+	for len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	for _, t := range tokens {
+		if t.text == ";" {
+			return "", ErrMultipleStatements
+		}
+	}
+	// 跳过包裹查询的括号，如 (SELECT ...) UNION (SELECT ...)
+	first := 0
+	for first < len(tokens) && tokens[first].text == "(" {
+		first++
+	}
+	if first == len(tokens) {
+		return "", ErrEmptyStatement
+	}
+	keyword := tokens[first].text
+	for i, t := range tokens {
+		if t.word && deniedFunctions[t.text] && i+1 < len(tokens) && tokens[i+1].text == "(" {
+			return "", fmt.Errorf("function %s is not allowed", t.text)
+		}
+	}
+	switch {
+	case writeKeywords[keyword]:
+		return SQLWrite, nil
+	case readKeywords[keyword]:
+		for i, t := range tokens {
+			if !t.word {
+				continue
+			}
+			switch t.text {
+			case "INTO":
+				// SELECT ... INTO OUTFILE / DUMPFILE / @var
+				return "", errors.New("SELECT ... INTO is not allowed")
+			case "LOCK":
+				// LOCK IN SHARE MODE
+				return "", errors.New("locking reads are not allowed")
+			case "FOR":
+				if i+1 < len(tokens) && (tokens[i+1].text == "UPDATE" || tokens[i+1].text == "SHARE") {
+					return "", errors.New("locking reads are not allowed")
+				}
+			}
+			// WITH ... UPDATE / DELETE 等公用表表达式之后的修改语句
+			if keyword == "WITH" && writeKeywords[t.text] {
+				return SQLWrite, nil
+			}
+		}
+		return SQLRead, nil
+	default:
+		return "", fmt.Errorf("statement %s is not allowed", keyword)
+	}
+}
+
+// tokenizeSQL 按 MySQL 词法切分单词与符号，跳过字符串、反引号标识符与注释，/*! */ 可执行注释视为错误
+func tokenizeSQL(query string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	s := query
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case c == '#' || (c == '-' && strings.HasPrefix(s[i:], "--") && (i+2 == len(s) || strings.ContainsRune(" \t\r\n", rune(s[i+2])))):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return tokens, nil
+			}
+			i += end + 1
+		case strings.HasPrefix(s[i:], "/*"):
+			if strings.HasPrefix(s[i:], "/*!") {
+				return nil, errors.New("executable comments are not allowed")
+			}
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.New("unterminated comment")
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			end, err := skipQuoted(s, i)
+			if err != nil {
+				return nil, err
+			}
+			// 字符串与标识符作为一个非单词记号，保留其位置
+			tokens = append(tokens, sqlToken{text: string(c)})
+			i = end
+		case isWordByte(c):
+			start := i
+			for i < len(s) && isWordByte(s[i]) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{text: strings.ToUpper(s[start:i]), word: true})
+		default:
+			tokens = append(tokens, sqlToken{text: string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// skipQuoted 返回以 s[start] 为引号的字符串结束后的位置，支持反斜杠转义与连续两个引号的转义
+func skipQuoted(s string, start int) (int, error) {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote != '`':
+			i++
+		case s[i] == quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+	return 0, errors.New("unterminated quoted string")
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c == '@' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifySQL(t *testing.T) {
+	cases := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr error // 为 nil 且 want 为空时只要求返回错误
+	}{
+		{"select", "SELECT * FROM users WHERE id = 1", SQLRead, nil},
+		{"lowercase with trailing semicolons", "select 1;;", SQLRead, nil},
+		{"show", "SHOW TABLES", SQLRead, nil},
+		{"describe", "desc users", SQLRead, nil},
+		{"explain", "EXPLAIN SELECT * FROM users", SQLRead, nil},
+		{"parenthesized union", "(SELECT 1) UNION (SELECT 2)", SQLRead, nil},
+		{"cte select", "WITH t AS (SELECT 1) SELECT * FROM t", SQLRead, nil},
+		{"keywords inside string", "SELECT * FROM logs WHERE msg = 'DELETE FROM users; DROP TABLE x'", SQLRead, nil},
+		{"keywords inside identifier", "SELECT `update` FROM t", SQLRead, nil},
+		{"keywords inside comment", "SELECT 1 -- ; DROP TABLE users", SQLRead, nil},
+		{"insert", "INSERT INTO users (name) VALUES ('a')", SQLWrite, nil},
+		{"update", "update users set name = 'b' where id = 1", SQLWrite, nil},
+		{"delete", "DELETE FROM users WHERE id = 1", SQLWrite, nil},
+		{"replace", "REPLACE INTO users VALUES (1)", SQLWrite, nil},
+		{"cte update", "WITH t AS (SELECT 1) UPDATE users SET a = 1", SQLWrite, nil},
+		{"empty", "  ;", "", ErrEmptyStatement},
+		{"only comment", "/* nothing */", "", ErrEmptyStatement},
+		{"multiple statements", "SELECT 1; DROP TABLE users", "", ErrMultipleStatements},
+		{"drop", "DROP TABLE users", "", nil},
+		{"set", "SET GLOBAL max_connections = 1", "", nil},
+		{"call", "CALL cleanup()", "", nil},
+		{"executable comment", "SELECT /*!50000 SLEEP(10) */ 1", "", nil},
+		{"unterminated comment", "SELECT 1 /* x", "", nil},
+		{"unterminated string", "SELECT 'abc", "", nil},
+		{"select into outfile", "SELECT * FROM users INTO OUTFILE '/tmp/x'", "", nil},
+		{"for update", "SELECT * FROM users FOR UPDATE", "", nil},
+		{"lock in share mode", "SELECT * FROM users LOCK IN SHARE MODE", "", nil},
+		{"sleep", "SELECT sleep (5)", "", nil},
+		{"benchmark", "SELECT BENCHMARK(1000000, MD5('a'))", "", nil},
+		{"sleep column allowed", "SELECT sleep FROM t", SQLRead, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ClassifySQL(c.query)
+			if c.want != "" {
+				if err != nil || got != c.want {
+					t.Errorf("ClassifySQL(%q) = %q, %v, want %q", c.query, got, err, c.want)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ClassifySQL(%q) = %q, want error", c.query, got)
+			}
+			if c.wantErr != nil && !errors.Is(err, c.wantErr) {
+				t.Errorf("ClassifySQL(%q) error = %v, want %v", c.query, err, c.wantErr)
+			}
+		})
+	}
+}
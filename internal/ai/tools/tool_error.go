@@ -0,0 +1,30 @@
+package tools
+
+import "encoding/json"
+
+// 工具返回给模型的错误码
+const (
+	ErrCodeInvalidArgument   = "invalid_argument"       // 参数缺失或格式错误
+	ErrCodeUnknownDatasource = "unknown_datasource"     // 数据源未配置
+	ErrCodeForbidden         = "forbidden_statement"    // 语句不在允许范围内
+	ErrCodeReadOnly          = "read_only"              // 只读数据源不允许修改数据
+	ErrCodeTimeout           = "timeout"                // 语句执行超时
+	ErrCodeUnavailable       = "datasource_unavailable" // 无法连接数据源
+	ErrCodeExecution         = "execution_error"        // 数据库返回的执行错误
+	ErrCodeServerUnavailable = "server_unavailable"     // 外部服务调用失败
+)
+
+// ToolError 返回给模型的结构化错误，模型可据此修正参数后重试
+type ToolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// marshalToolOutput 序列化工具输出，失败时返回结构化错误
+func marshalToolOutput(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(map[string]any{"success": false, "error": &ToolError{Code: ErrCodeExecution, Message: err.Error()}})
+	}
+	return string(b)
+}
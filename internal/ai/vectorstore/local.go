@@ -0,0 +1,219 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// localRecord 本地存储的单条文档
+type localRecord struct {
+	ID       string         `json:"id"`
+	Content  string         `json:"content"`
+	MetaData map[string]any `json:"metadata"`
+	Vector   []float32      `json:"vector"`
+}
+
+// LocalStore 内嵌的纯 Go 向量存储，数据保存在内存中，配置路径后每次写入都会落盘
+// 检索采用暴力计算余弦相似度，适用于本地开发与 CI 等小规模场景
+type LocalStore struct {
+	mu      sync.RWMutex
+	path    string
+	records []*localRecord
+	index   map[string]int // 文档 ID 到 records 下标
+}
+
+// NewLocalStore 创建本地向量存储，path 为空时仅保存在内存中，否则从该文件加载已有数据
+func NewLocalStore(path string) (*LocalStore, error) {
+	s := &LocalStore{
+		path:  path,
+		index: map[string]int{},
+	}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local vector store %s: %w", path, err)
+	}
+	if err = json.Unmarshal(data, &s.records); err != nil {
+		return nil, fmt.Errorf("failed to parse local vector store %s: %w", path, err)
+	}
+	for i, r := range s.records {
+		s.index[r.ID] = i
+	}
+	return s, nil
+}
+
+// Insert 写入文档及其向量，ID 已存在时覆盖
+func (s *LocalStore) Insert(ctx context.Context, docs []*schema.Document, vectors [][]float64) ([]string, error) {
+	if len(docs) != len(vectors) {
+		return nil, fmt.Errorf("documents and vectors length not match: %d != %d", len(docs), len(vectors))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(docs))
+	for i, doc := range docs {
+		if doc.ID == "" {
+			doc.ID = uuid.New().String()
+		}
+		vector := make([]float32, len(vectors[i]))
+		for j, v := range vectors[i] {
+			vector[j] = float32(v)
+		}
+		record := &localRecord{
+			ID:       doc.ID,
+			Content:  doc.Content,
+			MetaData: CopyMetadata(doc.MetaData),
+			Vector:   vector,
+		}
+		if idx, ok := s.index[doc.ID]; ok {
+			s.records[idx] = record
+		} else {
+			s.index[doc.ID] = len(s.records)
+			s.records = append(s.records, record)
+		}
+		ids = append(ids, doc.ID)
+	}
+	return ids, s.persist()
+}
+
+// DeleteByFilter 删除元数据满足过滤条件的文档
+func (s *LocalStore) DeleteByFilter(ctx context.Context, filter Filter) (int, error) {
+	if len(filter) == 0 {
+		return 0, fmt.Errorf("delete filter must not be empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.records[:0]
+	deleted := 0
+	for _, r := range s.records {
+		if filter.Match(r.MetaData) {
+			deleted++
+			continue
+		}
+		kept = append(kept, r)
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+	s.records = kept
+	s.index = make(map[string]int, len(kept))
+	for i, r := range kept {
+		s.index[r.ID] = i
+	}
+	return deleted, s.persist()
+}
+
+// QueryByMetadata 查询元数据满足过滤条件的文档
+func (s *LocalStore) QueryByMetadata(ctx context.Context, filter Filter, limit int) ([]*schema.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	docs := make([]*schema.Document, 0)
+	for _, r := range s.records {
+		if !filter.Match(r.MetaData) {
+			continue
+		}
+		docs = append(docs, r.toDocument())
+		if limit > 0 && len(docs) >= limit {
+			break
+		}
+	}
+	return docs, nil
+}
+
+// Search 按余弦相似度检索文档
+func (s *LocalStore) Search(ctx context.Context, vector []float64, opts *SearchOptions) ([]*schema.Document, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	topK := opts.TopK
+	if topK <= 0 {
+		topK = 1
+	}
+	type hit struct {
+		record *localRecord
+		score  float64
+	}
+	s.mu.RLock()
+	hits := make([]hit, 0, len(s.records))
+	for _, r := range s.records {
+		if !opts.Filter.Match(r.MetaData) {
+			continue
+		}
+		hits = append(hits, hit{record: r, score: cosine(vector, r.Vector)})
+	}
+	s.mu.RUnlock()
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+	if len(hits) > topK {
+		hits = hits[:topK]
+	}
+	docs := make([]*schema.Document, 0, len(hits))
+	for _, h := range hits {
+		docs = append(docs, h.record.toDocument().WithScore(h.score))
+	}
+	return docs, nil
+}
+
+// Close 本地存储每次写入都已落盘，无需额外处理
+func (s *LocalStore) Close() error {
+	return nil
+}
+
+// persist 将数据原子地写入文件，调用方需持有写锁
+func (s *LocalStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal local vector store: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create local vector store dir: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write local vector store: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (r *localRecord) toDocument() *schema.Document {
+	return &schema.Document{
+		ID:       r.ID,
+		Content:  r.Content,
+		MetaData: CopyMetadata(r.MetaData),
+	}
+}
+
+// cosine 计算余弦相似度
+func cosine(a []float64, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		bv := float64(b[i])
+		dot += a[i] * bv
+		na += a[i] * a[i]
+		nb += bv * bv
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,131 @@
+package vectorstore
+
+import (
+	"context"
+	"github.com/cloudwego/eino/schema"
+	"path/filepath"
+	"testing"
+)
+
+func newTestStore(t *testing.T, path string) *LocalStore {
+	t.Helper()
+	s, err := NewLocalStore(path)
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	docs := []*schema.Document{
+		{ID: "a1", Content: "磁盘告警", MetaData: map[string]any{"_source": "a.md", "chunk_index": 0}},
+		{ID: "a2", Content: "磁盘清理", MetaData: map[string]any{"_source": "a.md", "chunk_index": 1}},
+		{ID: "b1", Content: "CPU 告警", MetaData: map[string]any{"_source": "b.md", "chunk_index": 0}},
+	}
+	vectors := [][]float64{{1, 0}, {0.8, 0.6}, {0, 1}}
+	if _, err = s.Insert(context.Background(), docs, vectors); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	return s
+}
+
+func TestLocalStoreSearch(t *testing.T) {
+	s := newTestStore(t, "")
+	cases := []struct {
+		name    string
+		vector  []float64
+		opts    *SearchOptions
+		wantIDs []string
+	}{
+		{"default top k", []float64{1, 0}, nil, []string{"a1"}},
+		{"ordered by similarity", []float64{1, 0}, &SearchOptions{TopK: 3}, []string{"a1", "a2", "b1"}},
+		{"filtered", []float64{1, 0}, &SearchOptions{TopK: 3, Filter: Filter{"_source": "b.md"}}, []string{"b1"}},
+		{"dimension mismatch scores zero", []float64{1, 0, 0}, &SearchOptions{TopK: 1}, []string{"a1"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			docs, err := s.Search(context.Background(), c.vector, c.opts)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(docs) != len(c.wantIDs) {
+				t.Fatalf("Search() returned %d docs, want %d", len(docs), len(c.wantIDs))
+			}
+			for i, doc := range docs {
+				if doc.ID != c.wantIDs[i] {
+					t.Errorf("docs[%d].ID = %s, want %s", i, doc.ID, c.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLocalStoreInsert(t *testing.T) {
+	s := newTestStore(t, "")
+	ctx := context.Background()
+	if _, err := s.Insert(ctx, []*schema.Document{{ID: "x"}}, nil); err == nil {
+		t.Error("Insert() with mismatched vectors should fail")
+	}
+	// 相同 ID 覆盖原有文档，ID 为空时自动生成
+	docs := []*schema.Document{{ID: "a1", Content: "磁盘告警处理"}, {Content: "新文档"}}
+	ids, err := s.Insert(ctx, docs, [][]float64{{1, 0}, {0, 1}})
+	if err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "a1" || ids[1] == "" {
+		t.Fatalf("Insert() ids = %v", ids)
+	}
+	got, _ := s.QueryByMetadata(ctx, nil, 0)
+	if len(got) != 4 {
+		t.Fatalf("store has %d docs, want 4", len(got))
+	}
+	if got[0].ID != "a1" || got[0].Content != "磁盘告警处理" {
+		t.Errorf("overwritten doc = %s %q", got[0].ID, got[0].Content)
+	}
+	// 返回的元数据是副本，修改不影响存储
+	got[1].MetaData["_source"] = "changed.md"
+	again, _ := s.QueryByMetadata(ctx, Filter{"_source": "a.md"}, 0)
+	if len(again) != 1 || again[0].ID != "a2" {
+		t.Errorf("QueryByMetadata() after modifying result = %v", again)
+	}
+}
+
+func TestLocalStoreDeleteByFilter(t *testing.T) {
+	cases := []struct {
+		name        string
+		filter      Filter
+		wantErr     bool
+		wantDeleted int
+		wantLeft    []string
+	}{
+		{"empty filter", nil, true, 0, []string{"a1", "a2", "b1"}},
+		{"by source", Filter{"_source": "a.md"}, false, 2, []string{"b1"}},
+		{"json number", Filter{"chunk_index": float64(0)}, false, 2, []string{"a2"}},
+		{"no match", Filter{"_source": "c.md"}, false, 0, []string{"a1", "a2", "b1"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			s := newTestStore(t, path)
+			deleted, err := s.DeleteByFilter(context.Background(), c.filter)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("DeleteByFilter() error = %v, wantErr %v", err, c.wantErr)
+			}
+			if deleted != c.wantDeleted {
+				t.Errorf("DeleteByFilter() = %d, want %d", deleted, c.wantDeleted)
+			}
+			// 删除后的数据已落盘，重新加载与内存中一致
+			reloaded, err := NewLocalStore(path)
+			if err != nil {
+				t.Fatalf("NewLocalStore() error = %v", err)
+			}
+			for _, store := range []*LocalStore{s, reloaded} {
+				docs, _ := store.QueryByMetadata(context.Background(), nil, 0)
+				if len(docs) != len(c.wantLeft) {
+					t.Fatalf("store has %d docs, want %d", len(docs), len(c.wantLeft))
+				}
+				for i, doc := range docs {
+					if doc.ID != c.wantLeft[i] {
+						t.Errorf("docs[%d].ID = %s, want %s", i, doc.ID, c.wantLeft[i])
+					}
+				}
+			}
+		})
+	}
+}
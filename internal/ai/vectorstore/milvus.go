@@ -0,0 +1,293 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/NuyoahCh/eocall/utility/client"
+	"github.com/NuyoahCh/eocall/utility/common"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	cli "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// milvusOutputFields 查询与检索时返回的字段
+var milvusOutputFields = []string{"id", "content", "metadata"}
+
+// countField 统计文档数的输出字段
+const countField = "count(*)"
+
+// milvusRow 写入 Milvus 的行数据，字段与 collection schema 对应
+type milvusRow struct {
+	ID       string `json:"id" milvus:"name:id"`
+	Content  string `json:"content" milvus:"name:content"`
+	Vector   []byte `json:"vector" milvus:"name:vector"`
+	Metadata []byte `json:"metadata" milvus:"name:metadata"`
+}
+
+// defaultPartition Milvus collection 的默认分区
+const defaultPartition = "_default"
+
+// MilvusStore 基于 Milvus 的向量存储，向量以 float32 位串的二进制向量存储并按 HAMMING 距离检索
+// 每个 MilvusStore 只读写 collection 中的一个分区
+type MilvusStore struct {
+	cli        cli.Client
+	collection string
+	partition  string
+}
+
+// NewMilvusStore 使用共享的 Milvus 客户端创建指定分区的向量存储，分区不存在时自动创建并加载
+func NewMilvusStore(ctx context.Context, partition string) (*MilvusStore, error) {
+	c, err := client.GetMilvusClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := client.GetMilvusConfig(ctx).Collection
+	if partition == "" {
+		partition = defaultPartition
+	}
+	exists, err := c.HasPartition(ctx, collection, partition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check partition %s: %w", partition, err)
+	}
+	if !exists {
+		if err = c.CreatePartition(ctx, collection, partition); err != nil {
+			return nil, fmt.Errorf("failed to create partition %s: %w", partition, err)
+		}
+	}
+	state, err := c.GetLoadState(ctx, collection, []string{partition})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get load state of partition %s: %w", partition, err)
+	}
+	if state == entity.LoadStateNotLoad {
+		if err = c.LoadPartitions(ctx, collection, []string{partition}, false); err != nil {
+			return nil, fmt.Errorf("failed to load partition %s: %w", partition, err)
+		}
+	}
+	return &MilvusStore{cli: c, collection: collection, partition: partition}, nil
+}
+
+// Insert 写入文档及其向量
+func (s *MilvusStore) Insert(ctx context.Context, docs []*schema.Document, vectors [][]float64) ([]string, error) {
+	if len(docs) != len(vectors) {
+		return nil, fmt.Errorf("documents and vectors length not match: %d != %d", len(docs), len(vectors))
+	}
+	if len(docs) == 0 {
+		return []string{}, nil
+	}
+	rows := make([]interface{}, 0, len(docs))
+	for i, doc := range docs {
+		if doc.ID == "" {
+			doc.ID = uuid.New().String()
+		}
+		metadata, err := json.Marshal(doc.MetaData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		rows = append(rows, &milvusRow{
+			ID:       doc.ID,
+			Content:  doc.Content,
+			Vector:   vectorToBytes(vectors[i]),
+			Metadata: metadata,
+		})
+	}
+	result, err := s.cli.InsertRows(ctx, s.collection, s.partition, rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert rows: %w", err)
+	}
+	// 刷盘保证写入后立即可查
+	if err = s.cli.Flush(ctx, s.collection, false); err != nil {
+		return nil, fmt.Errorf("failed to flush collection: %w", err)
+	}
+	ids := make([]string, result.Len())
+	for i := range ids {
+		if ids[i], err = result.GetAsString(i); err != nil {
+			return nil, fmt.Errorf("failed to get id: %w", err)
+		}
+	}
+	return ids, nil
+}
+
+// DeleteByFilter 删除元数据满足过滤条件的文档
+// 直接按过滤表达式删除，不先查询 ID，避免查询结果受单次返回条数限制而漏删；删除数量由 count(*) 统计
+func (s *MilvusStore) DeleteByFilter(ctx context.Context, filter Filter) (int, error) {
+	if len(filter) == 0 {
+		return 0, fmt.Errorf("delete filter must not be empty")
+	}
+	expr := filterExpr(filter)
+	n, err := s.count(ctx, expr)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	if err = s.cli.Delete(ctx, s.collection, s.partition, expr); err != nil {
+		return 0, fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return n, nil
+}
+
+// count 统计满足表达式的文档数
+func (s *MilvusStore) count(ctx context.Context, expr string) (int, error) {
+	resultSet, err := s.cli.Query(ctx, s.collection, []string{s.partition}, expr, []string{countField})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	column, ok := resultSet.GetColumn(countField).(*entity.ColumnInt64)
+	if !ok || column.Len() == 0 {
+		return 0, fmt.Errorf("unexpected result of %s", countField)
+	}
+	return int(column.Data()[0]), nil
+}
+
+// QueryByMetadata 查询元数据满足过滤条件的文档
+func (s *MilvusStore) QueryByMetadata(ctx context.Context, filter Filter, limit int) ([]*schema.Document, error) {
+	expr := filterExpr(filter)
+	if expr == "" {
+		expr = `id != ""`
+	}
+	var opts []cli.SearchQueryOptionFunc
+	if limit > 0 {
+		opts = append(opts, cli.WithLimit(int64(limit)))
+	}
+	resultSet, err := s.cli.Query(ctx, s.collection, []string{s.partition}, expr, milvusOutputFields, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	return columnsToDocuments(resultSet, resultSet.GetColumn("id"), nil, 0)
+}
+
+// Search 按向量相似度检索文档，分值为归一化后的 HAMMING 相似度 1 - distance/bits
+func (s *MilvusStore) Search(ctx context.Context, vector []float64, opts *SearchOptions) ([]*schema.Document, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	topK := opts.TopK
+	if topK <= 0 {
+		topK = 1
+	}
+	level := opts.Level
+	if level <= 0 {
+		level = 1
+	}
+	sp, err := entity.NewIndexAUTOINDEXSearchParam(level)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.cli.Search(ctx, s.collection, []string{s.partition}, filterExpr(opts.Filter), milvusOutputFields,
+		[]entity.Vector{entity.BinaryVector(vectorToBytes(vector))}, "vector", entity.HAMMING, topK, sp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	bits := len(vector) * common.BinaryVectorBitsPerDim
+	docs := make([]*schema.Document, 0, topK)
+	for _, result := range results {
+		if result.Err != nil {
+			return nil, fmt.Errorf("search result has error: %w", result.Err)
+		}
+		if result.IDs == nil {
+			continue
+		}
+		part, err := columnsToDocuments(result.Fields, result.IDs, result.Scores, bits)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, part...)
+	}
+	return docs, nil
+}
+
+// Close 共享的 Milvus 客户端由 client.CloseMilvusClient 统一关闭，这里无需处理
+func (s *MilvusStore) Close() error {
+	return nil
+}
+
+// columnsToDocuments 将查询结果转换为文档，scores 不为空时按 HAMMING 距离换算相似度
+func columnsToDocuments(fields cli.ResultSet, ids entity.Column, scores []float32, bits int) ([]*schema.Document, error) {
+	if ids == nil {
+		return []*schema.Document{}, nil
+	}
+	docs := make([]*schema.Document, ids.Len())
+	for i := range docs {
+		id, err := ids.GetAsString(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get id: %w", err)
+		}
+		docs[i] = &schema.Document{ID: id, MetaData: map[string]any{}}
+	}
+	if content := fields.GetColumn("content"); content != nil {
+		for i, doc := range docs {
+			text, err := content.GetAsString(i)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get content: %w", err)
+			}
+			doc.Content = text
+		}
+	}
+	if metadata := fields.GetColumn("metadata"); metadata != nil {
+		for i, doc := range docs {
+			v, err := metadata.Get(i)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get metadata: %w", err)
+			}
+			raw, ok := v.([]byte)
+			if !ok || len(raw) == 0 {
+				continue
+			}
+			if err = json.Unmarshal(raw, &doc.MetaData); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+	}
+	for i := 0; i < len(scores) && i < len(docs) && bits > 0; i++ {
+		docs[i].WithScore(1 - float64(scores[i])/float64(bits))
+	}
+	return docs, nil
+}
+
+// filterExpr 将元数据过滤条件转换为 Milvus 表达式
+func filterExpr(filter Filter) string {
+	if len(filter) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(filter))
+	for k := range filter {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf(`metadata[%s] == %s`, strconv.Quote(k), exprLiteral(filter[k])))
+	}
+	return strings.Join(parts, " && ")
+}
+
+// exprLiteral 将过滤值转换为 Milvus 表达式字面量
+func exprLiteral(v any) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case bool:
+		return strconv.FormatBool(val)
+	case int, int32, int64, float32, float64:
+		return fmt.Sprint(val)
+	default:
+		return strconv.Quote(fmt.Sprint(val))
+	}
+}
+
+// vectorToBytes 将向量按 float32 小端序转换为二进制向量
+func vectorToBytes(vector []float64) []byte {
+	bytes := make([]byte, len(vector)*4)
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(bytes[i*4:], math.Float32bits(float32(v)))
+	}
+	return bytes
+}
@@ -0,0 +1,179 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// 向量存储后端类型
+const (
+	TypeMilvus = "milvus" // Milvus 向量数据库
+	TypeLocal  = "local"  // 内嵌的纯 Go 实现，用于本地开发与 CI
+)
+
+// Filter 元数据过滤条件，键为 metadata 中的字段名，值需完全相等，多个条件之间为且的关系
+type Filter map[string]any
+
+// Match 判断元数据是否满足全部过滤条件，数值统一按 float64 比较以兼容 JSON 反序列化的结果
+func (f Filter) Match(metadata map[string]any) bool {
+	for k, want := range f {
+		got, ok := metadata[k]
+		if !ok {
+			return false
+		}
+		if reflect.DeepEqual(got, want) {
+			continue
+		}
+		gf, gok := ToFloat(got)
+		wf, wok := ToFloat(want)
+		if !gok || !wok || gf != wf {
+			return false
+		}
+	}
+	return true
+}
+
+// ToFloat 将元数据中的数值转换为 float64，JSON 反序列化与 Milvus 读取得到的数值类型不同
+func ToFloat(v any) (float64, bool) {
+	switch val := v.(type) {
+	case int:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case float32:
+		return float64(val), true
+	case float64:
+		return val, true
+	default:
+		return 0, false
+	}
+}
+
+// CopyMetadata 浅拷贝元数据，避免调用方修改存储中保存的文档
+func CopyMetadata(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// SearchOptions 相似度检索参数
+type SearchOptions struct {
+	TopK   int    // 返回的文档数量
+	Filter Filter // 元数据过滤条件，为空时不过滤
+	Level  int    // 检索精度，仅 Milvus AUTOINDEX 使用，取值 1~5，为 0 时使用 1
+}
+
+// Store 向量存储接口
+// 检索结果通过 schema.Document 的 Score 返回相似度，分值越大越相似
+type Store interface {
+	// Insert 写入文档及其向量，文档 ID 为空时自动生成，返回写入的文档 ID
+	Insert(ctx context.Context, docs []*schema.Document, vectors [][]float64) ([]string, error)
+	// DeleteByFilter 删除元数据满足过滤条件的文档，返回删除的数量
+	DeleteByFilter(ctx context.Context, filter Filter) (int, error)
+	// QueryByMetadata 查询元数据满足过滤条件的文档，limit <= 0 时不限制数量
+	QueryByMetadata(ctx context.Context, filter Filter, limit int) ([]*schema.Document, error)
+	// Search 按向量相似度检索文档
+	Search(ctx context.Context, vector []float64, opts *SearchOptions) ([]*schema.Document, error)
+	// Close 释放存储占用的资源
+	Close() error
+}
+
+// DefaultKnowledgeBase 默认知识库，对应 Milvus collection 的默认分区
+const DefaultKnowledgeBase = "default"
+
+var (
+	mu     sync.Mutex
+	stores = map[string]Store{}
+)
+
+type knowledgeBaseKey struct{}
+
+// WithKnowledgeBase 将本次请求使用的知识库写入上下文，检索与索引组件据此选择存储
+func WithKnowledgeBase(ctx context.Context, kb string) context.Context {
+	return context.WithValue(ctx, knowledgeBaseKey{}, kb)
+}
+
+// KnowledgeBaseFromContext 读取上下文中的知识库，未设置时返回默认知识库
+func KnowledgeBaseFromContext(ctx context.Context) string {
+	if kb, ok := ctx.Value(knowledgeBaseKey{}).(string); ok && kb != "" {
+		return kb
+	}
+	return DefaultKnowledgeBase
+}
+
+// GetStore 获取知识库对应的进程级共享向量存储，后端由配置 vector_store.type 决定，默认为 milvus
+// 每个知识库在 Milvus 中对应一个分区，在本地存储中对应一个独立文件，首次获取时自动创建
+func GetStore(ctx context.Context, kb string) (Store, error) {
+	if kb == "" {
+		kb = DefaultKnowledgeBase
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if s, ok := stores[kb]; ok {
+		return s, nil
+	}
+	s, err := newStore(ctx, kb)
+	if err != nil {
+		return nil, err
+	}
+	stores[kb] = s
+	return s, nil
+}
+
+// GetContextStore 获取上下文中知识库对应的向量存储
+func GetContextStore(ctx context.Context) (Store, error) {
+	return GetStore(ctx, KnowledgeBaseFromContext(ctx))
+}
+
+// CloseStore 关闭全部共享的向量存储，服务退出时调用
+func CloseStore() error {
+	mu.Lock()
+	defer mu.Unlock()
+	var firstErr error
+	for kb, s := range stores {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(stores, kb)
+	}
+	return firstErr
+}
+
+// StoreType 获取配置的向量存储后端类型
+func StoreType(ctx context.Context) string {
+	return g.Cfg().MustGet(ctx, "vector_store.type", TypeMilvus).String()
+}
+
+func newStore(ctx context.Context, kb string) (Store, error) {
+	switch typ := StoreType(ctx); typ {
+	case TypeMilvus:
+		partition := kb
+		if kb == DefaultKnowledgeBase {
+			partition = defaultPartition
+		}
+		return NewMilvusStore(ctx, partition)
+	case TypeLocal:
+		return NewLocalStore(localStorePath(g.Cfg().MustGet(ctx, "vector_store.local.path").String(), kb))
+	default:
+		return nil, fmt.Errorf("unknown vector store type: %s", typ)
+	}
+}
+
+// localStorePath 默认知识库使用配置的路径，其它知识库在文件名后追加知识库名称
+func localStorePath(path, kb string) string {
+	if path == "" || kb == DefaultKnowledgeBase {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + kb + ext
+}
@@ -0,0 +1,29 @@
+package vectorstore
+
+import "testing"
+
+func TestFilterMatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		filter   Filter
+		metadata map[string]any
+		want     bool
+	}{
+		{"empty filter", nil, map[string]any{"_source": "a.md"}, true},
+		{"equal string", Filter{"_source": "a.md"}, map[string]any{"_source": "a.md"}, true},
+		{"different string", Filter{"_source": "a.md"}, map[string]any{"_source": "b.md"}, false},
+		{"missing key", Filter{"_source": "a.md"}, map[string]any{"title": "a"}, false},
+		{"int against json float", Filter{"chunk_index": 3}, map[string]any{"chunk_index": float64(3)}, true},
+		{"int64 against float32", Filter{"chunk_index": int64(2)}, map[string]any{"chunk_index": float32(2)}, true},
+		{"different number", Filter{"chunk_index": 3}, map[string]any{"chunk_index": float64(4)}, false},
+		{"number against string", Filter{"chunk_index": 3}, map[string]any{"chunk_index": "3"}, false},
+		{"all conditions", Filter{"_source": "a.md", "chunk_index": 1}, map[string]any{"_source": "a.md", "chunk_index": 2}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.filter.Match(c.metadata); got != c.want {
+				t.Errorf("Match() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
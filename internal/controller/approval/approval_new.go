@@ -0,0 +1,11 @@
+package approval
+
+import (
+	"github.com/NuyoahCh/eocall/api/approval"
+)
+
+type ControllerV1 struct{}
+
+func NewV1() approval.IApprovalV1 {
+	return &ControllerV1{}
+}
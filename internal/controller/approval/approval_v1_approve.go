@@ -0,0 +1,30 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	v1 "github.com/NuyoahCh/eocall/api/approval/v1"
+	"github.com/NuyoahCh/eocall/internal/logic/approval"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+func (c *ControllerV1) Approve(ctx context.Context, req *v1.ApproveReq) (res *v1.ApproveRes, err error) {
+	a, err := approval.Decide(ctx, req.Id, true, req.Reason)
+	if err != nil {
+		return nil, wrapError(err, "批准工具调用失败")
+	}
+	return &v1.ApproveRes{Approval: toAPI(a)}, nil
+}
+
+// wrapError 将审批相关错误转换为带错误码的错误
+func wrapError(err error, text string) error {
+	switch {
+	case errors.Is(err, approval.ErrForbidden):
+		return gerror.WrapCode(gcode.CodeNotAuthorized, err, text)
+	case errors.Is(err, approval.ErrNotFound):
+		return gerror.WrapCode(gcode.CodeNotFound, err, text)
+	default:
+		return gerror.Wrap(err, text)
+	}
+}
@@ -0,0 +1,30 @@
+package approval
+
+import (
+	"context"
+	v1 "github.com/NuyoahCh/eocall/api/approval/v1"
+	"github.com/NuyoahCh/eocall/internal/logic/approval"
+)
+
+func (c *ControllerV1) List(ctx context.Context, req *v1.ListReq) (res *v1.ListRes, err error) {
+	res = &v1.ListRes{List: []*v1.Approval{}}
+	for _, a := range approval.List(ctx) {
+		res.List = append(res.List, toAPI(a))
+	}
+	return res, nil
+}
+
+func toAPI(a *approval.Approval) *v1.Approval {
+	return &v1.Approval{
+		Id:        a.ID,
+		Tool:      a.Tool,
+		Arguments: a.Arguments,
+		Requester: a.Requester,
+		SessionId: a.SessionID,
+		Status:    a.Status,
+		Approver:  a.Approver,
+		Reason:    a.Reason,
+		CreatedAt: a.CreatedAt.Format("2006-01-02 15:04:05"),
+		ExpiresAt: a.ExpiresAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,15 @@
+package approval
+
+import (
+	"context"
+	v1 "github.com/NuyoahCh/eocall/api/approval/v1"
+	"github.com/NuyoahCh/eocall/internal/logic/approval"
+)
+
+func (c *ControllerV1) Reject(ctx context.Context, req *v1.RejectReq) (res *v1.RejectRes, err error) {
+	a, err := approval.Decide(ctx, req.Id, false, req.Reason)
+	if err != nil {
+		return nil, wrapError(err, "拒绝工具调用失败")
+	}
+	return &v1.RejectRes{Approval: toAPI(a)}, nil
+}
@@ -0,0 +1,21 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	v1 "github.com/NuyoahCh/eocall/api/approval/v1"
+	"github.com/NuyoahCh/eocall/internal/logic/approval"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+func (c *ControllerV1) Resume(ctx context.Context, req *v1.ResumeReq) (res *v1.ResumeRes, err error) {
+	result, err := approval.Resume(ctx, req.Id)
+	if errors.Is(err, approval.ErrPending) {
+		return nil, gerror.WrapCode(gcode.CodeInvalidOperation, err, "仍有待审批的工具调用")
+	}
+	if err != nil {
+		return nil, wrapError(err, "继续对话失败")
+	}
+	return &v1.ResumeRes{Result: result}, nil
+}
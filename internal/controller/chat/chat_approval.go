@@ -0,0 +1,18 @@
+package chat
+
+// approvalRequiredEvent approval_required 事件的内容，arguments 为模型生成的原始工具参数
+type approvalRequiredEvent struct {
+	Id        string `json:"id"`
+	Tool      string `json:"tool"`
+	Arguments string `json:"arguments"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// approvalResultEvent approval_result 事件的内容
+type approvalResultEvent struct {
+	Id       string `json:"id"`
+	Tool     string `json:"tool"`
+	Status   string `json:"status"`
+	Approver string `json:"approver"`
+	Reason   string `json:"reason"`
+}
@@ -2,15 +2,18 @@ package chat
 
 import (
 	"github.com/NuyoahCh/eocall/api/chat"
+	"github.com/NuyoahCh/eocall/internal/logic/agents"
 	"github.com/NuyoahCh/eocall/internal/logic/sse"
 )
 
 type ControllerV1 struct {
 	service *sse.Service
+	agents  *agents.Agents // 启动时构建、所有请求共享的智能体
 }
 
 func NewV1() chat.IChatV1 {
 	return &ControllerV1{
 		service: sse.New(),
+		agents:  agents.Shared(),
 	}
 }
@@ -0,0 +1,52 @@
+package chat
+
+import (
+	v1 "github.com/NuyoahCh/eocall/api/chat/v1"
+	"github.com/NuyoahCh/eocall/internal/ai/agent/chat_pipeline"
+)
+
+// toDebugRes 请求开启 debug 时将对话流程的中间结果转换为调试信息，t 为 Trace.Snapshot 的结果
+func toDebugRes(t *chat_pipeline.Trace, enabled bool) *v1.ChatDebug {
+	if !enabled || t == nil {
+		return nil
+	}
+	res := &v1.ChatDebug{
+		RewrittenQuery: t.RewrittenQuery,
+		SubQueries:     t.SubQueries,
+	}
+	if b := t.Budget; b != nil {
+		res.Budget = &v1.ChatBudget{
+			Profile:            b.Profile,
+			ContextWindow:      b.ContextWindow,
+			Reserved:           b.Reserved,
+			PromptTokens:       b.PromptTokens,
+			DroppedDocuments:   b.DroppedDocuments,
+			TruncatedDocuments: b.TruncatedDocuments,
+			DroppedHistory:     b.DroppedHistory,
+			TruncatedHistory:   b.TruncatedHistory,
+		}
+	}
+	return res
+}
+
+// toSourcesRes 根据回答中的引用标记生成来源列表，t 为 Trace.Snapshot 的结果
+func toSourcesRes(t *chat_pipeline.Trace, answer string) *v1.ChatSources {
+	sources, cited := chat_pipeline.BuildSources(t.Documents, answer)
+	res := &v1.ChatSources{
+		Sources:  make([]*v1.ChatSource, 0, len(sources)),
+		Uncited:  !cited,
+		Grounded: t.Grounded,
+	}
+	for _, s := range sources {
+		res.Sources = append(res.Sources, &v1.ChatSource{
+			Index:      s.Index,
+			Source:     s.Source,
+			Title:      s.Title,
+			HeaderPath: s.HeaderPath,
+			ChunkId:    s.ChunkID,
+			Score:      s.Score,
+			Cited:      s.Cited,
+		})
+	}
+	return res
+}
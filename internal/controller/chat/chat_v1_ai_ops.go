@@ -5,26 +5,32 @@ import (
 	"errors"
 	v1 "github.com/NuyoahCh/eocall/api/chat/v1"
 	"github.com/NuyoahCh/eocall/internal/ai/agent/plan_execute_replan"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"log"
 )
 
 func (c *ControllerV1) AIOps(ctx context.Context, req *v1.AIOpsReq) (res *v1.AIOpsRes, err error) {
-	query := `
-"1. 你是一个智能的服务告警分析助手,首先调用工具query_prometheus_alerts获取所有活跃的告警。"
-"2. 分别根据告警的名称调用工具query_internal_docs，获取告警名对应的处理方案。"
-"3. 完全遵循内部文档的内容进行查询和分析,不允许使用文档外的任何信息。"
-"4. 涉及到时间的参数都需要先通过工具get_current_time获取当前时间,再结合工具的时间要求进行传参。"
-"5. 涉及到日志的查询,需要先通过日志工具获取相关日志信息，参数必须携带地域和日志主题。"
-"6. 分别将告警对应查询到的信息进行总结分析,最后生成告警运维分析报告，格式如下：
-告警分析报告
----
-# 告警处理详情
-## 活跃告警清单
-## 告警根因分析N(第N个告警)
-## 处理方案执行N(第N个告警)
-## 结论
-`
+	ctx, err = knowledge.WithResolved(ctx, req.KnowledgeBase)
+	if err != nil {
+		return nil, err
+	}
+	ctx = tools.WithRunCache(ctx)
 
-	resp, detail, err := plan_execute_replan.BuildPlanAgent(ctx, query)
+	// 排查智能体由所有调用方共享，与告警 webhook、MCP 的 ai_ops 工具一样，调用方需有权使用其全部工具
+	plan, release, err := c.agents.Plan()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if len(tools.Permitted(ctx, plan.Tools)) < len(plan.Tools) {
+		log.Printf("[info] ai ops is not permitted for %s", auth.FromContext(ctx).User)
+		return nil, gerror.NewCode(gcode.CodeNotAuthorized, "无权使用排查智能体的全部工具")
+	}
+	resp, detail, err := plan_execute_replan.RunPlanAgent(ctx, plan.Agent, plan_execute_replan.AIOpsQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -4,35 +4,119 @@ import (
 	"context"
 	v1 "github.com/NuyoahCh/eocall/api/chat/v1"
 	"github.com/NuyoahCh/eocall/internal/ai/agent/chat_pipeline"
+	"github.com/NuyoahCh/eocall/internal/ai/retriever"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/internal/logic/approval"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
 	"github.com/NuyoahCh/eocall/utility/log_call_back"
 	"github.com/NuyoahCh/eocall/utility/mem"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/util/guid"
 )
 
 func (c *ControllerV1) Chat(ctx context.Context, req *v1.ChatReq) (res *v1.ChatRes, err error) {
 	id := req.Id
 	msg := req.Question
+	ctx, err = knowledge.WithResolved(ctx, req.KnowledgeBase)
+	if err != nil {
+		return nil, err
+	}
+	ctx = tools.WithRequested(ctx, req.Tools)
+	ctx = tools.WithSession(ctx, id)
+	// 同一次对话中重复的表结构查询直接使用缓存
+	ctx = tools.WithRunCache(ctx)
+	ctx, trace := chat_pipeline.WithTrace(ctx)
+	overrides := &retriever.Overrides{TopK: req.TopK, ScoreThreshold: req.ScoreThreshold}
+	opts := append(chat_pipeline.RetrievalOptions(ctx, overrides), compose.WithCallbacks(log_call_back.LogCallback(nil)))
 	userMessage := &chat_pipeline.UserMessage{
 		ID:      id,
 		Query:   msg,
 		History: mem.GetSimpleMemory(id).GetMessages(),
 	}
 
-	runner, err := chat_pipeline.BuildChatAgent(ctx)
+	runner, release, err := c.agents.Chat()
 	if err != nil {
 		return nil, err
 	}
 
-	out, err := runner.Invoke(ctx, userMessage, compose.WithCallbacks(log_call_back.LogCallback(nil)))
+	// 同一次运行的检查点 ID，工具等待审批时中断保存；非流式请求不等待审批，
+	// 直接返回审批单，审批结束后客户端通过审批接口继续运行
+	runID := guid.S()
+	run := &chatRun{
+		runner:  runner,
+		message: userMessage,
+		opts:    append(opts, compose.WithCheckPointID(runID)),
+		trace:   trace,
+		runID:   runID,
+		debug:   req.Debug,
+		release: release,
+	}
+	return run.invoke(ctx)
+}
+
+// chatRun 一次非流式对话的运行，工具等待审批时挂起，由 approval.Resume 继续
+type chatRun struct {
+	runner  compose.Runnable[*chat_pipeline.UserMessage, *schema.Message]
+	message *chat_pipeline.UserMessage
+	opts    []compose.Option
+	trace   *chat_pipeline.Trace
+	runID   string
+	debug   bool
+	// release 运行结束或挂起的运行被丢弃时释放智能体
+	release func()
+}
+
+// invoke 执行或从中断处恢复对话，运行因审批中断时返回待审批的工具调用
+func (r *chatRun) invoke(ctx context.Context) (*v1.ChatRes, error) {
+	out, err := r.runner.Invoke(ctx, r.message, r.opts...)
 	if err != nil {
-		return nil, err
+		pending, ok := approval.Suspend(ctx, err, r.runID, r.message.ID, r.resume, r.release)
+		if !ok {
+			r.release()
+			return nil, err
+		}
+		res := r.response("")
+		for _, a := range pending {
+			res.Approvals = append(res.Approvals, &v1.ChatApproval{
+				Id:        a.ID,
+				Tool:      a.Tool,
+				Arguments: a.Arguments,
+				ExpiresAt: a.ExpiresAt.Format("2006-01-02 15:04:05"),
+			})
+		}
+		return res, nil
 	}
-	res = &v1.ChatRes{
-		Answer: out.Content,
+	return r.complete(out.Content), nil
+}
+
+// resume 审批结束后继续运行，未获批准时以终止说明作为回答
+func (r *chatRun) resume(ctx context.Context, rejected *approval.Approval) (any, error) {
+	if rejected != nil {
+		return r.complete(approval.RejectedMessage(rejected)), nil
 	}
-	mem.GetSimpleMemory(id).SetMessages(schema.UserMessage(msg))
-	mem.GetSimpleMemory(id).SetMessages(schema.SystemMessage(out.Content))
+	return r.invoke(ctx)
+}
 
-	return res, nil
+// complete 结束运行，记录对话并生成回答的响应
+func (r *chatRun) complete(answer string) *v1.ChatRes {
+	r.release()
+	mem.GetSimpleMemory(r.message.ID).SetMessages(schema.UserMessage(r.message.Query))
+	mem.GetSimpleMemory(r.message.ID).SetMessages(schema.SystemMessage(answer))
+	return r.response(answer)
+}
+
+// response 根据中间结果的快照生成响应
+func (r *chatRun) response(answer string) *v1.ChatRes {
+	trace := r.trace.Snapshot()
+	sources := toSourcesRes(trace, answer)
+	return &v1.ChatRes{
+		Answer:   answer,
+		Route:    trace.Route,
+		Tools:    trace.Tools,
+		Sources:  sources.Sources,
+		Uncited:  sources.Uncited,
+		Grounded: sources.Grounded,
+		Debug:    toDebugRes(trace, r.debug),
+	}
 }
@@ -2,14 +2,20 @@ package chat
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"github.com/NuyoahCh/eocall/api/chat/v1"
 	"github.com/NuyoahCh/eocall/internal/ai/agent/chat_pipeline"
+	"github.com/NuyoahCh/eocall/internal/ai/retriever"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/internal/logic/approval"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
 	"github.com/NuyoahCh/eocall/utility/log_call_back"
 	"github.com/NuyoahCh/eocall/utility/mem"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/guid"
 	"io"
 	"strings"
 )
@@ -19,24 +25,56 @@ func (c *ControllerV1) ChatStream(ctx context.Context, req *v1.ChatStreamReq) (r
 	msg := req.Question
 
 	ctx = context.WithValue(ctx, "client_id", req.Id)
+	ctx, err = knowledge.WithResolved(ctx, req.KnowledgeBase)
+	if err != nil {
+		return nil, err
+	}
 	client, err := c.service.Create(ctx, g.RequestFromCtx(ctx))
 	if err != nil {
 		return nil, err
 	}
 
+	ctx = tools.WithRequested(ctx, req.Tools)
+	ctx = tools.WithSession(ctx, id)
+	// 同一次对话中重复的表结构查询直接使用缓存
+	ctx = tools.WithRunCache(ctx)
+	ctx, trace := chat_pipeline.WithTrace(ctx)
+	overrides := &retriever.Overrides{TopK: req.TopK, ScoreThreshold: req.ScoreThreshold}
+	opts := append(chat_pipeline.RetrievalOptions(ctx, overrides), compose.WithCallbacks(log_call_back.LogCallback(nil)))
 	userMessage := &chat_pipeline.UserMessage{
 		ID:      id,
 		Query:   msg,
 		History: mem.GetSimpleMemory(id).GetMessages(),
 	}
 
-	runner, err := chat_pipeline.BuildChatAgent(ctx)
-	sr, err := runner.Stream(ctx, userMessage, compose.WithCallbacks(log_call_back.LogCallback(nil)))
+	runner, release, err := c.agents.Chat()
 	if err != nil {
 		client.SendToClient("error", err.Error())
 		return nil, err
 	}
-	defer sr.Close()
+	defer release()
+	// 同一次运行的检查点 ID，工具等待审批时中断保存，审批通过后从中断处恢复
+	runID := guid.S()
+	opts = append(opts, compose.WithCheckPointID(runID))
+	notify := func(a *approval.Approval) {
+		if a.Status == approval.StatusPending {
+			b, _ := json.Marshal(&approvalRequiredEvent{Id: a.ID, Tool: a.Tool, Arguments: a.Arguments, ExpiresAt: a.ExpiresAt.Format("2006-01-02 15:04:05")})
+			client.SendToClient("approval_required", string(b))
+			return
+		}
+		b, _ := json.Marshal(&approvalResultEvent{Id: a.ID, Tool: a.Tool, Status: a.Status, Approver: a.Approver, Reason: a.Reason})
+		client.SendToClient("approval_result", string(b))
+	}
+	sr, err := runner.Stream(ctx, userMessage, opts...)
+	// 流式输出开始或工具等待审批时路由与检索阶段已完成，先推送处理路线与调试信息
+	snapshot := trace.Snapshot()
+	client.SendToClient("route", snapshot.Route)
+	b, _ := json.Marshal(snapshot.Tools)
+	client.SendToClient("tools", string(b))
+	if req.Debug {
+		b, _ := json.Marshal(toDebugRes(snapshot, req.Debug))
+		client.SendToClient("debug", string(b))
+	}
 
 	var fullResponse strings.Builder
 
@@ -49,14 +87,35 @@ func (c *ControllerV1) ChatStream(ctx context.Context, req *v1.ChatStreamReq) (r
 	}()
 
 	for {
-		chunk, err := sr.Recv()
+		if err != nil {
+			// 工具等待审批时运行中断，审批通过后从中断处恢复执行并继续输出，被拒绝或超时时终止
+			resumeCtx, rejected, ok := approval.Await(ctx, err, runID, id, notify)
+			if !ok {
+				client.SendToClient("error", err.Error())
+				return &v1.ChatStreamRes{}, nil
+			}
+			if rejected != nil {
+				fullResponse.WriteString(approval.RejectedMessage(rejected))
+				client.SendToClient("message", approval.RejectedMessage(rejected))
+				client.SendToClient("done", "Stream completed")
+				return &v1.ChatStreamRes{}, nil
+			}
+			sr, err = runner.Stream(resumeCtx, userMessage, opts...)
+			continue
+		}
+		var chunk *schema.Message
+		chunk, err = sr.Recv()
 		if errors.Is(err, io.EOF) {
+			sr.Close()
+			// 回答结束后根据引用标记推送来源列表
+			b, _ := json.Marshal(toSourcesRes(trace.Snapshot(), fullResponse.String()))
+			client.SendToClient("sources", string(b))
 			client.SendToClient("done", "Stream completed")
 			return &v1.ChatStreamRes{}, nil
 		}
 		if err != nil {
-			client.SendToClient("error", err.Error())
-			return &v1.ChatStreamRes{}, nil
+			sr.Close()
+			continue
 		}
 		fullResponse.WriteString(chunk.Content)
 		client.SendToClient("message", chunk.Content)
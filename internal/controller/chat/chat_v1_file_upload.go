@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 	v1 "github.com/NuyoahCh/eocall/api/chat/v1"
-	"github.com/NuyoahCh/eocall/internal/ai/agent/knowledge_index_pipeline"
+	"github.com/NuyoahCh/eocall/internal/ai/indexer"
 	loader2 "github.com/NuyoahCh/eocall/internal/ai/loader"
-	"github.com/NuyoahCh/eocall/utility/client"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
 	"github.com/NuyoahCh/eocall/utility/common"
 	"github.com/NuyoahCh/eocall/utility/log_call_back"
 	"github.com/cloudwego/eino/components/document"
@@ -16,7 +17,6 @@ import (
 	"github.com/gogf/gf/v2/os/gfile"
 	"os"
 	"path/filepath"
-	"strings"
 )
 
 func (c *ControllerV1) FileUpload(ctx context.Context, req *v1.FileUploadReq) (res *v1.FileUploadRes, err error) {
@@ -27,17 +27,27 @@ func (c *ControllerV1) FileUpload(ctx context.Context, req *v1.FileUploadReq) (r
 		return nil, gerror.New("请上传文件")
 	}
 
+	ctx, err = knowledge.WithResolved(ctx, req.KnowledgeBase)
+	if err != nil {
+		return nil, err
+	}
+	// 默认知识库的文件直接保存在 file_dir 下，其它知识库保存在以知识库命名的子目录中
+	fileDir := common.FileDir
+	if kb := vectorstore.KnowledgeBaseFromContext(ctx); kb != vectorstore.DefaultKnowledgeBase {
+		fileDir = filepath.Join(common.FileDir, kb)
+	}
+
 	// 确保保存目录存在
-	if !gfile.Exists(common.FileDir) {
-		if err := gfile.Mkdir(common.FileDir); err != nil {
-			return nil, gerror.Wrapf(err, "创建目录失败: %s", common.FileDir)
+	if !gfile.Exists(fileDir) {
+		if err := gfile.Mkdir(fileDir); err != nil {
+			return nil, gerror.Wrapf(err, "创建目录失败: %s", fileDir)
 		}
 	}
 
 	// 获取原始文件名
 	newFileName := uploadFile.Filename
 	// 完整的保存路径
-	savePath := filepath.Join(common.FileDir)
+	savePath := filepath.Join(fileDir)
 
 	// 保存文件
 	_, err = uploadFile.Save(savePath, false)
@@ -56,56 +66,34 @@ func (c *ControllerV1) FileUpload(ctx context.Context, req *v1.FileUploadReq) (r
 		FilePath: savePath,
 		FileSize: fileInfo.Size(),
 	}
-	err = buildIntoIndex(ctx, common.FileDir+"/"+newFileName)
+	err = c.buildIntoIndex(ctx, fileDir+"/"+newFileName)
 	if err != nil {
 		return nil, gerror.Wrapf(err, "构建知识库失败")
 	}
 	return res, nil
 }
 
-func buildIntoIndex(ctx context.Context, path string) error {
-	r, err := knowledge_index_pipeline.BuildKnowledgeIndexing(ctx)
-	// 删除biz数据metadata中_source一样的数据
-	loader, err := loader2.NewFileLoader(ctx)
+func (c *ControllerV1) buildIntoIndex(ctx context.Context, path string) error {
+	r, release, err := c.agents.Indexing()
 	if err != nil {
 		return err
 	}
-	docs, err := loader.Load(ctx, document.Source{URI: path})
+	defer release()
+	// 删除biz数据metadata中_source一样的数据
+	loader, err := loader2.NewFileLoader(ctx)
 	if err != nil {
 		return err
 	}
-	cli, err := client.NewMilvusClient(ctx)
+	docs, err := loader.Load(ctx, document.Source{URI: path})
 	if err != nil {
 		return err
 	}
-	// 查询所有metadata中_source一样的数据并删除
-	expr := fmt.Sprintf(`metadata["_source"] == "%s"`, docs[0].MetaData["_source"])
-	queryResult, err := cli.Query(ctx, common.MilvusCollectionName, []string{}, expr, []string{"id"})
+	// 删除所有metadata中_source一样的数据
+	deleted, err := indexer.DeleteBySource(ctx, docs[0].MetaData["_source"])
 	if err != nil {
-		return err
-	} else if len(queryResult) > 0 {
-		// 提取所有需要删除的id
-		var idsToDelete []string
-		for _, column := range queryResult {
-			if column.Name() == "id" {
-				for i := 0; i < column.Len(); i++ {
-					id, err := column.GetAsString(i)
-					if err == nil {
-						idsToDelete = append(idsToDelete, id)
-					}
-				}
-			}
-		}
-		// 删除这些数据
-		if len(idsToDelete) > 0 {
-			deleteExpr := fmt.Sprintf(`id in ["%s"]`, strings.Join(idsToDelete, `","`))
-			err = cli.Delete(ctx, common.MilvusCollectionName, "", deleteExpr)
-			if err != nil {
-				fmt.Printf("[warn] delete existing data failed: %v\n", err)
-			} else {
-				fmt.Printf("[info] deleted %d existing records with _source: %s\n", len(idsToDelete), docs[0].MetaData["_source"])
-			}
-		}
+		fmt.Printf("[warn] delete existing data failed: %v\n", err)
+	} else if deleted > 0 {
+		fmt.Printf("[info] deleted %d existing records with _source: %s\n", deleted, docs[0].MetaData["_source"])
 	}
 	// 重新构建
 	ids, err := r.Invoke(ctx, document.Source{URI: path}, compose.WithCallbacks(log_call_back.LogCallback(nil)))
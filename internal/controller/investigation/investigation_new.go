@@ -0,0 +1,14 @@
+package investigation
+
+import (
+	"github.com/NuyoahCh/eocall/api/investigation"
+	"github.com/NuyoahCh/eocall/internal/logic/agents"
+)
+
+type ControllerV1 struct {
+	agents *agents.Agents // 启动时构建、所有请求共享的智能体
+}
+
+func NewV1() investigation.IInvestigationV1 {
+	return &ControllerV1{agents: agents.Shared()}
+}
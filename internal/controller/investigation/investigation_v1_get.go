@@ -0,0 +1,21 @@
+package investigation
+
+import (
+	"context"
+	"errors"
+	v1 "github.com/NuyoahCh/eocall/api/investigation/v1"
+	"github.com/NuyoahCh/eocall/internal/logic/investigation"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+func (c *ControllerV1) Get(ctx context.Context, req *v1.GetReq) (res *v1.GetRes, err error) {
+	inv, err := investigation.Get(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, investigation.ErrNotFound) {
+			return nil, gerror.WrapCode(gcode.CodeNotFound, err, "排查不存在")
+		}
+		return nil, gerror.Wrap(err, "查询排查详情失败")
+	}
+	return &v1.GetRes{Investigation: toAPI(inv, true)}, nil
+}
@@ -0,0 +1,62 @@
+package investigation
+
+import (
+	"context"
+	v1 "github.com/NuyoahCh/eocall/api/investigation/v1"
+	"github.com/NuyoahCh/eocall/internal/logic/investigation"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"time"
+)
+
+func (c *ControllerV1) List(ctx context.Context, req *v1.ListReq) (res *v1.ListRes, err error) {
+	list, err := investigation.List(ctx, req.GroupKey, req.Status)
+	if err != nil {
+		return nil, gerror.Wrap(err, "查询排查列表失败")
+	}
+	res = &v1.ListRes{List: []*v1.Investigation{}}
+	for _, inv := range list {
+		res.List = append(res.List, toAPI(inv, false))
+	}
+	return res, nil
+}
+
+// toAPI 转换为接口返回的排查，detail 为 false 时不返回各步骤的输出
+func toAPI(inv *investigation.Investigation, detail bool) *v1.Investigation {
+	out := &v1.Investigation{
+		Id:            inv.ID,
+		GroupKey:      inv.GroupKey,
+		Receiver:      inv.Receiver,
+		GroupLabels:   inv.GroupLabels,
+		Alerts:        make([]*v1.Alert, 0, len(inv.Alerts)),
+		Status:        inv.Status,
+		Report:        inv.Report,
+		Error:         inv.Error,
+		Requester:     inv.Requester,
+		KnowledgeBase: inv.KnowledgeBase,
+		CreatedAt:     inv.CreatedAt.Format("2006-01-02 15:04:05"),
+		FinishedAt:    formatTime(inv.FinishedAt),
+		ResolvedAt:    formatTime(inv.ResolvedAt),
+	}
+	if detail {
+		out.Detail = inv.Detail
+	}
+	for _, a := range inv.Alerts {
+		out.Alerts = append(out.Alerts, &v1.Alert{
+			Status:       a.Status,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     a.StartsAt.Format(time.RFC3339),
+			EndsAt:       a.EndsAt.Format(time.RFC3339),
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint,
+		})
+	}
+	return out
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
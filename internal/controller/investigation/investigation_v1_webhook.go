@@ -0,0 +1,73 @@
+package investigation
+
+import (
+	"context"
+	"errors"
+	v1 "github.com/NuyoahCh/eocall/api/investigation/v1"
+	"github.com/NuyoahCh/eocall/internal/logic/investigation"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
+	"github.com/cloudwego/eino/adk"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"time"
+)
+
+func (c *ControllerV1) Webhook(ctx context.Context, req *v1.WebhookReq) (res *v1.WebhookRes, err error) {
+	// 排查使用推送方团队绑定的知识库查询处理方案
+	ctx, err = knowledge.WithResolved(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	n := &investigation.Notification{
+		GroupKey:          req.GroupKey,
+		Status:            req.Status,
+		Receiver:          req.Receiver,
+		GroupLabels:       req.GroupLabels,
+		CommonLabels:      req.CommonLabels,
+		CommonAnnotations: req.CommonAnnotations,
+		ExternalURL:       req.ExternalURL,
+	}
+	for _, a := range req.Alerts {
+		n.Alerts = append(n.Alerts, fromAPIAlert(a))
+	}
+	incidentTools, err := c.agents.IncidentTools()
+	if err != nil {
+		return nil, gerror.Wrap(err, "构建排查智能体失败")
+	}
+	plan := func() (adk.Agent, func(), error) {
+		p, release, err := c.agents.Plan()
+		if err != nil {
+			return nil, nil, err
+		}
+		return p.Agent, release, nil
+	}
+	inv, outcome, err := investigation.Receive(ctx, n, incidentTools, plan)
+	if err != nil {
+		if errors.Is(err, investigation.ErrDisabled) {
+			return nil, gerror.WrapCode(gcode.CodeNotSupported, err, "未启用告警 webhook")
+		}
+		if errors.Is(err, investigation.ErrForbidden) {
+			return nil, gerror.WrapCode(gcode.CodeNotAuthorized, err, "无权使用排查智能体的全部工具")
+		}
+		return nil, gerror.Wrap(err, "处理告警通知失败")
+	}
+	res = &v1.WebhookRes{Outcome: outcome}
+	if inv != nil {
+		res.Investigation = toAPI(inv, false)
+	}
+	return res, nil
+}
+
+func fromAPIAlert(a *v1.Alert) investigation.Alert {
+	startsAt, _ := time.Parse(time.RFC3339Nano, a.StartsAt)
+	endsAt, _ := time.Parse(time.RFC3339Nano, a.EndsAt)
+	return investigation.Alert{
+		Status:       a.Status,
+		Labels:       a.Labels,
+		Annotations:  a.Annotations,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		GeneratorURL: a.GeneratorURL,
+		Fingerprint:  a.Fingerprint,
+	}
+}
@@ -0,0 +1,11 @@
+package knowledge
+
+import (
+	"github.com/NuyoahCh/eocall/api/knowledge"
+)
+
+type ControllerV1 struct{}
+
+func NewV1() knowledge.IKnowledgeV1 {
+	return &ControllerV1{}
+}
@@ -0,0 +1,48 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	v1 "github.com/NuyoahCh/eocall/api/knowledge/v1"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+func (c *ControllerV1) Create(ctx context.Context, req *v1.CreateReq) (res *v1.CreateRes, err error) {
+	kb, err := knowledge.Create(ctx, &knowledge.KnowledgeBase{
+		Name:        req.Name,
+		Description: req.Description,
+		Teams:       req.Teams,
+	})
+	if err != nil {
+		return nil, wrapError(err, "创建知识库失败")
+	}
+	return &v1.CreateRes{KnowledgeBase: toAPI(kb)}, nil
+}
+
+func toAPI(kb *knowledge.KnowledgeBase) *v1.KnowledgeBase {
+	out := &v1.KnowledgeBase{
+		Name:        kb.Name,
+		Description: kb.Description,
+		Teams:       kb.Teams,
+	}
+	if !kb.CreatedAt.IsZero() {
+		out.CreatedAt = kb.CreatedAt.Format("2006-01-02 15:04:05")
+	}
+	return out
+}
+
+// wrapError 将知识库相关错误转换为带错误码的错误
+func wrapError(err error, text string) error {
+	switch {
+	case errors.Is(err, knowledge.ErrForbidden):
+		return gerror.WrapCode(gcode.CodeNotAuthorized, err, text)
+	case errors.Is(err, knowledge.ErrNotFound):
+		return gerror.WrapCode(gcode.CodeNotFound, err, text)
+	case errors.Is(err, knowledge.ErrExists):
+		return gerror.WrapCode(gcode.CodeInvalidParameter, err, text)
+	default:
+		return gerror.Wrap(err, text)
+	}
+}
@@ -0,0 +1,20 @@
+package knowledge
+
+import (
+	"context"
+	v1 "github.com/NuyoahCh/eocall/api/knowledge/v1"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+func (c *ControllerV1) List(ctx context.Context, req *v1.ListReq) (res *v1.ListRes, err error) {
+	list, err := knowledge.List(ctx)
+	if err != nil {
+		return nil, gerror.Wrap(err, "获取知识库列表失败")
+	}
+	res = &v1.ListRes{List: make([]*v1.KnowledgeBase, 0, len(list))}
+	for _, kb := range list {
+		res.List = append(res.List, toAPI(kb))
+	}
+	return res, nil
+}
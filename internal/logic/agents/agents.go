@@ -0,0 +1,274 @@
+package agents
+
+import (
+	"context"
+	"github.com/NuyoahCh/eocall/internal/ai/agent/chat_pipeline"
+	"github.com/NuyoahCh/eocall/internal/ai/agent/knowledge_index_pipeline"
+	"github.com/NuyoahCh/eocall/internal/ai/agent/plan_execute_replan"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/utility/closer"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gfsnotify"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// Agents 服务启动时构建的智能体与编排图，所有请求共享
+// 编译后的图与 adk 智能体可以并发调用，单次请求的数据通过调用上下文与图的状态传递；
+// 配置文件变更后在下一次获取时重新构建，构建完成前及构建失败时继续使用上一次构建的结果
+type Agents struct {
+	chat  *holder[compose.Runnable[*chat_pipeline.UserMessage, *schema.Message]]
+	plan  *holder[*PlanAgent]
+	index *holder[compose.Runnable[document.Source, []string]]
+}
+
+// New 构建全部智能体并监听配置文件变更，构建失败的智能体在首次使用时重试，不影响服务启动
+// ctx 用于构建智能体，其中的 MCP 等长连接随之存活，不能传入单次请求的上下文
+func New(ctx context.Context) *Agents {
+	a := &Agents{
+		plan:  newHolder(ctx, "plan execute agent", buildPlanAgent),
+		index: newHolder(ctx, "knowledge indexing", knowledge_index_pipeline.BuildKnowledgeIndexing),
+	}
+	// 对话智能体的故障排查路线使用同一个排查智能体，不单独建立 MCP 连接
+	a.chat = newHolder(ctx, "chat agent", func(ctx context.Context) (compose.Runnable[*chat_pipeline.UserMessage, *schema.Message], error) {
+		return chat_pipeline.BuildChatAgent(ctx, a.incident)
+	})
+	a.plan.warm()
+	a.chat.warm()
+	a.index.warm()
+	a.watch()
+	return a
+}
+
+var (
+	sharedOnce sync.Once
+	shared     *Agents
+)
+
+// Shared 进程内共享的智能体，首次调用时构建，REST 接口与 MCP 服务共用同一份
+func Shared() *Agents {
+	sharedOnce.Do(func() {
+		shared = New(gctx.GetInitCtx())
+	})
+	return shared
+}
+
+// Chat 对话智能体，运行结束后调用 release，包括等待审批而挂起的运行
+// 重新构建后旧的智能体继续服务已开始的运行，最后一次运行结束时释放其 MCP 连接
+func (a *Agents) Chat() (compose.Runnable[*chat_pipeline.UserMessage, *schema.Message], func(), error) {
+	return a.chat.Get()
+}
+
+// Plan plan-execute-replan 智能体及其执行器使用的工具名，运行结束后调用 release
+func (a *Agents) Plan() (*PlanAgent, func(), error) {
+	return a.plan.Get()
+}
+
+// IncidentTools plan-execute-replan 智能体执行器使用的工具名，调用方有权使用全部工具时才能发起排查
+func (a *Agents) IncidentTools() ([]string, error) {
+	p, release, err := a.plan.Get()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.Tools, nil
+}
+
+// incident 供对话智能体获取排查智能体
+func (a *Agents) incident() (adk.Agent, []string, func(), error) {
+	p, release, err := a.plan.Get()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return p.Agent, p.Tools, release, nil
+}
+
+// PlanAgent plan-execute-replan 智能体及其执行器使用的工具名
+type PlanAgent struct {
+	Agent adk.Agent
+	Tools []string
+}
+
+func buildPlanAgent(ctx context.Context) (*PlanAgent, error) {
+	toolList, err := plan_execute_replan.ExecutorTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	agent, err := plan_execute_replan.NewPlanExecuteAgentWithTools(ctx, toolList)
+	if err != nil {
+		return nil, err
+	}
+	return &PlanAgent{Agent: agent, Tools: tools.Names(ctx, toolList)}, nil
+}
+
+// Indexing 知识库索引图，使用结束后调用 release
+func (a *Agents) Indexing() (compose.Runnable[document.Source, []string], func(), error) {
+	return a.index.Get()
+}
+
+// Reload 标记全部智能体需要重新构建，下一次获取时生效
+func (a *Agents) Reload() {
+	a.chat.stale.Store(true)
+	a.plan.stale.Store(true)
+	a.index.stale.Store(true)
+}
+
+// watch 监听配置文件，变更后重新构建智能体
+func (a *Agents) watch() {
+	adapter, ok := g.Cfg().GetAdapter().(*gcfg.AdapterFile)
+	if !ok {
+		return
+	}
+	path, err := adapter.GetFilePath()
+	if err != nil || path == "" {
+		return
+	}
+	_, err = gfsnotify.Add(path, func(event *gfsnotify.Event) {
+		log.Printf("[info] config file %s changed, agents will be rebuilt", path)
+		a.Reload()
+	})
+	if err != nil {
+		log.Printf("[warn] watch config file %s failed: %v", path, err)
+	}
+}
+
+// holder 持有构建结果，并发获取时只构建一次
+// 构建在锁外进行，已有结果时后台重新构建并继续返回旧结果，构建成功后替换；
+// 构建期间打开的 MCP 连接等资源登记在 closer.Group 中，被替换的结果在最后一次使用结束后释放
+type holder[T any] struct {
+	ctx   context.Context
+	name  string
+	build func(ctx context.Context) (T, error)
+	stale atomic.Bool
+
+	mu       sync.Mutex
+	current  *generation[T]
+	building *pending
+}
+
+// generation 一次构建的结果，refs 为正在使用它的运行数
+type generation[T any] struct {
+	value     T
+	resources *closer.Group
+	refs      int
+	retired   bool
+}
+
+// pending 进行中的构建，结束后关闭 done
+type pending struct {
+	done chan struct{}
+	err  error
+}
+
+func newHolder[T any](ctx context.Context, name string, build func(ctx context.Context) (T, error)) *holder[T] {
+	return &holder[T]{ctx: ctx, name: name, build: build}
+}
+
+// Get 获取构建结果，使用结束后需调用 release；尚无结果时等待构建，构建失败时返回错误
+// 需要重新构建时在后台构建，构建完成前返回上一次的结果
+func (h *holder[T]) Get() (value T, release func(), err error) {
+	h.mu.Lock()
+	if h.current != nil {
+		if h.stale.Load() && h.building == nil {
+			go h.run(h.start())
+		}
+		value, release = h.acquire(h.current)
+		h.mu.Unlock()
+		return value, release, nil
+	}
+	p := h.building
+	if p == nil {
+		p = h.start()
+		h.mu.Unlock()
+		h.run(p)
+	} else {
+		h.mu.Unlock()
+		<-p.done
+	}
+	if p.err != nil {
+		return value, nil, p.err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	value, release = h.acquire(h.current)
+	return value, release, nil
+}
+
+// warm 构建并立即释放，失败时在首次使用时重试
+func (h *holder[T]) warm() {
+	_, release, err := h.Get()
+	if err != nil {
+		log.Printf("[warn] build %s failed, retry on first use: %v", h.name, err)
+		return
+	}
+	release()
+}
+
+// start 登记一次构建，调用方需持有锁
+func (h *holder[T]) start() *pending {
+	// 先清除标记，构建期间配置再次变更时下一次获取会重新构建
+	h.stale.Store(false)
+	h.building = &pending{done: make(chan struct{})}
+	return h.building
+}
+
+// run 在锁外构建，成功后替换当前结果，被替换的结果没有运行在使用时立即释放
+func (h *holder[T]) run(p *pending) {
+	resources := &closer.Group{}
+	value, err := h.build(closer.WithGroup(h.ctx, resources))
+
+	h.mu.Lock()
+	h.building = nil
+	p.err = err
+	var retired *generation[T]
+	if err == nil {
+		if previous := h.current; previous != nil {
+			previous.retired = true
+			if previous.refs == 0 {
+				retired = previous
+			}
+		}
+		h.current = &generation[T]{value: value, resources: resources}
+	} else if h.current != nil {
+		log.Printf("[warn] rebuild %s failed, keep using the previous one: %v", h.name, err)
+	}
+	h.mu.Unlock()
+	close(p.done)
+
+	if err != nil {
+		h.close(resources, "failed")
+	}
+	if retired != nil {
+		h.close(retired.resources, "previous")
+	}
+}
+
+// acquire 登记一次使用，调用方需持有锁；返回的 release 可重复调用
+func (h *holder[T]) acquire(gen *generation[T]) (T, func()) {
+	gen.refs++
+	var once sync.Once
+	return gen.value, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			gen.refs--
+			done := gen.retired && gen.refs == 0
+			h.mu.Unlock()
+			if done {
+				h.close(gen.resources, "previous")
+			}
+		})
+	}
+}
+
+func (h *holder[T]) close(resources *closer.Group, which string) {
+	if err := resources.Close(); err != nil {
+		log.Printf("[warn] release resources of %s %s build failed: %v", which, h.name, err)
+	}
+}
@@ -0,0 +1,84 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"github.com/NuyoahCh/eocall/utility/closer"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHolder(t *testing.T) {
+	var builds, closed atomic.Int32
+	unblock := make(chan struct{})
+	h := newHolder(context.Background(), "test", func(ctx context.Context) (int32, error) {
+		n := builds.Add(1)
+		if n == 2 {
+			<-unblock
+		}
+		closer.Add(ctx, func() error {
+			closed.Add(1)
+			return nil
+		})
+		return n, nil
+	})
+
+	first, release, err := h.Get()
+	if err != nil || first != 1 {
+		t.Fatalf("Get() = %v, %v, want 1", first, err)
+	}
+
+	// 重新构建期间继续返回旧结果，不阻塞在构建上
+	h.stale.Store(true)
+	value, again, err := h.Get()
+	if err != nil || value != 1 {
+		t.Fatalf("Get() while rebuilding = %v, %v, want 1", value, err)
+	}
+	again()
+	close(unblock)
+	deadline := time.Now().Add(time.Second)
+	for {
+		value, r, _ := h.Get()
+		r()
+		if value == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("rebuild did not replace the previous value")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 旧结果仍在使用时不释放资源，最后一次使用结束后释放
+	if n := closed.Load(); n != 0 {
+		t.Fatalf("closed %d builds while the previous one is in use", n)
+	}
+	release()
+	release()
+	if n := closed.Load(); n != 1 {
+		t.Fatalf("closed %d builds after the last release, want 1", n)
+	}
+	if n := builds.Load(); n != 2 {
+		t.Fatalf("built %d times, want 2", n)
+	}
+}
+
+func TestHolderBuildFailed(t *testing.T) {
+	var builds atomic.Int32
+	h := newHolder(context.Background(), "test", func(ctx context.Context) (int, error) {
+		if builds.Add(1) == 1 {
+			return 0, errors.New("dial failed")
+		}
+		return 1, nil
+	})
+	if _, _, err := h.Get(); err == nil {
+		t.Fatal("Get() error = nil, want the build error")
+	}
+	// 首次构建失败后再次获取时重试
+	value, release, err := h.Get()
+	if err != nil || value != 1 {
+		t.Fatalf("Get() after a failed build = %v, %v, want 1", value, err)
+	}
+	release()
+}
@@ -0,0 +1,180 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/guid"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout 审批的默认等待时间，超时视为拒绝
+const DefaultTimeout = 5 * time.Minute
+
+// 审批状态
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+	StatusExpired  = "expired"
+)
+
+var (
+	// ErrNotFound 审批单不存在或已结束
+	ErrNotFound = errors.New("approval not found")
+	// ErrForbidden 调用方无权审批
+	ErrForbidden = errors.New("approval access denied")
+	// ErrPending 运行中还有待审批的工具调用，暂不能继续
+	ErrPending = errors.New("approval still pending")
+)
+
+// Approval 工具调用的审批单，Arguments 为模型生成的原始参数
+type Approval struct {
+	ID          string    `json:"id"`
+	RunID       string    `json:"run_id"`       // 对话图的检查点 ID
+	InterruptID string    `json:"interrupt_id"` // 恢复执行时定位中断点
+	Tool        string    `json:"tool"`
+	Arguments   string    `json:"arguments"`
+	Requester   string    `json:"requester"`
+	SessionID   string    `json:"session_id"`
+	Status      string    `json:"status"`
+	Approver    string    `json:"approver"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+
+	done chan struct{}
+}
+
+// Decision 转换为恢复执行时传入的审批结果
+func (a *Approval) Decision() *tools.ApprovalDecision {
+	return &tools.ApprovalDecision{Approved: a.Status == StatusApproved, Approver: a.Approver, Reason: a.Reason}
+}
+
+var (
+	mu      sync.Mutex
+	pending = map[string]*Approval{}
+)
+
+// Timeout 从配置文件读取审批的等待时间
+func Timeout(ctx context.Context) time.Duration {
+	d := g.Cfg().MustGet(ctx, "approval.timeout", DefaultTimeout.String()).Duration()
+	if d <= 0 {
+		return DefaultTimeout
+	}
+	return d
+}
+
+// CanApprove 判断调用方是否可以审批工具调用，未启用鉴权时始终允许
+// 可审批的角色对应配置文件中的 approval.approver_roles，默认为管理员
+func CanApprove(ctx context.Context) bool {
+	if !auth.Enabled(ctx) {
+		return true
+	}
+	id := auth.FromContext(ctx)
+	if id.Anonymous {
+		return false
+	}
+	for _, role := range g.Cfg().MustGet(ctx, "approval.approver_roles", []string{auth.RoleAdmin}).Strings() {
+		if id.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Create 为中断的工具调用创建待审批的审批单，申请人为当前调用方
+func Create(ctx context.Context, runID, interruptID, sessionID string, req *tools.ApprovalRequest) *Approval {
+	now := time.Now()
+	a := &Approval{
+		ID:          guid.S(),
+		RunID:       runID,
+		InterruptID: interruptID,
+		Tool:        req.Tool,
+		Arguments:   req.Arguments,
+		Requester:   auth.FromContext(ctx).User,
+		SessionID:   sessionID,
+		Status:      StatusPending,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(Timeout(ctx)),
+		done:        make(chan struct{}),
+	}
+	mu.Lock()
+	pending[a.ID] = a
+	mu.Unlock()
+	return a
+}
+
+// Wait 等待审批结果，超时或请求取消时视为拒绝，返回审批单的快照
+func Wait(ctx context.Context, a *Approval) *Approval {
+	timer := time.NewTimer(time.Until(a.ExpiresAt))
+	defer timer.Stop()
+	select {
+	case <-a.done:
+	case <-timer.C:
+		finish(a.ID, StatusExpired, "", "审批超时")
+	case <-ctx.Done():
+		finish(a.ID, StatusExpired, "", "请求已取消")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	snapshot := *a
+	return &snapshot
+}
+
+// Decide 批准或拒绝待审批的审批单
+func Decide(ctx context.Context, id string, approved bool, reason string) (*Approval, error) {
+	if !CanApprove(ctx) {
+		return nil, ErrForbidden
+	}
+	status := StatusRejected
+	if approved {
+		status = StatusApproved
+	}
+	a, ok := finish(id, status, auth.FromContext(ctx).User, reason)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return a, nil
+}
+
+// Abort 结束仍在等待的审批单，用于同一次运行中其他工具调用已被拒绝的情况
+func Abort(id, reason string) {
+	finish(id, StatusRejected, "", reason)
+}
+
+// List 列出待审批的审批单，可审批的调用方看到全部，其他调用方只看到自己发起的
+func List(ctx context.Context) []*Approval {
+	all := CanApprove(ctx)
+	user := auth.FromContext(ctx).User
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]*Approval, 0, len(pending))
+	for _, a := range pending {
+		if all || a.Requester == user {
+			snapshot := *a
+			out = append(out, &snapshot)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// finish 结束审批并唤醒等待方，审批单已结束时返回 false
+func finish(id, status, approver, reason string) (*Approval, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	a, ok := pending[id]
+	if !ok {
+		return nil, false
+	}
+	delete(pending, id)
+	a.Status, a.Approver, a.Reason = status, approver, reason
+	close(a.done)
+	snapshot := *a
+	return &snapshot, true
+}
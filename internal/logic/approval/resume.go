@@ -0,0 +1,177 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/cloudwego/eino/compose"
+	"time"
+)
+
+// Resumer 恢复挂起的运行并返回运行结果；rejected 非空时表示运行因该审批单未获批准而终止
+type Resumer func(ctx context.Context, rejected *Approval) (any, error)
+
+// suspended 挂起等待审批的运行，审批结束后由发起方继续
+type suspended struct {
+	ctx       context.Context
+	approvals []*Approval
+	resume    Resumer
+	discard   func()
+}
+
+// runs 挂起的运行，按检查点 ID 索引，与 pending 共用 mu
+var runs = map[string]*suspended{}
+
+// Await 对话因工具等待审批而中断时，为每个待审批的工具调用创建审批单并等待结果
+// 全部批准时返回恢复执行的上下文；任一调用被拒绝或超时时返回该审批单，本次运行终止
+// err 不是审批引起的中断时返回 ok 为 false
+func Await(ctx context.Context, err error, runID, sessionID string, notify func(a *Approval)) (resumeCtx context.Context, rejected *Approval, ok bool) {
+	created := createAll(ctx, err, runID, sessionID)
+	if len(created) == 0 {
+		return nil, nil, false
+	}
+	if notify != nil {
+		for _, a := range created {
+			notify(a)
+		}
+	}
+	decisions := make(map[string]any, len(created))
+	for i, a := range created {
+		result := Wait(ctx, a)
+		if notify != nil {
+			notify(result)
+		}
+		if result.Status != StatusApproved {
+			abortRest(created[i+1:], a.Tool)
+			return nil, result, true
+		}
+		decisions[result.InterruptID] = result.Decision()
+	}
+	return compose.BatchResumeWithData(ctx, decisions), nil, true
+}
+
+// Suspend 与 Await 相同地创建审批单，但不阻塞等待：审批结束后由发起方调用 Resume 继续运行
+// 审批结束后发起方一直未继续时丢弃运行并调用 discard；返回创建的审批单，err 不是审批引起的中断时返回 ok 为 false
+func Suspend(ctx context.Context, err error, runID, sessionID string, resume Resumer, discard func()) ([]*Approval, bool) {
+	created := createAll(ctx, err, runID, sessionID)
+	if len(created) == 0 {
+		return nil, false
+	}
+	// 继续运行时发起请求已结束，保留上下文中的调用方与知识库等信息，但不随请求取消
+	run := &suspended{ctx: context.WithoutCancel(ctx), approvals: created, resume: resume, discard: discard}
+	mu.Lock()
+	runs[runID] = run
+	out := make([]*Approval, 0, len(created))
+	for _, a := range created {
+		snapshot := *a
+		out = append(out, &snapshot)
+	}
+	mu.Unlock()
+	var last time.Time
+	for _, a := range created {
+		id := a.ID
+		time.AfterFunc(time.Until(a.ExpiresAt), func() { finish(id, StatusExpired, "", "审批超时") })
+		if a.ExpiresAt.After(last) {
+			last = a.ExpiresAt
+		}
+	}
+	// 审批结束后发起方一直未继续的运行，再保留一个审批时长后丢弃
+	time.AfterFunc(time.Until(last)+Timeout(ctx), func() {
+		mu.Lock()
+		dropped := runs[runID] == run
+		if dropped {
+			delete(runs, runID)
+		}
+		mu.Unlock()
+		if dropped && run.discard != nil {
+			run.discard()
+		}
+	})
+	return out, true
+}
+
+// Resume 继续挂起的运行：全部批准时从中断处恢复，任一调用被拒绝或超时时终止运行，返回运行结果
+// id 为运行中任一审批单的 ID，只有发起方与可审批的调用方可以继续；仍有待审批的调用时返回 ErrPending
+func Resume(ctx context.Context, id string) (any, error) {
+	mu.Lock()
+	runID, run := findRun(id)
+	if run == nil {
+		mu.Unlock()
+		return nil, ErrNotFound
+	}
+	if run.approvals[0].Requester != auth.FromContext(ctx).User && !CanApprove(ctx) {
+		mu.Unlock()
+		return nil, ErrForbidden
+	}
+	var waiting []*Approval
+	for _, a := range run.approvals {
+		if _, ok := pending[a.ID]; ok {
+			waiting = append(waiting, a)
+			continue
+		}
+		if a.Status != StatusApproved {
+			delete(runs, runID)
+			rejected := *a
+			mu.Unlock()
+			abortRest(run.approvals, a.Tool)
+			return run.resume(run.ctx, &rejected)
+		}
+	}
+	if len(waiting) > 0 {
+		mu.Unlock()
+		return nil, ErrPending
+	}
+	decisions := make(map[string]any, len(run.approvals))
+	for _, a := range run.approvals {
+		decisions[a.InterruptID] = a.Decision()
+	}
+	delete(runs, runID)
+	mu.Unlock()
+	return run.resume(compose.BatchResumeWithData(run.ctx, decisions), nil)
+}
+
+// RejectedMessage 工具调用未获批准、运行终止时的回复
+func RejectedMessage(a *Approval) string {
+	msg := fmt.Sprintf("工具 %s 的调用未获批准（%s），本次操作已终止。", a.Tool, a.Status)
+	if a.Reason != "" {
+		msg += "原因：" + a.Reason
+	}
+	return msg
+}
+
+// createAll 为中断中每个待审批的工具调用创建审批单
+func createAll(ctx context.Context, err error, runID, sessionID string) []*Approval {
+	info, isInterrupt := compose.ExtractInterruptInfo(err)
+	if !isInterrupt {
+		return nil
+	}
+	var created []*Approval
+	for _, ic := range info.InterruptContexts {
+		req, isApproval := ic.Info.(*tools.ApprovalRequest)
+		if !ic.IsRootCause || !isApproval {
+			continue
+		}
+		created = append(created, Create(ctx, runID, ic.ID, sessionID, req))
+	}
+	return created
+}
+
+// findRun 查找审批单所属的挂起运行，调用方需持有 mu
+func findRun(id string) (string, *suspended) {
+	for runID, run := range runs {
+		for _, a := range run.approvals {
+			if a.ID == id {
+				return runID, run
+			}
+		}
+	}
+	return "", nil
+}
+
+// abortRest 结束同一次运行中仍在等待的审批单
+func abortRest(rest []*Approval, tool string) {
+	for _, a := range rest {
+		Abort(a.ID, fmt.Sprintf("同一次运行中的工具 %s 未获批准", tool))
+	}
+}
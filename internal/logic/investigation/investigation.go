@@ -0,0 +1,404 @@
+package investigation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/agent/plan_execute_replan"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/cloudwego/eino/adk"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/guid"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认配置，可通过配置文件 alertmanager_webhook 节点覆盖
+const (
+	DefaultStorePath         = "./data/investigations.json"
+	DefaultTimeout           = 30 * time.Minute
+	DefaultMaxConcurrent     = 2
+	DefaultMaxInvestigations = 200
+)
+
+// MaxPromptAlerts 写入排查查询的告警数上限，超出部分只注明个数
+const MaxPromptAlerts = 20
+
+// 排查状态
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// 收到通知后的处理结果
+const (
+	OutcomeStarted   = "started"   // 新触发的告警分组，已开始排查
+	OutcomeDuplicate = "duplicate" // 分组已有未恢复且进行中或已完成的排查，不重复排查
+	OutcomeResolved  = "resolved"  // 分组已恢复，下次触发时重新排查
+	OutcomeIgnored   = "ignored"   // 没有需要处理的告警
+)
+
+var (
+	// ErrDisabled 未启用告警 webhook
+	ErrDisabled = errors.New("alertmanager webhook is disabled")
+	// ErrNotFound 排查记录不存在
+	ErrNotFound = errors.New("investigation not found")
+	// ErrForbidden 调用方无权使用排查智能体的全部工具
+	ErrForbidden = errors.New("alert investigation is not permitted")
+)
+
+// Alert Alertmanager webhook 推送的告警
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Notification Alertmanager webhook 推送的告警分组通知
+type Notification struct {
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"` // firing | resolved
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Investigation 针对一个告警分组的自动排查，Alerts 为开始排查时正在触发的告警
+type Investigation struct {
+	ID            string            `json:"id"`
+	GroupKey      string            `json:"group_key"`
+	Receiver      string            `json:"receiver"`
+	GroupLabels   map[string]string `json:"group_labels"`
+	Alerts        []Alert           `json:"alerts"`
+	Status        string            `json:"status"`
+	Report        string            `json:"report"`
+	Detail        []string          `json:"detail"`
+	Error         string            `json:"error"`
+	Requester     string            `json:"requester"`      // 推送通知的调用方，需有权使用排查智能体的全部工具，排查使用其团队的知识库
+	KnowledgeBase string            `json:"knowledge_base"` // 排查使用的知识库，只有可以访问该知识库的调用方才能查看
+	CreatedAt     time.Time         `json:"created_at"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+	ResolvedAt    *time.Time        `json:"resolved_at,omitempty"` // 告警分组恢复的时间
+}
+
+var (
+	mu      sync.Mutex
+	records map[string]*Investigation // 按 ID 索引的全部排查记录
+	sem     chan struct{}
+)
+
+// Enabled 是否接收 Alertmanager webhook 推送
+func Enabled(ctx context.Context) bool {
+	return g.Cfg().MustGet(ctx, "alertmanager_webhook.enabled").Bool()
+}
+
+// Receive 处理 Alertmanager 推送的通知：按知识库与 groupKey 去重，新触发的分组在后台启动排查并返回排查记录，
+// 分组恢复后再次触发或上一次排查失败时重新排查；ctx 中的调用方身份与知识库用于后台排查
+// 排查智能体由所有调用方共享，与 MCP 的 ai_ops 工具一样，调用方需有权使用其全部工具 incidentTools；
+// plan 获取排查智能体，排查结束后调用其返回的 release
+func Receive(ctx context.Context, n *Notification, incidentTools []string, plan func() (adk.Agent, func(), error)) (*Investigation, string, error) {
+	if !Enabled(ctx) {
+		return nil, "", ErrDisabled
+	}
+	if len(tools.Permitted(ctx, incidentTools)) < len(incidentTools) {
+		log.Printf("[info] alert investigation is not permitted for %s", auth.FromContext(ctx).User)
+		return nil, "", ErrForbidden
+	}
+	if n.GroupKey == "" {
+		return nil, "", errors.New("groupKey is required")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := load(ctx); err != nil {
+		return nil, "", err
+	}
+	kb := vectorstore.KnowledgeBaseFromContext(ctx)
+	latest := latestOf(kb, n.GroupKey)
+	firing := firingAlerts(n)
+	if n.Status == "resolved" || len(firing) == 0 {
+		if latest == nil || latest.ResolvedAt != nil {
+			return nil, OutcomeIgnored, nil
+		}
+		now := time.Now()
+		latest.ResolvedAt = &now
+		if err := save(ctx); err != nil {
+			return nil, "", err
+		}
+		return snapshot(latest), OutcomeResolved, nil
+	}
+	if latest != nil && latest.ResolvedAt == nil && latest.Status != StatusFailed {
+		return snapshot(latest), OutcomeDuplicate, nil
+	}
+	inv := &Investigation{
+		ID:            guid.S(),
+		GroupKey:      n.GroupKey,
+		Receiver:      n.Receiver,
+		GroupLabels:   n.GroupLabels,
+		Alerts:        firing,
+		Status:        StatusRunning,
+		Requester:     auth.FromContext(ctx).User,
+		KnowledgeBase: kb,
+		CreatedAt:     time.Now(),
+	}
+	records[inv.ID] = inv
+	prune(ctx)
+	if err := save(ctx); err != nil {
+		delete(records, inv.ID)
+		return nil, "", err
+	}
+	go run(context.WithoutCancel(ctx), inv.ID, AlertsPrompt(inv.Alerts), plan)
+	return snapshot(inv), OutcomeStarted, nil
+}
+
+// run 在后台执行排查并保存报告，同时执行的排查数不超过 alertmanager_webhook.max_concurrent
+func run(ctx context.Context, id, alerts string, plan func() (adk.Agent, func(), error)) {
+	s := semaphore(ctx)
+	s <- struct{}{}
+	defer func() { <-s }()
+
+	timeout := g.Cfg().MustGet(ctx, "alertmanager_webhook.timeout", DefaultTimeout).Duration()
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ctx = tools.WithRunCache(ctx)
+
+	var (
+		report string
+		detail []string
+	)
+	agent, release, err := plan()
+	if err == nil {
+		report, detail, err = plan_execute_replan.RunPlanAgent(ctx, agent, plan_execute_replan.AlertInvestigationQuery(alerts))
+		release()
+	}
+	if err == nil && report == "" {
+		err = errors.New("empty report")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	inv, ok := records[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	inv.FinishedAt = &now
+	inv.Detail = detail
+	if err != nil {
+		log.Printf("[warn] investigation %s of alert group %s failed: %v", id, inv.GroupKey, err)
+		inv.Status = StatusFailed
+		inv.Error = err.Error()
+	} else {
+		inv.Status = StatusCompleted
+		inv.Report = report
+	}
+	if err = save(ctx); err != nil {
+		log.Printf("[warn] save investigation %s failed: %v", id, err)
+	}
+}
+
+func semaphore(ctx context.Context) chan struct{} {
+	mu.Lock()
+	defer mu.Unlock()
+	if sem == nil {
+		n := g.Cfg().MustGet(ctx, "alertmanager_webhook.max_concurrent", DefaultMaxConcurrent).Int()
+		if n <= 0 {
+			n = DefaultMaxConcurrent
+		}
+		sem = make(chan struct{}, n)
+	}
+	return sem
+}
+
+// AlertsPrompt 将告警整理为写入排查查询的清单，每行一条告警
+func AlertsPrompt(alerts []Alert) string {
+	var b strings.Builder
+	for i, a := range alerts {
+		if i == MaxPromptAlerts {
+			fmt.Fprintf(&b, "- ...(另有 %d 条告警)\n", len(alerts)-MaxPromptAlerts)
+			break
+		}
+		keys := make([]string, 0, len(a.Labels))
+		for k := range a.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		labels := make([]string, 0, len(keys))
+		for _, k := range keys {
+			labels = append(labels, fmt.Sprintf("%s=%q", k, a.Labels[k]))
+		}
+		fmt.Fprintf(&b, "- 告警 %s，标签 {%s}，开始于 %s", a.Labels["alertname"], strings.Join(labels, ", "), a.StartsAt.Format(time.RFC3339))
+		if s := a.Annotations["summary"]; s != "" {
+			fmt.Fprintf(&b, "，摘要：%s", s)
+		}
+		if d := a.Annotations["description"]; d != "" {
+			fmt.Fprintf(&b, "，描述：%s", d)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// firingAlerts 通知中仍在触发的告警
+func firingAlerts(n *Notification) []Alert {
+	out := make([]Alert, 0, len(n.Alerts))
+	for _, a := range n.Alerts {
+		if a.Status != "resolved" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Get 读取排查记录，调用方无权访问排查使用的知识库时视为不存在
+func Get(ctx context.Context, id string) (*Investigation, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := load(ctx); err != nil {
+		return nil, err
+	}
+	inv, ok := records[id]
+	if !ok || !visible(ctx, inv) {
+		return nil, ErrNotFound
+	}
+	return snapshot(inv), nil
+}
+
+// List 按创建时间从新到旧列出调用方可以查看的排查记录，groupKey 与 status 不为空时按其筛选
+func List(ctx context.Context, groupKey, status string) ([]*Investigation, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := load(ctx); err != nil {
+		return nil, err
+	}
+	out := make([]*Investigation, 0, len(records))
+	for _, inv := range records {
+		if (groupKey == "" || inv.GroupKey == groupKey) && (status == "" || inv.Status == status) && visible(ctx, inv) {
+			out = append(out, snapshot(inv))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// visible 调用方是否可以查看排查记录：需有权访问排查使用的知识库，未记录知识库的旧记录只对管理员可见
+func visible(ctx context.Context, inv *Investigation) bool {
+	if inv.KnowledgeBase == "" {
+		return auth.IsAdmin(ctx)
+	}
+	_, err := knowledge.Resolve(ctx, inv.KnowledgeBase)
+	return err == nil
+}
+
+// latestOf 知识库中分组最近一次排查，调用方需持有锁
+func latestOf(kb, groupKey string) *Investigation {
+	var latest *Investigation
+	for _, inv := range records {
+		if inv.KnowledgeBase == kb && inv.GroupKey == groupKey && (latest == nil || inv.CreatedAt.After(latest.CreatedAt)) {
+			latest = inv
+		}
+	}
+	return latest
+}
+
+// prune 记录数超过 alertmanager_webhook.max_investigations 时删除最早的已结束排查，调用方需持有锁
+func prune(ctx context.Context) {
+	max := g.Cfg().MustGet(ctx, "alertmanager_webhook.max_investigations", DefaultMaxInvestigations).Int()
+	if max <= 0 || len(records) <= max {
+		return
+	}
+	finished := make([]*Investigation, 0, len(records))
+	for _, inv := range records {
+		if inv.Status != StatusRunning {
+			finished = append(finished, inv)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].CreatedAt.Before(finished[j].CreatedAt) })
+	for _, inv := range finished {
+		if len(records) <= max {
+			break
+		}
+		delete(records, inv.ID)
+	}
+}
+
+func snapshot(inv *Investigation) *Investigation {
+	s := *inv
+	return &s
+}
+
+func storePath(ctx context.Context) string {
+	return g.Cfg().MustGet(ctx, "alertmanager_webhook.store_path", DefaultStorePath).String()
+}
+
+// load 首次使用时从文件加载排查记录，上次退出时未结束的排查标记为失败，调用方需持有锁
+func load(ctx context.Context) error {
+	if records != nil {
+		return nil
+	}
+	path := storePath(ctx)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		records = map[string]*Investigation{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read investigations %s: %w", path, err)
+	}
+	var list []*Investigation
+	if err = json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to parse investigations %s: %w", path, err)
+	}
+	records = make(map[string]*Investigation, len(list))
+	for _, inv := range list {
+		if inv.Status == StatusRunning {
+			inv.Status = StatusFailed
+			inv.Error = "服务重启，排查中断"
+		}
+		records[inv.ID] = inv
+	}
+	return nil
+}
+
+// save 将排查记录原子地写入文件，调用方需持有锁
+func save(ctx context.Context) error {
+	list := make([]*Investigation, 0, len(records))
+	for _, inv := range records {
+		list = append(list, inv)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal investigations: %w", err)
+	}
+	path := storePath(ctx)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create investigations dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write investigations: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,143 @@
+package investigation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/cloudwego/eino/adk"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setConfig(t *testing.T, content string) {
+	t.Helper()
+	adapter, err := gcfg.NewAdapterContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Cfg().SetAdapter(adapter)
+}
+
+func TestReceive(t *testing.T) {
+	mu.Lock()
+	records, sem = nil, nil
+	mu.Unlock()
+	setConfig(t, "alertmanager_webhook:\n  enabled: false\n")
+	incidentTools := []string{"query_prometheus_alerts", "query_internal_docs"}
+	// 排查在 fail 前保持进行中，之后全部失败
+	hold := make(chan struct{})
+	var failOnce sync.Once
+	fail := func() { failOnce.Do(func() { close(hold) }) }
+	plan := func() (adk.Agent, func(), error) {
+		<-hold
+		return nil, nil, errors.New("no agent in test")
+	}
+	if _, _, err := Receive(context.Background(), &Notification{GroupKey: "g1"}, incidentTools, plan); !errors.Is(err, ErrDisabled) {
+		t.Fatalf("Receive() while disabled error = %v, want ErrDisabled", err)
+	}
+	setConfig(t, fmt.Sprintf("alertmanager_webhook:\n  enabled: true\n  store_path: %q\ntool_policy:\n  default: [\"query_*\"]\n", filepath.Join(t.TempDir(), "investigations.json")))
+	t.Cleanup(func() {
+		fail()
+		waitFinished(t)
+	})
+
+	firing := func(group string) *Notification {
+		return &Notification{GroupKey: group, Status: "firing", Alerts: []Alert{{Status: "firing", Labels: map[string]string{"alertname": "DiskFull"}}}}
+	}
+	resolved := func(group string) *Notification {
+		return &Notification{GroupKey: group, Status: "resolved", Alerts: []Alert{{Status: "resolved", Labels: map[string]string{"alertname": "DiskFull"}}}}
+	}
+	allResolved := &Notification{GroupKey: "g2", Status: "firing", Alerts: []Alert{{Status: "resolved"}}}
+	defaultKB := context.Background()
+	teamKB := vectorstore.WithKnowledgeBase(context.Background(), "team")
+
+	ids := map[string]string{} // 步骤名到排查 ID
+	steps := []struct {
+		name        string
+		ctx         context.Context
+		n           *Notification
+		tools       []string
+		failBefore  bool // 执行该步骤前让进行中的排查全部失败
+		wantOutcome string
+		wantErr     error
+		sameAs      string // 返回的排查应与该步骤相同
+		differentTo string // 返回的排查应与该步骤不同
+	}{
+		{name: "first firing", ctx: defaultKB, n: firing("g1"), wantOutcome: OutcomeStarted},
+		{name: "repeated firing", ctx: defaultKB, n: firing("g1"), wantOutcome: OutcomeDuplicate, sameAs: "first firing"},
+		{name: "other knowledge base", ctx: teamKB, n: firing("g1"), wantOutcome: OutcomeStarted, differentTo: "first firing"},
+		{name: "resolved", ctx: defaultKB, n: resolved("g1"), wantOutcome: OutcomeResolved, sameAs: "first firing"},
+		{name: "resolved again", ctx: defaultKB, n: resolved("g1"), wantOutcome: OutcomeIgnored},
+		{name: "fires after resolved", ctx: defaultKB, n: firing("g1"), wantOutcome: OutcomeStarted, differentTo: "first firing"},
+		{name: "other knowledge base still open", ctx: teamKB, n: firing("g1"), wantOutcome: OutcomeDuplicate, sameAs: "other knowledge base"},
+		{name: "unknown group resolved", ctx: defaultKB, n: resolved("g2"), wantOutcome: OutcomeIgnored},
+		{name: "no firing alerts", ctx: defaultKB, n: allResolved, wantOutcome: OutcomeIgnored},
+		{name: "missing group key", ctx: defaultKB, n: firing(""), wantErr: errors.New("groupKey is required")},
+		{name: "tools not permitted", ctx: defaultKB, n: firing("g3"), tools: []string{"query_prometheus_alerts", "mysql_crud"}, wantErr: ErrForbidden},
+		{name: "fires after failed", ctx: teamKB, n: firing("g1"), failBefore: true, wantOutcome: OutcomeStarted, differentTo: "other knowledge base"},
+	}
+	for _, s := range steps {
+		if s.failBefore {
+			fail()
+			waitFinished(t)
+		}
+		list := incidentTools
+		if s.tools != nil {
+			list = s.tools
+		}
+		inv, outcome, err := Receive(s.ctx, s.n, list, plan)
+		if s.wantErr != nil {
+			if err == nil || (errors.Is(s.wantErr, ErrForbidden) && !errors.Is(err, ErrForbidden)) {
+				t.Errorf("%s: Receive() error = %v, want %v", s.name, err, s.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: Receive() error = %v", s.name, err)
+		}
+		if outcome != s.wantOutcome {
+			t.Errorf("%s: outcome = %s, want %s", s.name, outcome, s.wantOutcome)
+		}
+		if inv == nil {
+			if outcome != OutcomeIgnored {
+				t.Errorf("%s: no investigation returned", s.name)
+			}
+			continue
+		}
+		ids[s.name] = inv.ID
+		if s.sameAs != "" && inv.ID != ids[s.sameAs] {
+			t.Errorf("%s: investigation %s, want the one from %q", s.name, inv.ID, s.sameAs)
+		}
+		if s.differentTo != "" && inv.ID == ids[s.differentTo] {
+			t.Errorf("%s: reused the investigation from %q", s.name, s.differentTo)
+		}
+		if want := vectorstore.KnowledgeBaseFromContext(s.ctx); inv.KnowledgeBase != want {
+			t.Errorf("%s: knowledge base = %s, want %s", s.name, inv.KnowledgeBase, want)
+		}
+	}
+}
+
+// waitFinished 等待后台排查结束，避免测试结束后继续写入临时目录
+func waitFinished(t *testing.T) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		running := 0
+		for _, inv := range records {
+			if inv.Status == StatusRunning {
+				running++
+			}
+		}
+		mu.Unlock()
+		if running == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("background investigations did not finish")
+}
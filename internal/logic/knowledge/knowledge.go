@@ -0,0 +1,205 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/gogf/gf/v2/frame/g"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultRegistryPath 知识库注册表的默认保存路径
+const DefaultRegistryPath = "./data/knowledge_bases.json"
+
+var (
+	// ErrNotFound 知识库不存在
+	ErrNotFound = errors.New("knowledge base not found")
+	// ErrForbidden 调用方无权访问知识库
+	ErrForbidden = errors.New("knowledge base access denied")
+	// ErrExists 知识库已存在
+	ErrExists = errors.New("knowledge base already exists")
+)
+
+// namePattern 知识库名称同时用作 Milvus 分区名与本地文件名，只允许字母开头的字母、数字和下划线
+var namePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,63}$`)
+
+// KnowledgeBase 知识库，Teams 为空表示所有调用方均可访问
+type KnowledgeBase struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Teams       []string  `json:"teams"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+var (
+	mu       sync.Mutex
+	registry map[string]*KnowledgeBase
+)
+
+// Create 创建知识库并初始化对应的向量存储，启用鉴权时仅管理员可操作
+func Create(ctx context.Context, kb *KnowledgeBase) (*KnowledgeBase, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, ErrForbidden
+	}
+	if !namePattern.MatchString(kb.Name) {
+		return nil, fmt.Errorf("invalid knowledge base name %q: must start with a letter and contain only letters, digits and underscores", kb.Name)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := load(ctx); err != nil {
+		return nil, err
+	}
+	if _, ok := registry[kb.Name]; ok || kb.Name == vectorstore.DefaultKnowledgeBase {
+		return nil, ErrExists
+	}
+	if _, err := vectorstore.GetStore(ctx, kb.Name); err != nil {
+		return nil, fmt.Errorf("failed to init store of knowledge base %s: %w", kb.Name, err)
+	}
+	created := &KnowledgeBase{
+		Name:        kb.Name,
+		Description: kb.Description,
+		Teams:       kb.Teams,
+		CreatedAt:   time.Now(),
+	}
+	registry[created.Name] = created
+	if err := save(ctx); err != nil {
+		delete(registry, created.Name)
+		return nil, err
+	}
+	return created, nil
+}
+
+// List 列出调用方可访问的知识库，默认知识库始终在列
+func List(ctx context.Context) ([]*KnowledgeBase, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := load(ctx); err != nil {
+		return nil, err
+	}
+	list := []*KnowledgeBase{defaultKnowledgeBase()}
+	for _, kb := range registry {
+		if auth.Allowed(ctx, kb.Teams) {
+			list = append(list, kb)
+		}
+	}
+	sort.Slice(list[1:], func(i, j int) bool {
+		return list[i+1].Name < list[j+1].Name
+	})
+	return list, nil
+}
+
+// Resolve 确定本次请求使用的知识库
+// 指定了知识库时校验其存在且调用方有权访问；否则使用绑定到调用方团队的知识库，没有则使用默认知识库
+func Resolve(ctx context.Context, requested string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := load(ctx); err != nil {
+		return "", err
+	}
+	if requested != "" {
+		if requested == vectorstore.DefaultKnowledgeBase {
+			return requested, nil
+		}
+		kb, ok := registry[requested]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, requested)
+		}
+		if !auth.Allowed(ctx, kb.Teams) {
+			return "", fmt.Errorf("%w: %s", ErrForbidden, requested)
+		}
+		return requested, nil
+	}
+	team := auth.FromContext(ctx).Team
+	if team != "" {
+		names := make([]string, 0, len(registry))
+		for name := range registry {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, t := range registry[name].Teams {
+				if t == team {
+					return name, nil
+				}
+			}
+		}
+	}
+	return vectorstore.DefaultKnowledgeBase, nil
+}
+
+// WithResolved 解析知识库并写入上下文，供检索与索引组件使用
+func WithResolved(ctx context.Context, requested string) (context.Context, error) {
+	kb, err := Resolve(ctx, requested)
+	if err != nil {
+		return ctx, err
+	}
+	return vectorstore.WithKnowledgeBase(ctx, kb), nil
+}
+
+func defaultKnowledgeBase() *KnowledgeBase {
+	return &KnowledgeBase{
+		Name:        vectorstore.DefaultKnowledgeBase,
+		Description: "默认知识库，所有调用方均可访问",
+	}
+}
+
+func registryPath(ctx context.Context) string {
+	return g.Cfg().MustGet(ctx, "knowledge_base.registry_path", DefaultRegistryPath).String()
+}
+
+// load 首次使用时从文件加载注册表，调用方需持有锁
+func load(ctx context.Context) error {
+	if registry != nil {
+		return nil
+	}
+	path := registryPath(ctx)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		registry = map[string]*KnowledgeBase{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read knowledge base registry %s: %w", path, err)
+	}
+	var list []*KnowledgeBase
+	if err = json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to parse knowledge base registry %s: %w", path, err)
+	}
+	registry = make(map[string]*KnowledgeBase, len(list))
+	for _, kb := range list {
+		registry[kb.Name] = kb
+	}
+	return nil
+}
+
+// save 将注册表原子地写入文件，调用方需持有锁
+func save(ctx context.Context) error {
+	list := make([]*KnowledgeBase, 0, len(registry))
+	for _, kb := range registry {
+		list = append(list, kb)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal knowledge base registry: %w", err)
+	}
+	path := registryPath(ctx)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create knowledge base registry dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write knowledge base registry: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
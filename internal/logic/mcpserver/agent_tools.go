@@ -0,0 +1,132 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/agent/chat_pipeline"
+	"github.com/NuyoahCh/eocall/internal/ai/agent/plan_execute_replan"
+	"github.com/NuyoahCh/eocall/internal/ai/retriever"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/internal/logic/approval"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
+	"github.com/NuyoahCh/eocall/utility/log_call_back"
+	"github.com/NuyoahCh/eocall/utility/mem"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/util/guid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"strings"
+)
+
+// addAgentTools 注册对话智能体与告警排查智能体
+func (s *Server) addAgentTools() {
+	s.mcp.AddTool(mcp.NewTool(ToolChat,
+		mcp.WithDescription("Ask the EOCall operations assistant a question. It answers from internal documentation with citations and can query alerts, databases and other tools on the caller's behalf. Side-effecting tool calls wait for human approval through the EOCall approval API."),
+		mcp.WithString("question", mcp.Required(), mcp.Description("The question to ask")),
+		mcp.WithString("session_id", mcp.Description("Conversation ID to keep multi-turn history. Leave empty to use the MCP session")),
+		mcp.WithString(knowledgeBaseArg, mcp.Description("Knowledge base to search. Leave empty to use the knowledge base bound to the caller's team, or the default one")),
+	), s.chat)
+	s.mcp.AddTool(mcp.NewTool(ToolAIOps,
+		mcp.WithDescription("Run an AIOps investigation of all active Prometheus alerts: look up the runbooks of each alert, analyze root causes and return an alert analysis report. It may take several minutes."),
+		mcp.WithString(knowledgeBaseArg, mcp.Description("Knowledge base holding the runbooks. Leave empty to use the knowledge base bound to the caller's team, or the default one")),
+	), s.aiOps)
+}
+
+// chat 调用对话智能体，与 /chat 接口相同：工具等待审批时阻塞，审批单通过审批接口处理
+func (s *Server) chat(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	question, err := req.RequireString("question")
+	if err != nil || strings.TrimSpace(question) == "" {
+		return mcp.NewToolResultError("question is required"), nil
+	}
+	sessionID := req.GetString("session_id", "")
+	if sessionID == "" {
+		sessionID = "mcp-" + guid.S()
+		if session := server.ClientSessionFromContext(ctx); session != nil && session.SessionID() != "" {
+			sessionID = "mcp-" + session.SessionID()
+		}
+	}
+	ctx, err = knowledge.WithResolved(ctx, req.GetString(knowledgeBaseArg, ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ctx = tools.WithSession(ctx, sessionID)
+	ctx = tools.WithRunCache(ctx)
+	ctx, trace := chat_pipeline.WithTrace(ctx)
+	opts := append(chat_pipeline.RetrievalOptions(ctx, &retriever.Overrides{}), compose.WithCallbacks(log_call_back.LogCallback(nil)))
+	userMessage := &chat_pipeline.UserMessage{
+		ID:      sessionID,
+		Query:   question,
+		History: mem.GetSimpleMemory(sessionID).GetMessages(),
+	}
+
+	runner, release, err := s.agents.Chat()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	runID := guid.S()
+	opts = append(opts, compose.WithCheckPointID(runID))
+	out, err := runner.Invoke(ctx, userMessage, opts...)
+	for err != nil {
+		resumeCtx, rejected, ok := approval.Await(ctx, err, runID, sessionID, nil)
+		if !ok {
+			return nil, err
+		}
+		if rejected != nil {
+			out, err = schema.AssistantMessage(approval.RejectedMessage(rejected), nil), nil
+			break
+		}
+		out, err = runner.Invoke(resumeCtx, userMessage, opts...)
+	}
+	mem.GetSimpleMemory(sessionID).SetMessages(schema.UserMessage(question))
+	mem.GetSimpleMemory(sessionID).SetMessages(schema.SystemMessage(out.Content))
+	return mcp.NewToolResultText(out.Content + formatSources(trace, out.Content)), nil
+}
+
+// formatSources 在回答后列出引用的文档，文档以 runbook 资源地址表示，便于客户端读取原文
+func formatSources(trace *chat_pipeline.Trace, answer string) string {
+	sources, cited := chat_pipeline.BuildSources(trace.Snapshot().Documents, answer)
+	if !cited {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\n来源：")
+	for _, src := range sources {
+		if !src.Cited {
+			continue
+		}
+		fmt.Fprintf(&b, "\n[%d] %s", src.Index, runbookURIOf(src.Source))
+		if src.HeaderPath != "" {
+			b.WriteString(" " + src.HeaderPath)
+		}
+	}
+	return b.String()
+}
+
+// aiOps 分析全部活跃告警并生成报告，与 /ai_ops 接口相同
+func (s *Server) aiOps(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ctx, err := knowledge.WithResolved(ctx, req.GetString(knowledgeBaseArg, ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ctx = tools.WithRunCache(ctx)
+	plan, release, err := s.agents.Plan()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	// 列表与调用前的校验之后排查智能体可能已重新构建，按本次运行使用的智能体再次校验
+	if len(tools.Permitted(ctx, plan.Tools)) < len(plan.Tools) {
+		return mcp.NewToolResultError(fmt.Sprintf("工具 %s 不可用", ToolAIOps)), nil
+	}
+	resp, _, err := plan_execute_replan.RunPlanAgent(ctx, plan.Agent, plan_execute_replan.AIOpsQuery)
+	if err != nil {
+		return nil, err
+	}
+	if resp == "" {
+		return nil, errors.New("内部错误")
+	}
+	return mcp.NewToolResultText(resp), nil
+}
@@ -0,0 +1,167 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/tools"
+	"github.com/NuyoahCh/eocall/internal/logic/agents"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"log"
+	"slices"
+)
+
+// 智能体工具的名称，工具策略只作用于智能体内部调用的工具，不作用于这两个名称
+const (
+	ToolChat  = "chat"
+	ToolAIOps = "ai_ops"
+)
+
+// knowledgeBaseArg 检索类工具额外接受的知识库参数，与 REST 接口的 knowledge_base 含义相同
+const knowledgeBaseArg = "knowledge_base"
+
+// Server 将 EOCall 的工具、智能体与知识库文档以 MCP 协议对外提供
+// 调用方身份与 REST 接口使用同一套访问令牌，工具受 tool_policy 限制，知识库按团队隔离
+type Server struct {
+	mcp    *server.MCPServer
+	agents *agents.Agents
+	// shared stdio 模式只有一个调用方，文档列表写入全局资源；HTTP 模式按会话写入
+	shared bool
+}
+
+// New 创建 MCP 服务并注册工具与文档资源，ctx 需在服务的生命周期内保持有效
+func New(ctx context.Context, a *agents.Agents) (*Server, error) {
+	s := &Server{agents: a}
+	hooks := &server.Hooks{}
+	hooks.AddBeforeListResources(func(ctx context.Context, _ any, _ *mcp.ListResourcesRequest) {
+		s.refreshRunbooks(ctx)
+	})
+	s.mcp = server.NewMCPServer("eocall", "1.0.0",
+		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(false, false),
+		server.WithToolFilter(s.filterTools),
+		server.WithToolHandlerMiddleware(s.checkPolicy),
+		server.WithHooks(hooks),
+		server.WithRecovery(),
+		server.WithInstructions("EOCall 运维助手：查询内部文档、Prometheus 告警，发起告警排查或与对话智能体交流。知识库文档以 runbook:// 资源提供。"),
+	)
+
+	for _, t := range []tool.InvokableTool{
+		tools.NewQueryInternalDocsTool(),
+		tools.NewPrometheusAlertsQueryTool(),
+		tools.NewGetCurrentTimeTool(),
+	} {
+		if err := s.addTool(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+	s.addAgentTools()
+	s.addRunbookTemplate()
+	return s, nil
+}
+
+// addTool 将 eino 工具注册为 MCP 工具，参数定义沿用工具的 JSON Schema
+func (s *Server) addTool(ctx context.Context, t tool.InvokableTool) error {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return err
+	}
+	params, err := info.ParamsOneOf.ToJSONSchema()
+	if err != nil {
+		return fmt.Errorf("convert params of tool %s failed: %w", info.Name, err)
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("marshal params of tool %s failed: %w", info.Name, err)
+	}
+	// 检索内部文档的工具需要调用方指定知识库，智能体内部调用时知识库来自请求
+	scoped := info.Name == "query_internal_docs"
+	if scoped {
+		if raw, err = withKnowledgeBaseArg(raw); err != nil {
+			return fmt.Errorf("extend params of tool %s failed: %w", info.Name, err)
+		}
+	}
+	s.mcp.AddTool(mcp.NewToolWithRawSchema(info.Name, info.Desc, raw), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetArguments()
+		if args == nil {
+			args = map[string]any{}
+		}
+		if scoped {
+			kb, _ := args[knowledgeBaseArg].(string)
+			delete(args, knowledgeBaseArg)
+			var err error
+			if ctx, err = knowledge.WithResolved(ctx, kb); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		input, err := json.Marshal(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		out, err := t.InvokableRun(ctx, string(input))
+		if err != nil {
+			log.Printf("[warn] mcp call of tool %s failed: %v", info.Name, err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(out), nil
+	})
+	return nil
+}
+
+// withKnowledgeBaseArg 在工具参数定义中增加可选的 knowledge_base 参数
+func withKnowledgeBaseArg(raw json.RawMessage) (json.RawMessage, error) {
+	var params map[string]any
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	props, _ := params["properties"].(map[string]any)
+	if props == nil {
+		props = map[string]any{}
+		params["properties"] = props
+	}
+	props[knowledgeBaseArg] = map[string]any{
+		"type":        "string",
+		"description": "Knowledge base to search. Leave empty to use the knowledge base bound to the caller's team, or the default one",
+	}
+	return json.Marshal(params)
+}
+
+// allowed 判断调用方是否可以使用指定的 MCP 工具
+// 对话智能体始终可用，其内部调用的工具按策略筛选；告警排查需要调用方有权使用当前排查智能体的全部工具
+func (s *Server) allowed(ctx context.Context, name string) bool {
+	switch name {
+	case ToolChat:
+		return true
+	case ToolAIOps:
+		incidentTools, err := s.agents.IncidentTools()
+		if err != nil {
+			log.Printf("[warn] get incident agent failed: %v", err)
+			return false
+		}
+		return len(tools.Permitted(ctx, incidentTools)) == len(incidentTools)
+	default:
+		return len(tools.Permitted(ctx, []string{name})) == 1
+	}
+}
+
+// filterTools 工具列表只返回调用方可以使用的工具
+func (s *Server) filterTools(ctx context.Context, list []mcp.Tool) []mcp.Tool {
+	return slices.DeleteFunc(list, func(t mcp.Tool) bool {
+		return !s.allowed(ctx, t.Name)
+	})
+}
+
+// checkPolicy 调用时再次校验工具策略，防止调用方绕过工具列表直接调用
+func (s *Server) checkPolicy(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !s.allowed(ctx, req.Params.Name) {
+			log.Printf("[info] mcp tool %s is not permitted for %s", req.Params.Name, auth.FromContext(ctx).User)
+			return mcp.NewToolResultError(fmt.Sprintf("工具 %s 不可用", req.Params.Name)), nil
+		}
+		return next(ctx, req)
+	}
+}
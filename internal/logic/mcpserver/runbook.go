@@ -0,0 +1,179 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/NuyoahCh/eocall/internal/logic/knowledge"
+	"github.com/NuyoahCh/eocall/utility/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runbookScheme 知识库文档的资源地址格式为 runbook://<知识库>/<文件名>
+const runbookScheme = "runbook://"
+
+// runbook 知识库目录中的一篇文档
+type runbook struct {
+	KnowledgeBase string
+	Name          string
+	Path          string
+}
+
+func (r *runbook) uri() string {
+	return runbookScheme + url.PathEscape(r.KnowledgeBase) + "/" + url.PathEscape(r.Name)
+}
+
+// runbookDir 知识库文档所在目录，与上传接口的保存位置一致
+func runbookDir(kb string) string {
+	if kb == vectorstore.DefaultKnowledgeBase {
+		return common.FileDir
+	}
+	return filepath.Join(common.FileDir, kb)
+}
+
+// runbookURIOf 将文档来源路径转换为资源地址，不在知识库目录中的来源原样返回
+func runbookURIOf(source string) string {
+	rel, err := filepath.Rel(common.FileDir, source)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return source
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	switch len(parts) {
+	case 1:
+		return (&runbook{KnowledgeBase: vectorstore.DefaultKnowledgeBase, Name: parts[0]}).uri()
+	case 2:
+		return (&runbook{KnowledgeBase: parts[0], Name: parts[1]}).uri()
+	default:
+		return source
+	}
+}
+
+// listRunbooks 列出调用方可访问的知识库中的全部文档
+func listRunbooks(ctx context.Context) ([]*runbook, error) {
+	kbs, err := knowledge.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []*runbook
+	for _, kb := range kbs {
+		dir := runbookDir(kb.Name)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Printf("[warn] list runbooks of knowledge base %s failed: %v", kb.Name, err)
+			}
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			out = append(out, &runbook{KnowledgeBase: kb.Name, Name: e.Name(), Path: filepath.Join(dir, e.Name())})
+		}
+	}
+	return out, nil
+}
+
+// addRunbookTemplate 注册文档资源模板，客户端可以直接按地址读取未出现在列表中的文档
+func (s *Server) addRunbookTemplate() {
+	s.mcp.AddResourceTemplate(mcp.NewResourceTemplate(runbookScheme+"{knowledge_base}/{name}", "runbook",
+		mcp.WithTemplateDescription("A runbook or document indexed into an EOCall knowledge base"),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	), s.readRunbook)
+}
+
+// refreshRunbooks 在列出资源前按调用方的知识库权限刷新文档列表
+// HTTP 模式写入会话资源，不同调用方互不可见；没有会话的请求只能通过资源模板读取
+func (s *Server) refreshRunbooks(ctx context.Context) {
+	list, err := listRunbooks(ctx)
+	if err != nil {
+		log.Printf("[warn] list runbooks failed: %v", err)
+		return
+	}
+	resources := make(map[string]server.ServerResource, len(list))
+	for _, r := range list {
+		resources[r.uri()] = server.ServerResource{
+			Resource: mcp.NewResource(r.uri(), r.KnowledgeBase+"/"+r.Name,
+				mcp.WithResourceDescription(fmt.Sprintf("知识库 %s 中的文档 %s", r.KnowledgeBase, r.Name)),
+				mcp.WithMIMEType(runbookMIMEType(r.Name)),
+			),
+			Handler: s.readRunbook,
+		}
+	}
+	if s.shared {
+		all := make([]server.ServerResource, 0, len(resources))
+		for _, res := range resources {
+			all = append(all, res)
+		}
+		s.mcp.SetResources(all...)
+		return
+	}
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithResources)
+	if ok && session.SessionID() != "" {
+		session.SetSessionResources(resources)
+	}
+}
+
+// readRunbook 读取文档内容，每次读取都校验调用方对知识库的访问权限
+func (s *Server) readRunbook(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	r, err := parseRunbookURI(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = knowledge.Resolve(ctx, r.KnowledgeBase); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(r.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("runbook %s not found", req.Params.URI)
+		}
+		return nil, fmt.Errorf("read runbook %s failed: %w", req.Params.URI, err)
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      req.Params.URI,
+		MIMEType: runbookMIMEType(r.Name),
+		Text:     string(data),
+	}}, nil
+}
+
+// parseRunbookURI 解析资源地址，文件名不能包含路径，防止读取知识库目录之外的文件
+func parseRunbookURI(uri string) (*runbook, error) {
+	rest, ok := strings.CutPrefix(uri, runbookScheme)
+	if !ok {
+		return nil, fmt.Errorf("invalid runbook uri %s", uri)
+	}
+	rawKB, rawName, ok := strings.Cut(rest, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid runbook uri %s", uri)
+	}
+	kb, err := url.PathUnescape(rawKB)
+	if err != nil {
+		return nil, fmt.Errorf("invalid runbook uri %s: %w", uri, err)
+	}
+	name, err := url.PathUnescape(rawName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid runbook uri %s: %w", uri, err)
+	}
+	for _, part := range []string{kb, name} {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) {
+			return nil, fmt.Errorf("invalid runbook uri %s", uri)
+		}
+	}
+	return &runbook{KnowledgeBase: kb, Name: name, Path: filepath.Join(runbookDir(kb), name)}, nil
+}
+
+func runbookMIMEType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown":
+		return "text/markdown"
+	default:
+		return "text/plain"
+	}
+}
@@ -0,0 +1,100 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/mark3labs/mcp-go/server"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// DefaultPath HTTP 模式的默认挂载路径
+const DefaultPath = "/mcp"
+
+// TokenEnv stdio 模式读取访问令牌的环境变量，优先于配置 mcp_server.stdio_token
+const TokenEnv = "EOCALL_MCP_TOKEN"
+
+// Enabled 是否在 HTTP 服务上提供 MCP 服务
+func Enabled(ctx context.Context) bool {
+	return g.Cfg().MustGet(ctx, "mcp_server.enabled").Bool()
+}
+
+// Path HTTP 模式的挂载路径
+func Path(ctx context.Context) string {
+	return "/" + strings.Trim(g.Cfg().MustGet(ctx, "mcp_server.path", DefaultPath).String(), "/")
+}
+
+// Handler HTTP 模式的处理器：path 为 Streamable HTTP 端点，path/sse 与 path/message 为 SSE 端点
+// 与 REST 接口相同，从 Authorization: Bearer <token> 中识别调用方，配置 auth.required 后拒绝无效令牌
+func (s *Server) Handler(path string) http.Handler {
+	streamable := server.NewStreamableHTTPServer(s.mcp,
+		server.WithEndpointPath(path),
+		server.WithHTTPContextFunc(withRequestIdentity),
+	)
+	sse := server.NewSSEServer(s.mcp,
+		server.WithStaticBasePath(path),
+		server.WithSSEContextFunc(withRequestIdentity),
+	)
+	mux := http.NewServeMux()
+	mux.Handle(path, streamable)
+	mux.Handle(path+"/sse", sse)
+	mux.Handle(path+"/message", sse)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.Authenticate(r.Context(), bearerToken(r)); !ok && auth.Required(r.Context()) {
+			http.Error(w, "未授权的访问", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// withRequestIdentity 将请求携带的调用方身份写入工具与资源处理的上下文
+func withRequestIdentity(ctx context.Context, r *http.Request) context.Context {
+	if id, ok := auth.Authenticate(ctx, bearerToken(r)); ok {
+		return auth.WithIdentity(ctx, id)
+	}
+	return ctx
+}
+
+func bearerToken(r *http.Request) string {
+	return strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
+
+// ServeStdio 以 stdio 方式提供 MCP 服务，直到输入结束或进程收到退出信号
+// 调用方身份来自环境变量 EOCALL_MCP_TOKEN 或配置 mcp_server.stdio_token；
+// 标准输出专用于协议消息，框架日志改写到标准错误
+func (s *Server) ServeStdio(ctx context.Context) error {
+	token := os.Getenv(TokenEnv)
+	if token == "" {
+		token = g.Cfg().MustGet(ctx, "mcp_server.stdio_token").String()
+	}
+	id, ok := auth.Authenticate(ctx, token)
+	if !ok && (token != "" || auth.Required(ctx)) {
+		return errors.New("mcp stdio: missing or invalid access token, set " + TokenEnv)
+	}
+
+	g.Log().SetStdoutPrint(false)
+	g.Log().SetWriter(os.Stderr)
+	s.shared = true
+
+	stdio := server.NewStdioServer(s.mcp)
+	stdio.SetErrorLogger(log.New(os.Stderr, "", log.LstdFlags))
+	if ok {
+		stdio.SetContextFunc(func(ctx context.Context) context.Context {
+			return auth.WithIdentity(ctx, id)
+		})
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err := stdio.Listen(ctx, os.Stdin, os.Stdout)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"github.com/NuyoahCh/eocall/internal/ai/vectorstore"
+	"github.com/NuyoahCh/eocall/internal/controller/approval"
 	"github.com/NuyoahCh/eocall/internal/controller/chat"
+	"github.com/NuyoahCh/eocall/internal/controller/investigation"
+	"github.com/NuyoahCh/eocall/internal/controller/knowledge"
+	"github.com/NuyoahCh/eocall/internal/logic/agents"
+	"github.com/NuyoahCh/eocall/internal/logic/mcpserver"
+	"github.com/NuyoahCh/eocall/utility/client"
 	"github.com/NuyoahCh/eocall/utility/common"
 	"github.com/NuyoahCh/eocall/utility/middleware"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gctx"
+	"os"
 )
 
 func main() {
@@ -16,12 +24,43 @@ func main() {
 		panic(err)
 	}
 	common.FileDir = fileDir.String()
+	// 服务退出时关闭向量存储与共享的 Milvus 连接
+	defer client.CloseMilvusClient()
+	defer vectorstore.CloseStore()
+	// 校验向量模型与 Milvus collection 是否兼容
+	if vectorstore.StoreType(ctx) == vectorstore.TypeMilvus {
+		if err = client.CheckStartupCompat(ctx); err != nil {
+			panic(err)
+		}
+	}
+	// go run main.go mcp 以 stdio 方式提供 MCP 服务，供 IDE 助手等本地客户端启动
+	if len(os.Args) > 1 && os.Args[1] == "mcp" {
+		mcpServer, err := mcpserver.New(ctx, agents.Shared())
+		if err != nil {
+			panic(err)
+		}
+		if err = mcpServer.ServeStdio(ctx); err != nil {
+			panic(err)
+		}
+		return
+	}
 	s := g.Server()
 	s.Group("/api", func(group *ghttp.RouterGroup) {
 		group.Middleware(middleware.CORSMiddleware)
 		group.Middleware(middleware.ResponseMiddleware)
-		group.Bind(chat.NewV1())
+		group.Middleware(middleware.AuthMiddleware)
+		group.Bind(chat.NewV1(), knowledge.NewV1(), approval.NewV1(), investigation.NewV1())
 	})
+	if mcpserver.Enabled(ctx) {
+		mcpServer, err := mcpserver.New(ctx, agents.Shared())
+		if err != nil {
+			panic(err)
+		}
+		path := mcpserver.Path(ctx)
+		handler := ghttp.WrapH(mcpServer.Handler(path))
+		s.BindHandler(path, handler)
+		s.BindHandler(path+"/*any", handler)
+	}
 	s.SetPort(6872)
 	s.Run()
 }
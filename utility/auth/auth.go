@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// RoleAdmin 管理员角色，可访问全部知识库并执行管理操作
+const RoleAdmin = "admin"
+
+// Identity 调用方身份
+type Identity struct {
+	User  string   `json:"user"`
+	Team  string   `json:"team"`
+	Roles []string `json:"roles"`
+	// Anonymous 未携带有效凭证的调用方
+	Anonymous bool `json:"anonymous"`
+}
+
+// HasRole 判断是否拥有指定角色
+func (i *Identity) HasRole(role string) bool {
+	for _, r := range i.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// userConfig 配置文件中 auth.users 的单个用户
+type userConfig struct {
+	Name  string   `json:"name"`
+	Token string   `json:"token"`
+	Team  string   `json:"team"`
+	Roles []string `json:"roles"`
+}
+
+type identityKey struct{}
+
+var anonymous = &Identity{User: "anonymous", Anonymous: true}
+
+// WithIdentity 将调用方身份写入上下文
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext 读取上下文中的调用方身份，未设置时返回匿名身份
+func FromContext(ctx context.Context) *Identity {
+	if id, ok := ctx.Value(identityKey{}).(*Identity); ok && id != nil {
+		return id
+	}
+	return anonymous
+}
+
+// Enabled 是否配置了用户，未配置时不做任何访问控制
+func Enabled(ctx context.Context) bool {
+	return len(loadUsers(ctx)) > 0
+}
+
+// Required 是否要求所有请求携带有效凭证
+func Required(ctx context.Context) bool {
+	return g.Cfg().MustGet(ctx, "auth.required").Bool()
+}
+
+// Authenticate 根据访问令牌查找用户身份
+func Authenticate(ctx context.Context, token string) (*Identity, bool) {
+	if token == "" {
+		return nil, false
+	}
+	for _, u := range loadUsers(ctx) {
+		if u.Token != "" && subtle.ConstantTimeCompare([]byte(u.Token), []byte(token)) == 1 {
+			return &Identity{User: u.Name, Team: u.Team, Roles: u.Roles}, true
+		}
+	}
+	return nil, false
+}
+
+// Allowed 判断调用方是否可以访问限定给 teams 的资源，teams 为空表示不限制
+// 未启用鉴权或调用方为管理员时始终允许
+func Allowed(ctx context.Context, teams []string) bool {
+	if len(teams) == 0 || !Enabled(ctx) {
+		return true
+	}
+	id := FromContext(ctx)
+	if id.HasRole(RoleAdmin) {
+		return true
+	}
+	for _, t := range teams {
+		if id.Team != "" && t == id.Team {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin 判断调用方是否可以执行管理操作，未启用鉴权时始终允许
+func IsAdmin(ctx context.Context) bool {
+	return !Enabled(ctx) || FromContext(ctx).HasRole(RoleAdmin)
+}
+
+func loadUsers(ctx context.Context) []userConfig {
+	var users []userConfig
+	_ = g.Cfg().MustGet(ctx, "auth.users").Scan(&users)
+	return users
+}
@@ -3,21 +3,24 @@ package client
 import (
 	"context"
 	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/embedder"
 	"github.com/NuyoahCh/eocall/utility/common"
 	cli "github.com/milvus-io/milvus-sdk-go/v2/client"
 	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"strconv"
 )
 
-// NewMilvusClient 初始化数据库客户端链接
+// NewMilvusClient 新建数据库客户端链接，并确保数据库与 collection 已初始化
+// 服务内请使用 GetMilvusClient 复用进程级共享的客户端
 func NewMilvusClient(ctx context.Context) (cli.Client, error) {
+	conf := GetMilvusConfig(ctx)
 	// 1. 先连接default数据库
-	defaultClient, err := cli.NewClient(ctx, cli.Config{
-		Address: "localhost:19530",
-		DBName:  "default",
-	})
+	defaultClient, err := conf.dial(ctx, "default")
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to default database: %w", err)
 	}
+	// 初始化完成后关闭default数据库连接
+	defer defaultClient.Close()
 
 	// 2. 检查agent数据库是否存在，不存在则创建
 	databases, err := defaultClient.ListDatabases(ctx)
@@ -26,116 +29,121 @@ func NewMilvusClient(ctx context.Context) (cli.Client, error) {
 	}
 	agentDBExists := false
 	for _, db := range databases {
-		if db.Name == common.MilvusDBName {
+		if db.Name == conf.DBName {
 			agentDBExists = true
 			break
 		}
 	}
 	if !agentDBExists {
-		err = defaultClient.CreateDatabase(ctx, common.MilvusDBName)
+		err = defaultClient.CreateDatabase(ctx, conf.DBName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create agent database: %w", err)
 		}
 	}
 
 	// 3. 创建连接到agent数据库的客户端
-	agentClient, err := cli.NewClient(ctx, cli.Config{
-		Address: "localhost:19530",
-		DBName:  common.MilvusDBName,
-	})
+	agentClient, err := conf.dial(ctx, conf.DBName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to agent database: %w", err)
 	}
 
 	// 4. 检查biz collection是否存在，不存在则创建
+	if err = ensureCollection(ctx, agentClient, conf); err != nil {
+		agentClient.Close()
+		return nil, err
+	}
+	return agentClient, nil
+}
+
+// ensureCollection 检查 collection 是否存在，不存在则按当前向量模型创建并建立索引
+func ensureCollection(ctx context.Context, agentClient cli.Client, conf *MilvusConfig) error {
 	collections, err := agentClient.ListCollections(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list collections: %w", err)
+		return fmt.Errorf("failed to list collections: %w", err)
 	}
-
-	bizCollectionExists := false
 	for _, collection := range collections {
-		if collection.Name == common.MilvusCollectionName {
-			bizCollectionExists = true
-			break
+		if collection.Name == conf.Collection {
+			return nil
 		}
 	}
 
-	if !bizCollectionExists {
-		// 创建biz collection的schema
-		schema := &entity.Schema{
-			CollectionName: common.MilvusCollectionName,
-			Description:    "Business knowledge collection",
-			Fields:         fields,
-		}
-
-		err = agentClient.CreateCollection(ctx, schema, entity.DefaultShardNumber)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create biz collection: %w", err)
-		}
-
-		// 为id字段创建auto index索引
-		idIndex, err := entity.NewIndexAUTOINDEX(entity.L2)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create id index: %w", err)
-		}
-		err = agentClient.CreateIndex(ctx, common.MilvusCollectionName, "id", idIndex, false)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create id index: %w", err)
-		}
+	// 创建biz collection的schema
+	dim := embedder.Dimensions(ctx)
+	schema := &entity.Schema{
+		CollectionName: conf.Collection,
+		Description:    "Business knowledge collection",
+		Fields:         newFields(dim * common.BinaryVectorBitsPerDim),
+	}
 
-		// 为content字段创建auto index索引
-		contentIndex, err := entity.NewIndexAUTOINDEX(entity.L2)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create content index: %w", err)
-		}
-		err = agentClient.CreateIndex(ctx, common.MilvusCollectionName, "content", contentIndex, false)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create content index: %w", err)
-		}
+	// 记录建表时使用的向量模型，启动时据此校验兼容性
+	err = agentClient.CreateCollection(ctx, schema, conf.ShardNum,
+		cli.WithCollectionProperty(common.MilvusPropEmbeddingModel, embedder.ModelName(ctx)),
+		cli.WithCollectionProperty(common.MilvusPropEmbeddingDim, strconv.Itoa(dim)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create biz collection: %w", err)
+	}
 
-		// 为vector字段创建auto index索引
-		vectorIndex, err := entity.NewIndexAUTOINDEX(entity.HAMMING)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create vector index: %w", err)
-		}
-		err = agentClient.CreateIndex(ctx, common.MilvusCollectionName, "vector", vectorIndex, false)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create vector index: %w", err)
-		}
+	// 为id字段创建auto index索引
+	idIndex, err := entity.NewIndexAUTOINDEX(entity.L2)
+	if err != nil {
+		return fmt.Errorf("failed to create id index: %w", err)
+	}
+	err = agentClient.CreateIndex(ctx, conf.Collection, "id", idIndex, false)
+	if err != nil {
+		return fmt.Errorf("failed to create id index: %w", err)
 	}
 
-	// 关闭default数据库连接
-	defaultClient.Close()
+	// 为content字段创建auto index索引
+	contentIndex, err := entity.NewIndexAUTOINDEX(entity.L2)
+	if err != nil {
+		return fmt.Errorf("failed to create content index: %w", err)
+	}
+	err = agentClient.CreateIndex(ctx, conf.Collection, "content", contentIndex, false)
+	if err != nil {
+		return fmt.Errorf("failed to create content index: %w", err)
+	}
 
-	return agentClient, nil
+	// 为vector字段创建auto index索引
+	vectorIndex, err := entity.NewIndexAUTOINDEX(entity.HAMMING)
+	if err != nil {
+		return fmt.Errorf("failed to create vector index: %w", err)
+	}
+	err = agentClient.CreateIndex(ctx, conf.Collection, "vector", vectorIndex, false)
+	if err != nil {
+		return fmt.Errorf("failed to create vector index: %w", err)
+	}
+	return nil
 }
 
-var fields = []*entity.Field{
-	{
-		Name:     "id",
-		DataType: entity.FieldTypeVarChar,
-		TypeParams: map[string]string{
-			"max_length": "256",
+// newFields 构建 biz collection 的字段，vectorDim 为二进制向量的位数
+func newFields(vectorDim int) []*entity.Field {
+	return []*entity.Field{
+		{
+			Name:     "id",
+			DataType: entity.FieldTypeVarChar,
+			TypeParams: map[string]string{
+				"max_length": "256",
+			},
+			PrimaryKey: true,
 		},
-		PrimaryKey: true,
-	},
-	{
-		Name:     "vector", // 确保字段名匹配
-		DataType: entity.FieldTypeBinaryVector,
-		TypeParams: map[string]string{
-			"dim": "65536",
+		{
+			Name:     "vector", // 确保字段名匹配
+			DataType: entity.FieldTypeBinaryVector,
+			TypeParams: map[string]string{
+				"dim": strconv.Itoa(vectorDim),
+			},
 		},
-	},
-	{
-		Name:     "content",
-		DataType: entity.FieldTypeVarChar,
-		TypeParams: map[string]string{
-			"max_length": "8192",
+		{
+			Name:     "content",
+			DataType: entity.FieldTypeVarChar,
+			TypeParams: map[string]string{
+				"max_length": "8192",
+			},
 		},
-	},
-	{
-		Name:     "metadata",
-		DataType: entity.FieldTypeJSON,
-	},
+		{
+			Name:     "metadata",
+			DataType: entity.FieldTypeJSON,
+		},
+	}
 }
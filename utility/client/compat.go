@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/NuyoahCh/eocall/internal/ai/embedder"
+	"github.com/NuyoahCh/eocall/utility/common"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/gogf/gf/v2/frame/g"
+	cli "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// 兼容性校验模式
+const (
+	CompatModeStrict = "strict" // 不兼容时拒绝启动
+	CompatModeWarn   = "warn"   // 不兼容时仅打印告警
+	CompatModeOff    = "off"    // 跳过校验
+)
+
+// compatProbeText 用于探测向量维度的文本
+const compatProbeText = "eocall embedding compatibility probe"
+
+// CheckStartupCompat 启动时校验向量模型与 Milvus collection 是否兼容，按 milvus_compat_check 配置决定拒绝启动还是告警
+func CheckStartupCompat(ctx context.Context) error {
+	mode := g.Cfg().MustGet(ctx, "milvus_compat_check", CompatModeStrict).String()
+	if mode == CompatModeOff {
+		return nil
+	}
+	c, err := GetMilvusClient(ctx)
+	if err != nil {
+		return err
+	}
+	eb, err := embedder.DoubaoEmbedding(ctx)
+	if err != nil {
+		return err
+	}
+	err = CheckEmbeddingCompat(ctx, c, eb, embedder.ModelName(ctx))
+	if err == nil {
+		return nil
+	}
+	if mode == CompatModeWarn {
+		log.Printf("[WARN] ================ 向量库兼容性校验未通过 ================")
+		log.Printf("[WARN] %v", err)
+		log.Printf("[WARN] 检索与导入结果可能不可用，请尽快处理")
+		return nil
+	}
+	return err
+}
+
+// CheckEmbeddingCompat 探测向量模型的实际输出维度，并与 collection 的 schema、索引度量和记录的模型名称比对
+func CheckEmbeddingCompat(ctx context.Context, c cli.Client, eb embedding.Embedder, model string) error {
+	vectors, err := eb.EmbedStrings(ctx, []string{compatProbeText})
+	if err != nil {
+		return fmt.Errorf("failed to probe embedding dimension: %w", err)
+	}
+	if len(vectors) != 1 || len(vectors[0]) == 0 {
+		return fmt.Errorf("failed to probe embedding dimension: embedder returned no vector")
+	}
+	embeddingDim := len(vectors[0])
+
+	conf := GetMilvusConfig(ctx)
+	collection, err := c.DescribeCollection(ctx, conf.Collection)
+	if err != nil {
+		return fmt.Errorf("failed to describe collection %s: %w", conf.Collection, err)
+	}
+
+	var problems []string
+	// 1. 向量维度
+	collectionDim, err := vectorFieldDim(collection.Schema)
+	if err != nil {
+		problems = append(problems, err.Error())
+	} else if collectionDim != embeddingDim*common.BinaryVectorBitsPerDim {
+		problems = append(problems, fmt.Sprintf(
+			"向量维度不一致: 模型实际输出 %d 维(二进制向量 %d 位)，collection 字段 vector 为 %d 位(对应 %d 维)",
+			embeddingDim, embeddingDim*common.BinaryVectorBitsPerDim,
+			collectionDim, collectionDim/common.BinaryVectorBitsPerDim))
+	}
+
+	// 2. 索引度量
+	indexes, err := c.DescribeIndex(ctx, conf.Collection, "vector")
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("无法读取 vector 字段索引: %v", err))
+	} else {
+		for _, idx := range indexes {
+			metric := idx.Params()["metric_type"]
+			if metric != "" && !strings.EqualFold(metric, string(entity.HAMMING)) {
+				problems = append(problems, fmt.Sprintf("索引度量不一致: vector 字段索引为 %s，检索使用 %s", metric, entity.HAMMING))
+			}
+		}
+	}
+
+	// 3. 建表时记录的模型名称
+	recordedModel := collection.Properties[common.MilvusPropEmbeddingModel]
+	if recordedModel == "" {
+		log.Printf("[WARN] collection %s 未记录向量模型名称，无法校验模型是否变更", conf.Collection)
+	} else if recordedModel != model {
+		problems = append(problems, fmt.Sprintf("向量模型不一致: collection 使用 %s 构建，当前配置为 %s", recordedModel, model))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("embedder 与 Milvus collection %s.%s 不兼容:\n  - %s\n处理方式: 恢复 doubao_embedding_model 的 model/dimensions 配置与建表时一致(model=%s, dimensions=%s)，"+
+		"或删除该 collection 后重启服务并重新上传全部文档；确认风险后可将 milvus_compat_check 设为 warn 临时跳过",
+		conf.DBName, conf.Collection, strings.Join(problems, "\n  - "),
+		orUnknown(recordedModel), orUnknown(collection.Properties[common.MilvusPropEmbeddingDim]))
+}
+
+// vectorFieldDim 读取 vector 字段的维度
+func vectorFieldDim(s *entity.Schema) (int, error) {
+	if s == nil {
+		return 0, fmt.Errorf("collection schema 为空")
+	}
+	for _, field := range s.Fields {
+		if field.Name == "vector" {
+			dim, err := strconv.Atoi(field.TypeParams["dim"])
+			if err != nil {
+				return 0, fmt.Errorf("无法解析 vector 字段维度 %q: %v", field.TypeParams["dim"], err)
+			}
+			return dim, nil
+		}
+	}
+	return 0, fmt.Errorf("collection schema 中缺少 vector 字段")
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "未知"
+	}
+	return s
+}
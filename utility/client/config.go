@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/NuyoahCh/eocall/utility/common"
+	"github.com/gogf/gf/v2/frame/g"
+	cli "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
+	"os"
+	"time"
+)
+
+// MilvusConfig Milvus 连接配置，对应配置文件中的 milvus 节点
+type MilvusConfig struct {
+	Address        string        // 服务地址，如 localhost:19530
+	Username       string        // 用户名
+	Password       string        // 密码
+	APIKey         string        // API Key（Zilliz Cloud 等托管服务）
+	EnableTLS      bool          // 是否启用 TLS
+	TLSCACert      string        // 自定义 CA 证书路径，为空时使用系统证书
+	TLSServerName  string        // TLS 校验使用的服务端名称
+	DBName         string        // 数据库名称
+	Collection     string        // collection 名称
+	ShardNum       int32         // 创建 collection 时的分片数
+	DialTimeout    time.Duration // 建立连接的超时时间
+	RequestTimeout time.Duration // 单次请求的超时时间，调用方未设置 deadline 时生效
+
+	ReconnectMaxAttempts int           // 建连失败的最大尝试次数
+	ReconnectBackoff     time.Duration // 重连的初始退避时间
+	ReconnectMaxBackoff  time.Duration // 重连的最大退避时间
+	HealthCheckInterval  time.Duration // 健康检查间隔，0 表示关闭
+}
+
+// GetMilvusConfig 从配置文件读取 Milvus 配置，未配置的项使用默认值
+func GetMilvusConfig(ctx context.Context) *MilvusConfig {
+	cfg := g.Cfg()
+	c := &MilvusConfig{
+		Address:        cfg.MustGet(ctx, "milvus.address", common.DefaultMilvusAddress).String(),
+		Username:       cfg.MustGet(ctx, "milvus.username").String(),
+		Password:       cfg.MustGet(ctx, "milvus.password").String(),
+		APIKey:         cfg.MustGet(ctx, "milvus.api_key").String(),
+		EnableTLS:      cfg.MustGet(ctx, "milvus.enable_tls").Bool(),
+		TLSCACert:      cfg.MustGet(ctx, "milvus.tls_ca_cert").String(),
+		TLSServerName:  cfg.MustGet(ctx, "milvus.tls_server_name").String(),
+		DBName:         cfg.MustGet(ctx, "milvus.db_name", common.DefaultMilvusDBName).String(),
+		Collection:     cfg.MustGet(ctx, "milvus.collection", common.DefaultMilvusCollectionName).String(),
+		ShardNum:       cfg.MustGet(ctx, "milvus.shard_num").Int32(),
+		DialTimeout:    cfg.MustGet(ctx, "milvus.dial_timeout", common.DefaultMilvusDialTimeout).Duration(),
+		RequestTimeout: cfg.MustGet(ctx, "milvus.request_timeout", common.DefaultMilvusRequestTimeout).Duration(),
+
+		ReconnectMaxAttempts: cfg.MustGet(ctx, "milvus.reconnect_max_attempts", 5).Int(),
+		ReconnectBackoff:     cfg.MustGet(ctx, "milvus.reconnect_backoff", "500ms").Duration(),
+		ReconnectMaxBackoff:  cfg.MustGet(ctx, "milvus.reconnect_max_backoff", "10s").Duration(),
+		HealthCheckInterval:  cfg.MustGet(ctx, "milvus.health_check_interval", "30s").Duration(),
+	}
+	if c.ShardNum <= 0 {
+		c.ShardNum = 1
+	}
+	if c.ReconnectMaxAttempts <= 0 {
+		c.ReconnectMaxAttempts = 1
+	}
+	if c.ReconnectBackoff <= 0 {
+		c.ReconnectBackoff = 500 * time.Millisecond
+	}
+	if c.ReconnectMaxBackoff < c.ReconnectBackoff {
+		c.ReconnectMaxBackoff = c.ReconnectBackoff
+	}
+	return c
+}
+
+// clientConfig 构建连接指定数据库的 SDK 配置
+func (c *MilvusConfig) clientConfig(dbName string) (cli.Config, error) {
+	config := cli.Config{
+		Address:       c.Address,
+		Username:      c.Username,
+		Password:      c.Password,
+		APIKey:        c.APIKey,
+		DBName:        dbName,
+		EnableTLSAuth: c.EnableTLS,
+	}
+	dialOptions := append([]grpc.DialOption{}, cli.DefaultGrpcOpts...)
+	// 连接断开后 gRPC 按该退避策略自动重连
+	dialOptions = append(dialOptions, grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff: backoff.Config{
+			BaseDelay:  c.ReconnectBackoff,
+			Multiplier: 1.6,
+			Jitter:     0.2,
+			MaxDelay:   c.ReconnectMaxBackoff,
+		},
+		MinConnectTimeout: 3 * time.Second,
+	}))
+	if c.EnableTLS && (c.TLSCACert != "" || c.TLSServerName != "") {
+		tlsConfig := &tls.Config{ServerName: c.TLSServerName}
+		if c.TLSCACert != "" {
+			pem, err := os.ReadFile(c.TLSCACert)
+			if err != nil {
+				return config, fmt.Errorf("failed to read milvus tls ca cert: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return config, fmt.Errorf("invalid milvus tls ca cert: %s", c.TLSCACert)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	if c.RequestTimeout > 0 {
+		dialOptions = append(dialOptions, grpc.WithChainUnaryInterceptor(requestTimeoutInterceptor(c.RequestTimeout)))
+	}
+	config.DialOptions = dialOptions
+	return config, nil
+}
+
+// dial 连接指定数据库，连接过程受 DialTimeout 限制
+func (c *MilvusConfig) dial(ctx context.Context, dbName string) (cli.Client, error) {
+	config, err := c.clientConfig(dbName)
+	if err != nil {
+		return nil, err
+	}
+	if c.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.DialTimeout)
+		defer cancel()
+	}
+	return cli.NewClient(ctx, config)
+}
+
+// requestTimeoutInterceptor 为没有 deadline 的请求设置默认超时
+func requestTimeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
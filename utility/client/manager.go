@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"errors"
+	cli "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrMilvusClientClosed 共享客户端已关闭
+var ErrMilvusClientClosed = errors.New("milvus client manager closed")
+
+// MilvusManager 进程级的 Milvus 客户端管理器
+// 首次获取时建立连接并初始化数据库与 collection，之后复用同一连接；
+// 建连失败按指数退避重试且不缓存失败结果，连接断开后由 gRPC 按配置的退避策略自动重连。
+// 建连期间不持有锁，并发获取的调用方等待同一次建连的结果。
+type MilvusManager struct {
+	mu      sync.Mutex
+	client  cli.Client
+	dialing *milvusDial // 正在进行的建连，为空表示没有
+	closed  bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// milvusDial 一次建连，done 关闭后 client 与 err 可读
+type milvusDial struct {
+	done   chan struct{}
+	client cli.Client
+	err    error
+}
+
+var defaultMilvusManager = &MilvusManager{}
+
+// GetMilvusClient 获取进程级共享的 Milvus 客户端，调用方不要关闭返回的客户端
+func GetMilvusClient(ctx context.Context) (cli.Client, error) {
+	return defaultMilvusManager.Get(ctx)
+}
+
+// CloseMilvusClient 关闭进程级共享的 Milvus 客户端，服务退出时调用
+func CloseMilvusClient() error {
+	return defaultMilvusManager.Close()
+}
+
+// Get 获取客户端，尚未连接时按退避策略建立连接
+// 第一个调用方负责建连，其余调用方等待其结果或自身的 ctx 结束；只在发布客户端时持有锁
+// 建连不随第一个调用方的 ctx 取消，否则该请求结束时其余等待的调用方会一同失败，每次尝试受 dial_timeout 限制
+func (m *MilvusManager) Get(ctx context.Context) (cli.Client, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrMilvusClientClosed
+	}
+	if m.client != nil {
+		c := m.client
+		m.mu.Unlock()
+		return c, nil
+	}
+	d, leader := m.dialing, false
+	if d == nil {
+		d, leader = &milvusDial{done: make(chan struct{})}, true
+		m.dialing = d
+	}
+	m.mu.Unlock()
+
+	if leader {
+		c, err := m.connect(context.WithoutCancel(ctx))
+		d.client, d.err = m.publish(ctx, d, c, err)
+		close(d.done)
+	}
+	select {
+	case <-d.done:
+		return d.client, d.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// publish 保存建连结果并启动健康检查，建连期间管理器已关闭时关闭新建的客户端
+func (m *MilvusManager) publish(ctx context.Context, d *milvusDial, c cli.Client, err error) (cli.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.dialing == d {
+		m.dialing = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.closed {
+		_ = c.Close()
+		return nil, ErrMilvusClientClosed
+	}
+	m.client = c
+	if interval := GetMilvusConfig(ctx).HealthCheckInterval; interval > 0 {
+		m.stop = make(chan struct{})
+		m.done = make(chan struct{})
+		go m.healthLoop(c, interval, m.stop, m.done)
+	}
+	return c, nil
+}
+
+// Close 关闭客户端并停止健康检查，关闭后 Get 返回 ErrMilvusClientClosed
+func (m *MilvusManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	if m.stop != nil {
+		close(m.stop)
+		<-m.done
+	}
+	if m.client == nil {
+		return nil
+	}
+	err := m.client.Close()
+	m.client = nil
+	return err
+}
+
+// connect 建立连接并初始化 schema，每次尝试受 DialTimeout 限制，失败时按指数退避重试
+func (m *MilvusManager) connect(ctx context.Context) (cli.Client, error) {
+	conf := GetMilvusConfig(ctx)
+	backoff := conf.ReconnectBackoff
+	var lastErr error
+	for attempt := 1; attempt <= conf.ReconnectMaxAttempts; attempt++ {
+		c, err := m.attempt(ctx, conf.DialTimeout)
+		if err == nil {
+			return c, nil
+		}
+		lastErr = err
+		if attempt == conf.ReconnectMaxAttempts {
+			break
+		}
+		log.Printf("[warn] connect milvus %s failed (attempt %d/%d), retry in %s: %v",
+			conf.Address, attempt, conf.ReconnectMaxAttempts, backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > conf.ReconnectMaxBackoff {
+			backoff = conf.ReconnectMaxBackoff
+		}
+	}
+	return nil, lastErr
+}
+
+// attempt 建立一次连接，timeout 不大于 0 时不限制
+func (m *MilvusManager) attempt(ctx context.Context, timeout time.Duration) (cli.Client, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return NewMilvusClient(ctx)
+}
+
+// healthLoop 定期检查服务状态，仅在状态变化时打印日志
+func (m *MilvusManager) healthLoop(c cli.Client, interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	healthy := true
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			state, err := c.CheckHealth(ctx)
+			cancel()
+			ok := err == nil && state != nil && state.IsHealthy
+			if ok != healthy {
+				if ok {
+					log.Printf("[info] milvus connection recovered")
+				} else {
+					log.Printf("[warn] milvus unhealthy, waiting for reconnect: err=%v, state=%+v", err, state)
+				}
+				healthy = ok
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package closer
+
+import (
+	"context"
+	"sync"
+)
+
+// Group 收集一次构建中打开的资源，构建结果不再使用时统一释放
+type Group struct {
+	mu  sync.Mutex
+	fns []func() error
+}
+
+type groupKey struct{}
+
+// WithGroup 将 Group 写入上下文，构建期间打开的资源通过 Add 登记到其中
+func WithGroup(ctx context.Context, g *Group) context.Context {
+	return context.WithValue(ctx, groupKey{}, g)
+}
+
+// Add 将资源的释放函数登记到上下文中的 Group，上下文中没有 Group 时返回 false，资源随进程存活
+func Add(ctx context.Context, fn func() error) bool {
+	g, ok := ctx.Value(groupKey{}).(*Group)
+	if !ok || g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.fns = append(g.fns, fn)
+	return true
+}
+
+// Close 按登记的逆序释放全部资源，返回第一个错误；重复调用时不再释放
+func (g *Group) Close() error {
+	g.mu.Lock()
+	fns := g.fns
+	g.fns = nil
+	g.mu.Unlock()
+	var firstErr error
+	for i := len(fns) - 1; i >= 0; i-- {
+		if err := fns[i](); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -1,9 +1,26 @@
 package common
 
-// Milvus 数据库相关配置
+// Milvus 相关默认配置，可通过配置文件中的 milvus 节点覆盖
 const (
-	MilvusDBName         = "agent"
-	MilvusCollectionName = "biz"
+	DefaultMilvusAddress        = "localhost:19530"
+	DefaultMilvusDBName         = "agent"
+	DefaultMilvusCollectionName = "biz"
+	DefaultMilvusDialTimeout    = "10s"
+	DefaultMilvusRequestTimeout = "30s"
+)
+
+// 向量化相关配置
+const (
+	// EmbeddingDim 默认的向量维度
+	EmbeddingDim = 2048
+	// BinaryVectorBitsPerDim 每个 float32 维度写入 Milvus 二进制向量后占用的位数
+	BinaryVectorBitsPerDim = 32
+)
+
+// Milvus collection 属性，创建时记录向量模型信息，用于启动时的兼容性校验
+const (
+	MilvusPropEmbeddingModel = "eocall.embedding_model"
+	MilvusPropEmbeddingDim   = "eocall.embedding_dim"
 )
 
 // FileDir 文件夹路径名称
@@ -3,8 +3,8 @@ package log_call_back
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/cloudwego/eino/callbacks"
+	"github.com/gogf/gf/v2/frame/g"
 )
 
 // LogCallbackConfig 日志回调配置
@@ -13,7 +13,7 @@ type LogCallbackConfig struct {
 	Debug  bool
 }
 
-// LogCallback 日志回调方法
+// LogCallback 日志回调方法，以 debug 级别写入框架日志，不占用标准输出
 func LogCallback(config *LogCallbackConfig) callbacks.Handler {
 	if config == nil {
 		config = &LogCallbackConfig{
@@ -23,7 +23,7 @@ func LogCallback(config *LogCallbackConfig) callbacks.Handler {
 
 	builder := callbacks.NewHandlerBuilder()
 	builder.OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
-		fmt.Printf("[view start]:[%s:%s:%s]\n", info.Component, info.Type, info.Name)
+		g.Log().Debugf(ctx, "[view start]:[%s:%s:%s]", info.Component, info.Type, info.Name)
 		if config.Detail {
 			var b []byte
 			if config.Debug {
@@ -31,12 +31,12 @@ func LogCallback(config *LogCallbackConfig) callbacks.Handler {
 			} else {
 				b, _ = json.Marshal(input)
 			}
-			fmt.Printf("%s\n", string(b))
+			g.Log().Debug(ctx, string(b))
 		}
 		return ctx
 	})
 	builder.OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
-		fmt.Printf("[view end]:[%s:%s:%s]\n", info.Component, info.Type, info.Name)
+		g.Log().Debugf(ctx, "[view end]:[%s:%s:%s]", info.Component, info.Type, info.Name)
 		return ctx
 	})
 	return builder.Build()
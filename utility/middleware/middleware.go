@@ -1,6 +1,13 @@
 package middleware
 
-import "github.com/gogf/gf/v2/net/ghttp"
+import (
+	"github.com/NuyoahCh/eocall/utility/auth"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"net/http"
+	"strings"
+)
 
 // CORSMiddleware 处理CORS跨域请求
 func CORSMiddleware(r *ghttp.Request) {
@@ -8,6 +15,21 @@ func CORSMiddleware(r *ghttp.Request) {
 	r.Middleware.Next()
 }
 
+// AuthMiddleware 解析 Authorization: Bearer <token> 中的访问令牌，将调用方身份写入上下文
+// 配置 auth.required 后拒绝未携带有效令牌的请求
+func AuthMiddleware(r *ghttp.Request) {
+	ctx := r.Context()
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if id, ok := auth.Authenticate(ctx, token); ok {
+		r.SetCtx(auth.WithIdentity(ctx, id))
+	} else if auth.Required(ctx) {
+		r.Response.WriteHeader(http.StatusUnauthorized)
+		r.SetError(gerror.NewCode(gcode.CodeNotAuthorized, "未授权的访问"))
+		return
+	}
+	r.Middleware.Next()
+}
+
 // ResponseMiddleware 处理响应信息
 func ResponseMiddleware(r *ghttp.Request) {
 	r.Middleware.Next()